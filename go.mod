@@ -3,6 +3,6 @@ module lazarus-c
 go 1.21.6
 
 require (
-	github.com/alecthomas/participle/v2 v2.1.1 // indirect
-	github.com/xlab/treeprint v1.2.0 // indirect
+	github.com/alecthomas/participle/v2 v2.1.1
+	github.com/xlab/treeprint v1.2.0
 )
@@ -3,21 +3,38 @@ package ast
 
 import (
 	"github.com/alecthomas/participle/v2"
+	plexer "github.com/alecthomas/participle/v2/lexer"
 	"io"
 	"lazarus-c/src/lexer"
 )
 
-type node interface {
+type Node interface {
 	getPos() lexer.Position
+	getEndPos() lexer.Position
 }
 
+// Parser backtracks without bound, as a function definition and a
+// declaration share their specifiers and declarator and only the token after
+// the declarator tells them apart. Backtracking is cheap; a repetition that
+// must match at least once is not, as participle formats the grammar of the
+// whole repetition into the error it fails with. The grammar thus spells
+// x+ as x x*. BenchmarkParse measures the parser on dense code.
 var Parser = participle.MustBuild[TranslationUnit](
-	participle.UseLookahead(1),
-	participle.Unquote("String", "Char"),
+	participle.UseLookahead(participle.MaxLookahead),
+	participle.Map(unquote, "String", "Char"),
 	participle.Lexer(lexer.Lexer),
 	participle.Elide("Whitespace", "Comment"),
 )
 
+func unquote(token plexer.Token) (plexer.Token, error) {
+	var value, err = lexer.Unquote(token.Value)
+	if err != nil {
+		return token, participle.Errorf(token.Pos, "%s", err)
+	}
+	token.Value = value
+	return token, nil
+}
+
 func ParseString(s string) (*TranslationUnit, error) {
 	program, err := Parser.ParseString("", s)
 	if err != nil {
@@ -26,6 +43,15 @@ func ParseString(s string) (*TranslationUnit, error) {
 	return program, nil
 }
 
+// ParseBytes parses src, reporting positions in the named file.
+func ParseBytes(filename string, src []byte) (*TranslationUnit, error) {
+	program, err := Parser.ParseBytes(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
 func Parse(r io.Reader) (*TranslationUnit, error) {
 	program, err := Parser.Parse("", r)
 	if err != nil {
@@ -36,17 +62,20 @@ func Parse(r io.Reader) (*TranslationUnit, error) {
 
 type TranslationUnit struct {
 	Pos                  lexer.Position
-	ExternalDeclarations []*ExternalDeclaration `parser:"@@+"`
+	EndPos               lexer.Position
+	ExternalDeclarations []*ExternalDeclaration `parser:"@@ @@*"`
 }
 
 type ExternalDeclaration struct {
 	Pos                lexer.Position
+	EndPos             lexer.Position
 	FunctionDefinition *FunctionDefinition `parser:"@@"`
 	Declaration        *Declaration        `parser:"| @@"`
 }
 
 type FunctionDefinition struct {
 	Pos                   lexer.Position
+	EndPos                lexer.Position
 	DeclarationSpecifiers *DeclarationSpecifiers `parser:"@@?"`
 	Declarator            *Declarator            `parser:"@@"`
 	DeclarationList       *DeclarationList       `parser:"@@?"`
@@ -55,17 +84,20 @@ type FunctionDefinition struct {
 
 type CompoundStatement struct {
 	Pos             lexer.Position
+	EndPos          lexer.Position
 	DeclarationList *DeclarationList `parser:"'{' @@?"`
 	StatementList   *StatementList   `parser:"@@? '}'"`
 }
 
 type StatementList struct {
 	Pos        lexer.Position
-	Statements []*Statement `parser:"@@+"`
+	EndPos     lexer.Position
+	Statements []*Statement `parser:"@@ @@*"`
 }
 
 type Statement struct {
 	Pos                 lexer.Position
+	EndPos              lexer.Position
 	LabeledStatement    *LabeledStatement    `parser:"@@"`
 	CompoundStatement   *CompoundStatement   `parser:"| @@"`
 	ExpressionStatement *ExpressionStatement `parser:"| @@"`
@@ -76,20 +108,24 @@ type Statement struct {
 
 type LabeledStatement struct {
 	Pos              lexer.Position
-	GotoLabel        *string             `parser:"@Ident"`
-	GotoStatement    *Statement          `parser:"':' @@"`
-	CaseExpression   *ConstantExpression `parser:"| 'case' @@"`
+	EndPos           lexer.Position
+	GotoLabel        *string             `parser:"@Ident ':'"`
+	Attributes       *Attributes         `parser:"@@?"`
+	GotoStatement    *Statement          `parser:"@@"`
+	CaseExpression   *ConstantExpression `parser:"| 'case' @@ ':'"`
 	CaseStatement    *Statement          `parser:"@@"`
-	DefaultStatement *Statement          `parser:"| 'default' @@"`
+	DefaultStatement *Statement          `parser:"| 'default' ':' @@"`
 }
 
 type ExpressionStatement struct {
 	Pos        lexer.Position
+	EndPos     lexer.Position
 	Expression *Expression `parser:"@@? ';'"`
 }
 
 type SelectionStatement struct {
 	Pos              lexer.Position
+	EndPos           lexer.Position
 	IfTest           *Expression `parser:"'if' '(' @@ ')'"`
 	IfBody           *Statement  `parser:"@@"`
 	ElseBody         *Statement  `parser:"( 'else' @@ )?"`
@@ -99,6 +135,7 @@ type SelectionStatement struct {
 
 type IterationStatement struct {
 	Pos       lexer.Position
+	EndPos    lexer.Position
 	WhileTest *Expression          `parser:"'while' '(' @@ ')'"`
 	WhileBody *Statement           `parser:"@@"`
 	DoBody    *Statement           `parser:"| 'do' @@"`
@@ -111,6 +148,7 @@ type IterationStatement struct {
 
 type JumpStatement struct {
 	Pos              lexer.Position
+	EndPos           lexer.Position
 	GotoIdent        *string     `parser:"'goto' @Ident ';'"`
 	IsContinue       bool        `parser:"| @'continue' ';'"`
 	IsBreak          bool        `parser:"| @'break' ';'"`
@@ -119,16 +157,19 @@ type JumpStatement struct {
 }
 
 type DeclarationSpecifiers struct {
-	Pos lexer.Position
+	Pos    lexer.Position
+	EndPos lexer.Position
 	// Typedef should be implemented inside of StorageClassSpecifier in the future
 	StorageClassSpecifier *string                `parser:"( ( @'extern' | @'static' | @'auto' | @'register' )"`
 	TypeSpecifier         *TypeSpecifier         `parser:"| @@"`
-	TypeQualifier         *TypeQualifier         `parser:"| @@ )"`
+	TypeQualifier         *TypeQualifier         `parser:"| @@"`
+	Attributes            *Attributes            `parser:"| @@ )"`
 	DeclarationSpecifiers *DeclarationSpecifiers `parser:"@@?"`
 }
 
 type TypeSpecifier struct {
-	Pos lexer.Position
+	Pos    lexer.Position
+	EndPos lexer.Position
 	// Custom types created with typedef should be added here.
 	TypeSpecifier          *string                 `parser:"( @'void' | @'char' | @'short' | @'int' | @'long' | @'float' | @'double' | @'signed' | @'unsigned' )"`
 	StructOrUnionSpecifier *StructOrUnionSpecifier `parser:"| @@"`
@@ -137,164 +178,234 @@ type TypeSpecifier struct {
 
 type StructOrUnionSpecifier struct {
 	Pos                   lexer.Position
-	StructOrUnion         *string                `parser:"@'struct' | @'union'"`
+	EndPos                lexer.Position
+	StructOrUnion         *string                `parser:"( @'struct' | @'union' )"`
+	Attributes            *Attributes            `parser:"@@?"`
 	Identifier            *string                `parser:"( ( @Ident"`
 	StructDeclarationList *StructDeclarationList `parser:"( '{' @@ '}' )? ) | '{' @@ '}' )"`
+	TrailingAttributes    *Attributes            `parser:"@@?"`
 }
 
 type StructDeclarationList struct {
 	Pos                lexer.Position
-	StructDeclarations []*StructDeclaration `parser:"@@+"`
+	EndPos             lexer.Position
+	StructDeclarations []*StructDeclaration `parser:"@@ @@*"`
 }
 
 type StructDeclaration struct {
 	Pos                    lexer.Position
+	EndPos                 lexer.Position
 	SpecifierQualifierList *SpecifierQualifierList `parser:"@@"`
 	StructDeclaratorList   *StructDeclaratorList   `parser:"@@ ';'"`
 }
 
 type SpecifierQualifierList struct {
-	Pos           lexer.Position
-	TypeSpecifier *TypeSpecifier `parser:"( @@"`
-	TypeQualifier *TypeQualifier `parser:"| @@ )+"`
+	Pos                    lexer.Position
+	EndPos                 lexer.Position
+	TypeSpecifier          *TypeSpecifier          `parser:"( @@"`
+	TypeQualifier          *TypeQualifier          `parser:"| @@ )"`
+	SpecifierQualifierList *SpecifierQualifierList `parser:"@@?"`
 }
 
 type StructDeclaratorList struct {
 	Pos               lexer.Position
+	EndPos            lexer.Position
 	StructDeclarators []*StructDeclarator `parser:"@@ ( ',' @@ )*"`
 }
 
 type StructDeclarator struct {
 	Pos                lexer.Position
-	Declarator         *Declarator         `parser:"@@"`
-	ConstantExpression *ConstantExpression `parser:"( ':' @@ )? | @@"`
+	EndPos             lexer.Position
+	Declarator         *Declarator         `parser:"( @@"`
+	ConstantExpression *ConstantExpression `parser:"( ':' @@ )? | ':' @@ )"`
+	Attributes         *Attributes         `parser:"@@?"`
 }
 
 type EnumSpecifier struct {
 	Pos            lexer.Position
-	Identifier     *string         `parser:"'enum' ( ( @Ident"`
-	EnumeratorList *EnumeratorList `parser:"( '{' @@ '}' )? ) | '{' @@ '}' )"`
+	EndPos         lexer.Position
+	Identifier     *string         `parser:"'enum' (?= Ident | ':' | '{' ) @Ident?"`
+	EnumBase       *EnumBase       `parser:"( (?= ':' ~( ';' | ',' | '{' | '}' | ')' )+ '{' ) ':' @@ )?"`
+	EnumeratorList *EnumeratorList `parser:"( '{' @@ ','? '}' )?"`
+}
+
+// EnumBase is a dialect extension fixing the underlying type of an
+// enumeration, as in enum Color : uint8_t { ... }. Until typedef names
+// are supported, the exact-width integer types of <stdint.h> are known by
+// name here.
+type EnumBase struct {
+	Pos                    lexer.Position
+	EndPos                 lexer.Position
+	SpecifierQualifierList *SpecifierQualifierList `parser:"@@"`
+	Identifier             *string                 `parser:"| @Ident"`
 }
 
 type EnumeratorList struct {
 	Pos         lexer.Position
+	EndPos      lexer.Position
 	Enumerators []*Enumerator `parser:"@@ ( ',' @@ )*"`
 }
 
 type Enumerator struct {
 	Pos                lexer.Position
+	EndPos             lexer.Position
 	Identifier         *string             `parser:"@Ident"`
 	ConstantExpression *ConstantExpression `parser:"( '=' @@ )?"`
 }
 
+// Attributes are a dialect extension in the style of GCC, as in
+// __attribute__((packed, aligned(8))).
+type Attributes struct {
+	Pos        lexer.Position
+	EndPos     lexer.Position
+	Attributes []*Attribute `parser:"'__attribute__' '(' '(' @@ ( ',' @@ )* ')' ')' ( '__attribute__' '(' '(' @@ ( ',' @@ )* ')' ')' )*"`
+}
+
+type Attribute struct {
+	Pos       lexer.Position
+	EndPos    lexer.Position
+	Name      *string               `parser:"@Ident"`
+	Arguments []*ConstantExpression `parser:"( '(' @@ ( ',' @@ )* ')' )?"`
+}
+
 type DeclarationList struct {
 	Pos          lexer.Position
-	Declarations []*Declaration `parser:"@@+"`
+	EndPos       lexer.Position
+	Declarations []*Declaration `parser:"@@ @@*"`
 }
 
 type Declaration struct {
 	Pos                   lexer.Position
+	EndPos                lexer.Position
 	DeclarationSpecifiers *DeclarationSpecifiers `parser:"@@"`
 	InitDeclaratorList    *InitDeclaratorList    `parser:"@@? ';'"`
 }
 
 type InitDeclaratorList struct {
 	Pos             lexer.Position
+	EndPos          lexer.Position
 	InitDeclarators []*InitDeclarator `parser:"@@ ( ',' @@ )*"`
 }
 
 type InitDeclarator struct {
 	Pos         lexer.Position
+	EndPos      lexer.Position
 	Declarator  *Declarator  `parser:"@@"`
+	Attributes  *Attributes  `parser:"@@?"`
 	Initializer *Initializer `parser:"( '=' @@ )?"`
 }
 
 type Initializer struct {
 	Pos                  lexer.Position
+	EndPos               lexer.Position
 	AssignmentExpression *AssignmentExpression `parser:"@@"`
 	InitializerList      *InitializerList      `parser:"| '{' @@ ','? '}'"`
 }
 
 type InitializerList struct {
 	Pos          lexer.Position
+	EndPos       lexer.Position
 	Initializers []*Initializer `parser:"@@ ( ',' @@ )*"`
 }
 
 type Declarator struct {
 	Pos               lexer.Position
+	EndPos            lexer.Position
 	Pointer           *Pointer            `parser:"@@?"`
 	DirectDeclarators []*DirectDeclarator `parser:"@@"`
 }
 
 type Pointer struct {
 	Pos               lexer.Position
-	TypeQualifierList *TypeQualifierList `parser:"'*' ( @@"`
-	Pointer           *Pointer           `parser:"@@? | @@ )"`
+	EndPos            lexer.Position
+	TypeQualifierList *TypeQualifierList `parser:"'*' @@?"`
+	Pointer           *Pointer           `parser:"@@?"`
 }
 
 type TypeQualifierList struct {
 	Pos            lexer.Position
-	TypeQualifiers []*TypeQualifier `parser:"@@+"`
+	EndPos         lexer.Position
+	TypeQualifiers []*TypeQualifier `parser:"@@ @@*"`
 }
 
 type TypeQualifier struct {
 	Pos       lexer.Position
+	EndPos    lexer.Position
 	Qualifier *string `parser:"@'const' | @'volatile'"`
 }
 
 type DirectDeclarator struct {
 	Pos                lexer.Position
-	Identifier         *string               `parser:"( @Ident"`
-	Declarator         *Declarator           `parser:"| '(' @@ ')' )"`
-	ArrayLength        []*ConstantExpression `parser:"( '[' @@? ']'"`
-	ParameterTypeLists []*ParameterTypeList  `parser:"| '(' ( @@"`
-	IdentifierLists    []*IdentifierList     `parser:"| @@ )? ')' )*"`
+	EndPos             lexer.Position
+	Identifier         *string             `parser:"( @Ident"`
+	Declarator         *Declarator         `parser:"| '(' @@ ')' )"`
+	DeclaratorSuffixes []*DeclaratorSuffix `parser:"@@*"`
+}
+
+// DeclaratorSuffix is one array or function suffix following the name, or
+// the parenthesized inner declarator, of a direct declarator. Empty
+// brackets and parentheses are recorded by IsArray and IsFunction.
+type DeclaratorSuffix struct {
+	Pos               lexer.Position
+	EndPos            lexer.Position
+	IsArray           bool                `parser:"@'['"`
+	ArrayLength       *ConstantExpression `parser:"@@? ']'"`
+	IsFunction        bool                `parser:"| @'('"`
+	ParameterTypeList *ParameterTypeList  `parser:"( @@"`
+	IdentifierList    *IdentifierList     `parser:"| @@ )? ')'"`
 }
 
 type IdentifierList struct {
 	Pos         lexer.Position
-	Identifiers []*string `parser:"@Ident ( ',' @Ident )*"`
+	EndPos      lexer.Position
+	Identifiers []string `parser:"@Ident ( ',' @Ident )*"`
 }
 
 type ParameterTypeList struct {
 	Pos           lexer.Position
+	EndPos        lexer.Position
 	ParameterList *ParameterList `parser:"@@"`
 	Ellipsis      bool           `parser:"( ',' @'...' )?"`
 }
 
 type ParameterList struct {
 	Pos                   lexer.Position
+	EndPos                lexer.Position
 	ParameterDeclarations []*ParameterDeclaration `parser:"@@ ( ',' @@ )*"`
 }
 
 type ParameterDeclaration struct {
 	Pos                   lexer.Position
+	EndPos                lexer.Position
 	DeclarationSpecifiers *DeclarationSpecifiers `parser:"@@"`
 	Declarator            *Declarator            `parser:"( @@"`
 	AbstractDeclarator    *AbstractDeclarator    `parser:"| @@ )?"`
+	Attributes            *Attributes            `parser:"@@?"`
 }
 
 type AbstractDeclarator struct {
 	Pos                      lexer.Position
+	EndPos                   lexer.Position
 	Pointer                  *Pointer                  `parser:"@@"`
 	DirectAbstractDeclarator *DirectAbstractDeclarator `parser:"@@? | @@"`
 }
 
 type DirectAbstractDeclarator struct {
-	Pos                      lexer.Position
-	AbstractDeclarator       *AbstractDeclarator       `parser:"'(' @@ ')'"`
-	DirectAbstractDeclarator *DirectAbstractDeclarator `parser:"| @@?"`
-	ConstantExpression       *ConstantExpression       `parser:"( '[' @@? ']'"`
-	ParameterTypeList        *ParameterTypeList        `parser:"| '(' @@? ')' )"`
+	Pos                lexer.Position
+	EndPos             lexer.Position
+	AbstractDeclarator *AbstractDeclarator `parser:"'(' @@ ')'"`
+	DeclaratorSuffixes []*DeclaratorSuffix `parser:"@@* | @@ @@*"`
 }
 
 type ConstantExpression struct {
 	Pos                   lexer.Position
+	EndPos                lexer.Position
 	ConditionalExpression *ConditionalExpression `parser:"@@"`
 }
 
 type ConditionalExpression struct {
 	Pos                    lexer.Position
+	EndPos                 lexer.Position
 	LogicalOrExpression    *LogicalOrExpression   `parser:"@@"`
 	TernaryTrueExpression  *Expression            `parser:"( '?' @@"`
 	TernaryFalseExpression *ConditionalExpression `parser:"':' @@ )?"`
@@ -302,107 +413,133 @@ type ConditionalExpression struct {
 
 type LogicalOrExpression struct {
 	Pos                   lexer.Position
+	EndPos                lexer.Position
 	LogicalAndExpressions []*LogicalAndExpression `parser:"@@ ( '||' @@ )*"`
 }
 
 type LogicalAndExpression struct {
 	Pos                    lexer.Position
+	EndPos                 lexer.Position
 	InclusiveOrExpressions []*InclusiveOrExpression `parser:"@@ ( '&&' @@ )*"`
 }
 
 type InclusiveOrExpression struct {
 	Pos                    lexer.Position
+	EndPos                 lexer.Position
 	ExclusiveOrExpressions []*ExclusiveOrExpression `parser:"@@ ( '|' @@ )*"`
 }
 
 type ExclusiveOrExpression struct {
 	Pos            lexer.Position
+	EndPos         lexer.Position
 	AndExpressions []*AndExpression `parser:"@@ ( '^' @@ )*"`
 }
 
 type AndExpression struct {
 	Pos                 lexer.Position
+	EndPos              lexer.Position
 	EqualityExpressions []*EqualityExpression `parser:"@@ ( '&' @@ )*"`
 }
 
 type EqualityExpression struct {
 	Pos                       lexer.Position
+	EndPos                    lexer.Position
 	HeadRelationalExpression  *RelationalExpression   `parser:"@@"`
-	Operators                 []*string               `parser:"( ( @'==' | @'!=' )"`
+	Operators                 []string                `parser:"( ( @'==' | @'!=' )"`
 	TailRelationalExpressions []*RelationalExpression `parser:"@@ )*"`
 }
 
 type RelationalExpression struct {
 	Pos                  lexer.Position
+	EndPos               lexer.Position
 	HeadShiftExpression  *ShiftExpression   `parser:"@@"`
-	Operators            []*string          `parser:"( ( @'<' | @'>' | @'<=' | @'>=' )"`
+	Operators            []string           `parser:"( ( @'<' | @'>' | @'<=' | @'>=' )"`
 	TailShiftExpressions []*ShiftExpression `parser:"@@ )*"`
 }
 
 type ShiftExpression struct {
 	Pos                     lexer.Position
+	EndPos                  lexer.Position
 	HeadAdditiveExpression  *AdditiveExpression   `parser:"@@"`
-	Operators               []*string             `parser:"( ( @'<<' | @'>>' )"`
+	Operators               []string              `parser:"( ( @'<<' | @'>>' )"`
 	TailAdditiveExpressions []*AdditiveExpression `parser:"@@ )*"`
 }
 
 type AdditiveExpression struct {
 	Pos                          lexer.Position
+	EndPos                       lexer.Position
 	HeadMultiplicativeExpression *MultiplicativeExpression   `parser:"@@"`
-	Operators                    []*string                   `parser:"( ( @'+' | @'-' )"`
+	Operators                    []string                    `parser:"( ( @'+' | @'-' )"`
 	TailMultiplicativeExpression []*MultiplicativeExpression `parser:"@@ )*"`
 }
 
 type MultiplicativeExpression struct {
 	Pos                lexer.Position
+	EndPos             lexer.Position
 	HeadCastExpression *CastExpression   `parser:"@@"`
-	Operators          []*string         `parser:"( (@'*' | @'/' | @'%' )"`
+	Operators          []string          `parser:"( (@'*' | @'/' | @'%' )"`
 	TailCastExpression []*CastExpression `parser:"@@ )*"`
 }
 
 type CastExpression struct {
 	Pos             lexer.Position
+	EndPos          lexer.Position
 	TypeNames       []*TypeName      `parser:"( '(' @@ ')' )*"`
 	UnaryExpression *UnaryExpression `parser:"@@"`
 }
 
 type UnaryExpression struct {
 	Pos                 lexer.Position
-	UnaryOperators      []*string          `parser:"( @'++' | @'--' | @'sizeof' )*"`
+	EndPos              lexer.Position
+	SizeOfTypeName      *TypeName          `parser:"'sizeof' '(' @@ ')'"`
+	UnaryOperators      []string           `parser:"| ( @'++' | @'--' | @'sizeof' )*"`
 	PostfixExpression   *PostfixExpression `parser:"( @@"`
-	SizeOfTypeName      *TypeName          `parser:"| 'sizeof' '(' @@ ')'"`
 	UnaryOperatorOnCast *UnaryOperator     `parser:"| @@"`
 	CastExpression      *CastExpression    `parser:"@@ )"`
 }
 
 type UnaryOperator struct {
 	Pos      lexer.Position
+	EndPos   lexer.Position
 	Operator *string `parser:"@'&' | @'*' | @'+' | @'-' | @'~' | @'!'"`
 }
 
 type TypeName struct {
 	Pos                    lexer.Position
+	EndPos                 lexer.Position
 	SpecifierQualifierList *SpecifierQualifierList `parser:"@@"`
 	AbstractDeclarator     *AbstractDeclarator     `parser:"@@?"`
 }
 
 type PostfixExpression struct {
+	Pos               lexer.Position
+	EndPos            lexer.Position
+	PrimaryExpression *PrimaryExpression `parser:"@@"`
+	PostfixOperators  []*PostfixOperator `parser:"@@*"`
+}
+
+// PostfixOperator is one array access, call, member access or
+// increment applied, in order, to a PostfixExpression.
+type PostfixOperator struct {
 	Pos                    lexer.Position
-	PrimaryExpression      *PrimaryExpression        `parser:"@@"`
-	ArrayAccessExpression  *Expression               `parser:"( '[' @@ ']'"`
-	ArgumentExpressionList []*ArgumentExpressionList `parser:"| '(' @@? ')'"`
-	IdentifierAccess       *string                   `parser:"| '.' @Ident"`
-	IdentifierPtrAccess    *string                   `parser:"| '->' @Ident"`
-	Operator               *string                   `parser:"| @'++' | @'--' )*"`
+	EndPos                 lexer.Position
+	ArrayAccessExpression  *Expression             `parser:"'[' @@ ']'"`
+	IsCall                 bool                    `parser:"| @'('"`
+	ArgumentExpressionList *ArgumentExpressionList `parser:"@@? ')'"`
+	IdentifierAccess       *string                 `parser:"| '.' @Ident"`
+	IdentifierPtrAccess    *string                 `parser:"| '->' @Ident"`
+	Operator               *string                 `parser:"| @'++' | @'--'"`
 }
 
 type ArgumentExpressionList struct {
 	Pos                   lexer.Position
+	EndPos                lexer.Position
 	AssignmentExpressions []*AssignmentExpression `parser:"@@ ( ',' @@ )*"`
 }
 
 type PrimaryExpression struct {
 	Pos           lexer.Position
+	EndPos        lexer.Position
 	Identifier    *string     `parser:"@Ident"`
 	Int           *string     `parser:"| @Int"`
 	Float         *string     `parser:"| @Float"`
@@ -413,18 +550,27 @@ type PrimaryExpression struct {
 
 type Expression struct {
 	Pos                   lexer.Position
+	EndPos                lexer.Position
 	AssignmentExpressions []*AssignmentExpression `parser:"@@ ( ',' @@ )*"`
 }
 
+// The lookahead only lets the parser try an assignment when an assignment
+// operator shows up before the end of the expression, looking past
+// brackets and parentheses nested up to four levels deep, as in
+// a[b[c[i]]] = x or (*f(x)).y = z. Without it every nested operand is parsed
+// twice, once as the target of an assignment that is not there, and
+// parsing time grows exponentially with nesting.
 type AssignmentExpression struct {
 	Pos                   lexer.Position
-	UnaryExpressions      []*UnaryExpression     `parser:"( @@"`
+	EndPos                lexer.Position
+	UnaryExpressions      []*UnaryExpression     `parser:"( (?= ( '(' ( ~( '(' | ')' | '[' | ']' ) | '(' ( ~( '(' | ')' | '[' | ']' ) | '(' ( ~( '(' | ')' | '[' | ']' ) )* ')' | '[' ( ~( '(' | ')' | '[' | ']' ) )* ']' )* ')' | '[' ( ~( '(' | ')' | '[' | ']' ) | '(' ( ~( '(' | ')' | '[' | ']' ) )* ')' | '[' ( ~( '(' | ')' | '[' | ']' ) )* ']' )* ']' )* ')' | '[' ( ~( '(' | ')' | '[' | ']' ) | '(' ( ~( '(' | ')' | '[' | ']' ) | '(' ( ~( '(' | ')' | '[' | ']' ) )* ')' | '[' ( ~( '(' | ')' | '[' | ']' ) )* ']' )* ')' | '[' ( ~( '(' | ')' | '[' | ']' ) | '(' ( ~( '(' | ')' | '[' | ']' ) )* ')' | '[' ( ~( '(' | ')' | '[' | ']' ) )* ']' )* ']' )* ']' | ~( ';' | ',' | ')' | ']' | '{' | '}' | '?' | ':' | '(' | '[' | '=' | '*=' | '/=' | '%=' | '+=' | '-=' | '<<=' | '>>=' | '&=' | '^=' | '|=' ) )* ( '=' | '*=' | '/=' | '%=' | '+=' | '-=' | '<<=' | '>>=' | '&=' | '^=' | '|=' ) ) @@"`
 	AssignmentOperators   []*AssignmentOperator  `parser:"@@ )*"`
 	ConditionalExpression *ConditionalExpression `parser:"@@"`
 }
 
 type AssignmentOperator struct {
 	Pos                lexer.Position
+	EndPos             lexer.Position
 	AssignmentOperator *string `parser:"@'=' | @'+=' | @'-=' | @'*=' | @'/=' | @'%=' | @'<<=' | @'>>=' | @'|=' | @'&=' | @'^='"`
 }
 
@@ -432,242 +578,526 @@ func (n *TranslationUnit) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *TranslationUnit) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *ExternalDeclaration) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *ExternalDeclaration) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *FunctionDefinition) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *FunctionDefinition) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *CompoundStatement) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *CompoundStatement) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *StatementList) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *StatementList) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *Statement) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *Statement) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *LabeledStatement) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *LabeledStatement) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *ExpressionStatement) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *ExpressionStatement) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *SelectionStatement) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *SelectionStatement) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *IterationStatement) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *IterationStatement) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *JumpStatement) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *JumpStatement) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *DeclarationSpecifiers) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *DeclarationSpecifiers) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *TypeSpecifier) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *TypeSpecifier) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *StructOrUnionSpecifier) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *StructOrUnionSpecifier) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *StructDeclarationList) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *StructDeclarationList) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *StructDeclaration) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *StructDeclaration) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *SpecifierQualifierList) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *SpecifierQualifierList) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *StructDeclaratorList) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *StructDeclaratorList) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *StructDeclarator) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *StructDeclarator) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *EnumSpecifier) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *EnumSpecifier) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *EnumeratorList) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *EnumeratorList) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *Enumerator) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *Enumerator) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
+func (n *EnumBase) getPos() lexer.Position {
+	return n.Pos
+}
+
+func (n *EnumBase) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
+func (n *Attributes) getPos() lexer.Position {
+	return n.Pos
+}
+
+func (n *Attributes) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
+func (n *Attribute) getPos() lexer.Position {
+	return n.Pos
+}
+
+func (n *Attribute) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *DeclarationList) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *DeclarationList) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *Declaration) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *Declaration) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *InitDeclaratorList) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *InitDeclaratorList) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *InitDeclarator) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *InitDeclarator) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *Initializer) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *Initializer) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *InitializerList) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *InitializerList) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *Declarator) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *Declarator) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *Pointer) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *Pointer) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *TypeQualifierList) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *TypeQualifierList) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *TypeQualifier) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *TypeQualifier) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *DirectDeclarator) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *DirectDeclarator) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
+func (n *DeclaratorSuffix) getPos() lexer.Position {
+	return n.Pos
+}
+
+func (n *DeclaratorSuffix) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *IdentifierList) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *IdentifierList) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *ParameterTypeList) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *ParameterTypeList) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *ParameterList) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *ParameterList) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *ParameterDeclaration) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *ParameterDeclaration) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *AbstractDeclarator) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *AbstractDeclarator) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *DirectAbstractDeclarator) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *DirectAbstractDeclarator) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *ConstantExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *ConstantExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *ConditionalExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *ConditionalExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *LogicalOrExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *LogicalOrExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *LogicalAndExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *LogicalAndExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *InclusiveOrExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *InclusiveOrExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *ExclusiveOrExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *ExclusiveOrExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *AndExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *AndExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *EqualityExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *EqualityExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *RelationalExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *RelationalExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *ShiftExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *ShiftExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *AdditiveExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *AdditiveExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *MultiplicativeExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *MultiplicativeExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *CastExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *CastExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *UnaryExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *UnaryExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *UnaryOperator) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *UnaryOperator) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *TypeName) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *TypeName) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *PostfixExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *PostfixExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
+func (n *PostfixOperator) getPos() lexer.Position {
+	return n.Pos
+}
+
+func (n *PostfixOperator) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *ArgumentExpressionList) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *ArgumentExpressionList) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *PrimaryExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *PrimaryExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *Expression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *Expression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *AssignmentExpression) getPos() lexer.Position {
 	return n.Pos
 }
 
+func (n *AssignmentExpression) getEndPos() lexer.Position {
+	return n.EndPos
+}
+
 func (n *AssignmentOperator) getPos() lexer.Position {
 	return n.Pos
 }
+
+func (n *AssignmentOperator) getEndPos() lexer.Position {
+	return n.EndPos
+}
@@ -0,0 +1,37 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// dense returns n functions of ten lines each, with nested expressions,
+// declarations and statements of every kind.
+func dense(n int) string {
+	var b strings.Builder
+	b.WriteString("struct s { int v[4]; int m; struct { struct { int z[2]; } k[2]; } n; } __attribute__((aligned(8)));\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, `int f%d(int a, int *p, struct s *q) {
+	int x = a * (p[a + 1] - q->v[a & 3]) / (a ? a : 1), y[4] = { 1, 2, 3, 4 };
+	for (x = 0; x < a && p[x] != y[x %% 4]; x++) y[x & 3] += (int)(p[x] << 2) | q->m;
+	if ((a = f%d(a - 1, p, q)) > 0 || q->n.k[a %% 2].z[(a + 1) & 1] == 0) return -a;
+	while (--a > 0) { p[a] = p[a - 1] * 3 + (a > 2 ? y[a %% 4] : *p); }
+	switch (a) { case 1: x = sizeof(struct s) + sizeof x; break; default: x = ~x ^ 7; }
+	q->v[(x + a) & 3] = ((x << 1) + (a >> 2)) %% 9 + (((a))) * ((x - 1) / 2);
+	x += *p++ + *--p + !a - -x + (char)a + (unsigned)x * (long)a;
+	return x + y[0] + y[1] * y[2] - y[3] + a;
+}
+`, i, i)
+	}
+	return b.String()
+}
+
+func BenchmarkParse(b *testing.B) {
+	var src = []byte(dense(30))
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseBytes("dense.c", src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
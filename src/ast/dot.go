@@ -0,0 +1,52 @@
+package ast
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT writes n and all of its descendants to w as a Graphviz digraph.
+// Every node becomes one vertex labelled with its type and lexemes, and
+// edges are labelled with the name of the field holding the child.
+func WriteDOT(w io.Writer, n Node) error {
+	var out = bufio.NewWriter(w)
+	var next = 0
+
+	var visit func(n Node) int
+	visit = func(n Node) int {
+		var id = next
+		next++
+
+		var label = []string{typeName(n), n.getPos().String()}
+		var fs = fields(n)
+		for _, f := range fs {
+			if f.Lexeme != nil {
+				label = append(label, fmt.Sprintf("%s: %q", f.label(), *f.Lexeme))
+			} else if f.Flag {
+				label = append(label, fmt.Sprintf("%s: true", f.label()))
+			}
+		}
+		fmt.Fprintf(out, "\tn%d [label=\"%s\"];\n", id, dotEscape(strings.Join(label, "\n")))
+
+		for _, f := range fs {
+			if f.Child != nil {
+				var child = visit(f.Child)
+				fmt.Fprintf(out, "\tn%d -> n%d [label=\"%s\"];\n", id, child, f.label())
+			}
+		}
+		return id
+	}
+
+	out.WriteString("digraph AST {\n")
+	out.WriteString("\tnode [shape=box, fontname=\"monospace\"];\n")
+	visit(n)
+	out.WriteString("}\n")
+	return out.Flush()
+}
+
+func dotEscape(s string) string {
+	var replacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return replacer.Replace(s)
+}
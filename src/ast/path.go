@@ -0,0 +1,98 @@
+package ast
+
+import "lazarus-c/src/lexer"
+
+// Pos returns the position of the first token of n.
+func Pos(n Node) lexer.Position {
+	return n.getPos()
+}
+
+// End returns the position right after the last token of n.
+func End(n Node) lexer.Position {
+	return n.getEndPos()
+}
+
+// DeclaredName returns the direct declarator holding the identifier
+// declared by d, looking through parenthesized declarators.
+func DeclaredName(d *Declarator) *DirectDeclarator {
+	var direct = d.DirectDeclarators[0]
+	for direct.Declarator != nil {
+		direct = direct.Declarator.DirectDeclarators[0]
+	}
+	return direct
+}
+
+// FunctionSuffix returns the parameter list of the function declared by
+// d: the first suffix following its name, or nil when d does not declare
+// a function.
+func FunctionSuffix(d *Declarator) *DeclaratorSuffix {
+	var direct = DeclaredName(d)
+	if len(direct.DeclaratorSuffixes) == 0 || !direct.DeclaratorSuffixes[0].IsFunction {
+		return nil
+	}
+	return direct.DeclaratorSuffixes[0]
+}
+
+// StringLiteral returns the string literal that makes up the expression
+// n, possibly parenthesized, if any.
+func StringLiteral(n Node) *PrimaryExpression {
+	for {
+		if primary, ok := n.(*PrimaryExpression); ok && primary.StringLiteral != nil {
+			return primary
+		}
+		var children = Children(n)
+		if len(children) != 1 {
+			return nil
+		}
+		n = children[0]
+	}
+}
+
+// PathEnclosingInterval returns the nodes enclosing the source interval
+// [start, end), given as byte offsets, from root down to the innermost
+// one. An empty interval designates the position start. The result is
+// nil when root itself does not enclose the interval.
+func PathEnclosingInterval(root Node, start, end int) []Node {
+	if !encloses(root, start, end) {
+		return nil
+	}
+
+	var path = []Node{root}
+	for n := root; ; {
+		var inner Node
+		for _, child := range Children(n) {
+			if encloses(child, start, end) {
+				inner = child
+				break
+			}
+		}
+		if inner == nil {
+			return path
+		}
+		path = append(path, inner)
+		n = inner
+	}
+}
+
+func encloses(n Node, start, end int) bool {
+	var nodeStart, nodeEnd = n.getPos().Offset, n.getEndPos().Offset
+	if start == end {
+		return nodeStart <= start && start < nodeEnd
+	}
+	return nodeStart <= start && end <= nodeEnd
+}
+
+// Parents maps every node of the tree rooted at root, except root itself,
+// to the node holding it.
+func Parents(root Node) map[Node]Node {
+	var parents = make(map[Node]Node)
+	var visit func(n Node)
+	visit = func(n Node) {
+		for _, child := range Children(n) {
+			parents[child] = n
+			visit(child)
+		}
+	}
+	visit(root)
+	return parents
+}
@@ -0,0 +1,65 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+const pathSource = "int f(int a) {\n\treturn a + 1;\n}\nint g;"
+
+// paths lists intervals of pathSource, marked by the text they start with
+// and, unless empty, by the text they end with, along with the type and
+// the text of the innermost node enclosing them.
+var paths = []struct {
+	from, to  string
+	innermost string
+	text      string
+}{
+	{"a +", "", "PrimaryExpression", "a"},
+	{"+ 1", "", "AdditiveExpression", "a + 1"},
+	{"a + 1", "1", "AdditiveExpression", "a + 1"},
+	{"return", "", "JumpStatement", "return a + 1;"},
+	{"int a", "", "TypeSpecifier", "int"},
+	{"a)", "", "DirectDeclarator", "a"},
+	{"(int a)", "", "DeclaratorSuffix", "(int a)"},
+	{"g;", "", "DirectDeclarator", "g"},
+	{";\n}", "", "JumpStatement", "return a + 1;"},
+	{"{", "}", "CompoundStatement", "{\n\treturn a + 1;\n}"},
+	{"(int a)", "g", "TranslationUnit", pathSource},
+}
+
+func TestPathEnclosingInterval(t *testing.T) {
+	var unit, err = ParseString(pathSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parents = Parents(unit)
+	for _, test := range paths {
+		var start = strings.Index(pathSource, test.from)
+		var end = start
+		if test.to != "" {
+			end = start + strings.Index(pathSource[start:], test.to) + len(test.to)
+		}
+		var path = PathEnclosingInterval(unit, start, end)
+		if len(path) == 0 || path[0] != unit {
+			t.Errorf("%q to %q: enclosed by %d nodes from %v", test.from, test.to, len(path), path)
+			continue
+		}
+		for i, n := range path[1:] {
+			if parents[n] != path[i] {
+				t.Errorf("%q to %q: %s follows %s rather than its parent", test.from, test.to, typeName(n), typeName(path[i]))
+			}
+		}
+		var innermost = path[len(path)-1]
+		var text = pathSource[Pos(innermost).Offset:End(innermost).Offset]
+		if typeName(innermost) != test.innermost || text != test.text {
+			t.Errorf("%q to %q: innermost node is %s %q instead of %s %q", test.from, test.to, typeName(innermost), text, test.innermost, test.text)
+		}
+	}
+	if path := PathEnclosingInterval(unit, len(pathSource), len(pathSource)); path != nil {
+		t.Errorf("the end of the source is enclosed by %d nodes", len(path))
+	}
+	if parent, ok := parents[unit]; ok {
+		t.Errorf("the root has parent %v", parent)
+	}
+}
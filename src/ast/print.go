@@ -3,8 +3,6 @@ package ast
 import (
 	"fmt"
 	"github.com/xlab/treeprint"
-	"lazarus-c/src/lexer"
-	"reflect"
 )
 
 func init() {
@@ -13,410 +11,383 @@ func init() {
 	treeprint.EdgeTypeEnd = "`-"
 }
 
-func format(n node, tree treeprint.Tree) treeprint.Tree {
-	var nodeVal = reflect.ValueOf(n).Elem()
-	var nodeType = reflect.TypeOf(n).Elem()
+// PrintConfig controls how syntax trees are rendered by Sprint and by the
+// String methods of every node.
+type PrintConfig struct {
+	// Positions attaches the source position of every node.
+	Positions bool
+	// Collapse replaces nodes whose only content is a single child, such
+	// as the levels of the expression grammar, with that child.
+	Collapse bool
+	// MaxDepth limits the number of levels printed below the root. Zero
+	// means no limit.
+	MaxDepth int
+	// Types restricts the output to nodes of the listed types when it is
+	// not empty. The children of other nodes are attached to their
+	// closest printed ancestor.
+	Types []string
+	// Color highlights node types, lexemes and positions with ANSI
+	// escape sequences.
+	Color bool
+}
+
+// DefaultPrintConfig is used by the String methods of every node.
+var DefaultPrintConfig = &PrintConfig{Positions: true}
+
+const (
+	colorType     = "\x1b[1;34m"
+	colorLexeme   = "\x1b[32m"
+	colorPosition = "\x1b[2m"
+	colorReset    = "\x1b[0m"
+)
 
-	var fields = reflect.VisibleFields(nodeType)
-	for idx := 0; idx < nodeVal.NumField(); idx++ {
-		if fields[idx].Type == reflect.TypeOf(lexer.Position{}) {
-			continue
-		}
-		if nodeVal.Field(idx).IsNil() {
-			continue
-		}
-		if fields[idx].Type == reflect.TypeOf(true) {
-			tree.AddMetaNode(fields[idx].Name, nodeVal.Field(idx).String())
-		} else if fields[idx].Type.Elem() == reflect.TypeOf("") {
-			var lexeme = fmt.Sprintf("\"%s\"", nodeVal.Field(idx).Elem().String())
-			lexeme = fmt.Sprintf("%s: %s", fields[idx].Name, lexeme)
-			tree.AddNode(lexeme)
-		} else if nodeVal.Field(idx).Kind() == reflect.Slice {
-			var fieldVal = nodeVal.Field(idx)
-			for _idx := 0; _idx < fieldVal.Len(); _idx++ {
-				var elemVal = fieldVal.Index(_idx)
-				if elemVal.Elem().Type() == reflect.TypeOf("") {
-					var lexeme = fmt.Sprintf("\"%s\"", elemVal.Elem().String())
-					lexeme = fmt.Sprintf("%s[%d]: %s", fields[idx].Name, _idx, lexeme)
-					tree.AddNode(lexeme)
-				} else {
-					var n = elemVal.Interface().(node)
-					var branch = tree.AddMetaBranch(n.getPos(), elemVal.Elem().Type().Name())
-					format(n, branch)
-				}
+// Sprint renders n and its descendants as an indented tree.
+func (c *PrintConfig) Sprint(n Node) string {
+	if c.Collapse {
+		n = collapse(n)
+	}
+	var tree = treeprint.NewWithRoot(c.paint(colorType, typeName(n)))
+	tree = c.format(n, tree, 1, true)
+	return tree.String()
+}
+
+func (c *PrintConfig) format(n Node, tree treeprint.Tree, depth int, lexemes bool) treeprint.Tree {
+	for _, f := range fields(n) {
+		switch {
+		case f.Lexeme != nil:
+			if lexemes {
+				var lexeme = c.paint(colorLexeme, fmt.Sprintf("\"%s\"", *f.Lexeme))
+				tree.AddNode(fmt.Sprintf("%s: %s", f.label(), lexeme))
 			}
-		} else {
-			var n = nodeVal.Field(idx).Interface().(node)
-			var branch = tree.AddMetaBranch(n.getPos(), nodeVal.Field(idx).Elem().Type().Name())
-			format(nodeVal.Field(idx).Interface().(node), branch)
+		case f.Flag:
+			if lexemes {
+				tree.AddNode(fmt.Sprintf("%s: %s", f.label(), c.paint(colorLexeme, "true")))
+			}
+		default:
+			c.formatChild(f.Child, tree, depth)
 		}
 	}
 
 	return tree
 }
 
+func (c *PrintConfig) formatChild(n Node, tree treeprint.Tree, depth int) {
+	if c.Collapse {
+		n = collapse(n)
+	}
+	if !c.selected(n) {
+		c.format(n, tree, depth, false)
+		return
+	}
+
+	var name = c.paint(colorType, typeName(n))
+	var branch treeprint.Tree
+	if c.Positions {
+		branch = tree.AddMetaBranch(c.paint(colorPosition, n.getPos().String()), name)
+	} else {
+		branch = tree.AddBranch(name)
+	}
+
+	if c.MaxDepth > 0 && depth >= c.MaxDepth {
+		if len(fields(n)) > 0 {
+			branch.AddNode("...")
+		}
+		return
+	}
+	c.format(n, branch, depth+1, true)
+}
+
+func (c *PrintConfig) selected(n Node) bool {
+	if len(c.Types) == 0 {
+		return true
+	}
+	var name = typeName(n)
+	for _, t := range c.Types {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *PrintConfig) paint(color string, s string) string {
+	if !c.Color {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// collapse follows a chain of nodes that only hold a single child and
+// returns the last one.
+func collapse(n Node) Node {
+	var fs = fields(n)
+	for len(fs) == 1 && fs[0].Child != nil {
+		n = fs[0].Child
+		fs = fields(n)
+	}
+	return n
+}
+
 func (n *TranslationUnit) String() string {
-	var tree = treeprint.NewWithRoot("TranslationUnit")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *ExternalDeclaration) String() string {
-	var tree = treeprint.NewWithRoot("ExternalDeclaration")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *FunctionDefinition) String() string {
-	var tree = treeprint.NewWithRoot("FunctionDefinition")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *CompoundStatement) String() string {
-	var tree = treeprint.NewWithRoot("CompoundStatement")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *StatementList) String() string {
-	var tree = treeprint.NewWithRoot("StatementList")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *Statement) String() string {
-	var tree = treeprint.NewWithRoot("Statement")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *LabeledStatement) String() string {
-	var tree = treeprint.NewWithRoot("LabeledStatement")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *ExpressionStatement) String() string {
-	var tree = treeprint.NewWithRoot("ExpressionStatement")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *SelectionStatement) String() string {
-	var tree = treeprint.NewWithRoot("SelectionStatement")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *IterationStatement) String() string {
-	var tree = treeprint.NewWithRoot("IterationStatement")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *JumpStatement) String() string {
-	var tree = treeprint.NewWithRoot("JumpStatement")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *DeclarationSpecifiers) String() string {
-	var tree = treeprint.NewWithRoot("DeclarationSpecifiers")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *TypeSpecifier) String() string {
-	var tree = treeprint.NewWithRoot("TypeSpecifier")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *StructOrUnionSpecifier) String() string {
-	var tree = treeprint.NewWithRoot("StructOrUnionSpecifier")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *StructDeclarationList) String() string {
-	var tree = treeprint.NewWithRoot("StructDeclarationList")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *StructDeclaration) String() string {
-	var tree = treeprint.NewWithRoot("StructDeclaration")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *SpecifierQualifierList) String() string {
-	var tree = treeprint.NewWithRoot("SpecifierQualifierList")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *StructDeclaratorList) String() string {
-	var tree = treeprint.NewWithRoot("StructDeclaratorList")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *StructDeclarator) String() string {
-	var tree = treeprint.NewWithRoot("StructDeclarator")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *EnumSpecifier) String() string {
-	var tree = treeprint.NewWithRoot("EnumSpecifier")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
+}
+
+func (n *EnumBase) String() string {
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *EnumeratorList) String() string {
-	var tree = treeprint.NewWithRoot("EnumeratorList")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *Enumerator) String() string {
-	var tree = treeprint.NewWithRoot("Enumerator")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
+}
+
+func (n *Attributes) String() string {
+	return DefaultPrintConfig.Sprint(n)
+}
+
+func (n *Attribute) String() string {
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *DeclarationList) String() string {
-	var tree = treeprint.NewWithRoot("DeclarationList")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *Declaration) String() string {
-	var tree = treeprint.NewWithRoot("Declaration")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *InitDeclaratorList) String() string {
-	var tree = treeprint.NewWithRoot("InitDeclaratorList")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *InitDeclarator) String() string {
-	var tree = treeprint.NewWithRoot("InitDeclarator")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *Initializer) String() string {
-	var tree = treeprint.NewWithRoot("Initializer")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *InitializerList) String() string {
-	var tree = treeprint.NewWithRoot("InitializerList")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *Declarator) String() string {
-	var tree = treeprint.NewWithRoot("Declarator")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *Pointer) String() string {
-	var tree = treeprint.NewWithRoot("Pointer")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *TypeQualifierList) String() string {
-	var tree = treeprint.NewWithRoot("TypeQualifierList")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *TypeQualifier) String() string {
-	var tree = treeprint.NewWithRoot("TypeQualifier")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *DirectDeclarator) String() string {
-	var tree = treeprint.NewWithRoot("DirectDeclarator")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
+}
+
+func (n *DeclaratorSuffix) String() string {
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *IdentifierList) String() string {
-	var tree = treeprint.NewWithRoot("IdentifierList")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *ParameterTypeList) String() string {
-	var tree = treeprint.NewWithRoot("ParameterTypeList")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *ParameterList) String() string {
-	var tree = treeprint.NewWithRoot("ParameterList")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *ParameterDeclaration) String() string {
-	var tree = treeprint.NewWithRoot("ParameterDeclaration")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *AbstractDeclarator) String() string {
-	var tree = treeprint.NewWithRoot("AbstractDeclarator")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *DirectAbstractDeclarator) String() string {
-	var tree = treeprint.NewWithRoot("DirectAbstractDeclarator")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *ConstantExpression) String() string {
-	var tree = treeprint.NewWithRoot("ConstantExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *ConditionalExpression) String() string {
-	var tree = treeprint.NewWithRoot("ConditionalExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *LogicalOrExpression) String() string {
-	var tree = treeprint.NewWithRoot("LogicalOrExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *LogicalAndExpression) String() string {
-	var tree = treeprint.NewWithRoot("LogicalAndExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *InclusiveOrExpression) String() string {
-	var tree = treeprint.NewWithRoot("InclusiveOrExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *ExclusiveOrExpression) String() string {
-	var tree = treeprint.NewWithRoot("ExclusiveOrExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *AndExpression) String() string {
-	var tree = treeprint.NewWithRoot("AndExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *EqualityExpression) String() string {
-	var tree = treeprint.NewWithRoot("EqualityExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *RelationalExpression) String() string {
-	var tree = treeprint.NewWithRoot("RelationalExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *ShiftExpression) String() string {
-	var tree = treeprint.NewWithRoot("ShiftExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *AdditiveExpression) String() string {
-	var tree = treeprint.NewWithRoot("AdditiveExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *MultiplicativeExpression) String() string {
-	var tree = treeprint.NewWithRoot("MultiplicativeExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *CastExpression) String() string {
-	var tree = treeprint.NewWithRoot("CastExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *UnaryExpression) String() string {
-	var tree = treeprint.NewWithRoot("UnaryExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *UnaryOperator) String() string {
-	var tree = treeprint.NewWithRoot("UnaryOperator")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *TypeName) String() string {
-	var tree = treeprint.NewWithRoot("TypeName")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *PostfixExpression) String() string {
-	var tree = treeprint.NewWithRoot("PostfixExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
+}
+
+func (n *PostfixOperator) String() string {
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *ArgumentExpressionList) String() string {
-	var tree = treeprint.NewWithRoot("ArgumentExpressionList")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *PrimaryExpression) String() string {
-	var tree = treeprint.NewWithRoot("PrimaryExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *Expression) String() string {
-	var tree = treeprint.NewWithRoot("Expression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *AssignmentExpression) String() string {
-	var tree = treeprint.NewWithRoot("AssignmentExpression")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
 
 func (n *AssignmentOperator) String() string {
-	var tree = treeprint.NewWithRoot("AssignmentOperator")
-	tree = format(n, tree)
-	return tree.String()
+	return DefaultPrintConfig.Sprint(n)
 }
@@ -0,0 +1,98 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+const printSource = "int i = 10;\nint f(void) { return !i; }"
+
+// prints lists configurations with the tree they print for printSource
+// or, when unit is false, for its second external declaration. The
+// backquotes of last branches are written as quotes.
+var prints = []struct {
+	name   string
+	config PrintConfig
+	unit   bool
+	tree   string
+}{
+	{"collapse", PrintConfig{Collapse: true}, true, `TranslationUnit
+|- Declaration
+|   |- TypeSpecifier
+|   |   '- TypeSpecifier: "int"
+|   '- InitDeclarator
+|       |- DirectDeclarator
+|       |   '- Identifier: "i"
+|       '- PrimaryExpression
+|           '- Int: "10"
+'- FunctionDefinition
+    |- TypeSpecifier
+    |   '- TypeSpecifier: "int"
+    |- DirectDeclarator
+    |   |- Identifier: "f"
+    |   '- DeclaratorSuffix
+    |       |- IsFunction: true
+    |       '- TypeSpecifier
+    |           '- TypeSpecifier: "void"
+    '- JumpStatement
+        |- IsReturn: true
+        '- UnaryExpression
+            |- UnaryOperator
+            |   '- Operator: "!"
+            '- PrimaryExpression
+                '- Identifier: "i"
+`},
+	{"positions and depth", PrintConfig{Positions: true, MaxDepth: 2}, false, `ExternalDeclaration
+'- [2:1]  FunctionDefinition
+    |- [2:1]  DeclarationSpecifiers
+    |   '- ...
+    |- [2:5]  Declarator
+    |   '- ...
+    '- [2:13]  CompoundStatement
+        '- ...
+`},
+	{"types", PrintConfig{Types: []string{"JumpStatement", "PrimaryExpression", "UnaryOperator"}}, true, `TranslationUnit
+|- PrimaryExpression
+|   '- Int: "10"
+'- JumpStatement
+    |- IsReturn: true
+    |- UnaryOperator
+    |   '- Operator: "!"
+    '- PrimaryExpression
+        '- Identifier: "i"
+`},
+	{"color", PrintConfig{Collapse: true, Color: true, MaxDepth: 1}, false, "\x1b[1;34mFunctionDefinition\x1b[0m\n" +
+		"|- \x1b[1;34mTypeSpecifier\x1b[0m\n|   '- ...\n" +
+		"|- \x1b[1;34mDirectDeclarator\x1b[0m\n|   '- ...\n" +
+		"'- \x1b[1;34mJumpStatement\x1b[0m\n    '- ...\n"},
+}
+
+func TestPrintConfig(t *testing.T) {
+	var unit, err = ParseString(printSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range prints {
+		var n Node = unit.ExternalDeclarations[1]
+		if test.unit {
+			n = unit
+		}
+		if got := strings.ReplaceAll(test.config.Sprint(n), "`", "'"); got != test.tree {
+			t.Errorf("%s: printed\n%s\ninstead of\n%s", test.name, got, test.tree)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	var unit, err = ParseString(printSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jump = unit.ExternalDeclarations[1].FunctionDefinition.CompoundStatement.StatementList.Statements[0].JumpStatement
+	if got, want := jump.String(), DefaultPrintConfig.Sprint(jump); got != want {
+		t.Errorf("printed\n%s\ninstead of\n%s", got, want)
+	}
+	if want := "JumpStatement\n|- IsReturn: true\n"; !strings.HasPrefix(jump.String(), want) {
+		t.Errorf("printed\n%s\nwhich does not start with\n%s", jump.String(), want)
+	}
+}
@@ -0,0 +1,53 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// sexprWidth is the line width below which a list is kept on one line.
+const sexprWidth = 80
+
+// WriteSExpr writes n to w as an S-expression such as
+// (FunctionDefinition (TypeSpecifier TypeSpecifier:"int") ...).
+// Nodes whose only content is a single child, like the chain of
+// expression levels between an AssignmentExpression and its
+// PrimaryExpression, are skipped in favour of that child.
+func WriteSExpr(w io.Writer, n Node) error {
+	var _, err = io.WriteString(w, SExpr(n)+"\n")
+	return err
+}
+
+// SExpr returns the S-expression form of n, as written by WriteSExpr.
+func SExpr(n Node) string {
+	return sexpr(n, 0)
+}
+
+func sexpr(n Node, indent int) string {
+	n = collapse(n)
+	var fs = fields(n)
+
+	var items = []string{typeName(n)}
+	var multiline = false
+	for _, f := range fs {
+		var item string
+		switch {
+		case f.Lexeme != nil:
+			item = fmt.Sprintf("%s:%q", f.label(), *f.Lexeme)
+		case f.Flag:
+			item = fmt.Sprintf("%s:true", f.label())
+		default:
+			item = sexpr(f.Child, indent+1)
+		}
+		multiline = multiline || strings.Contains(item, "\n")
+		items = append(items, item)
+	}
+
+	var inline = "(" + strings.Join(items, " ") + ")"
+	if !multiline && 2*indent+len(inline) <= sexprWidth {
+		return inline
+	}
+	var pad = "\n" + strings.Repeat("  ", indent+1)
+	return "(" + strings.Join(items, pad) + ")"
+}
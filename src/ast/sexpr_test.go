@@ -0,0 +1,109 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+// find returns the first node of type N in the tree rooted at root.
+func find[N Node](root Node) N {
+	var found N
+	var done bool
+	Inspect(root, func(n Node) bool {
+		if m, ok := n.(N); ok && !done {
+			found, done = m, true
+		}
+		return !done
+	})
+	return found
+}
+
+// sexprs lists sources with their S-expression. Single declarations
+// collapse their translation unit.
+var sexprs = []struct {
+	src, sexpr string
+}{
+	{`int i = 10;`, `(Declaration
+  (TypeSpecifier TypeSpecifier:"int")
+  (InitDeclarator
+    (DirectDeclarator Identifier:"i")
+    (PrimaryExpression Int:"10")))`},
+	{`int f(void) { return !i; }`, `(FunctionDefinition
+  (TypeSpecifier TypeSpecifier:"int")
+  (DirectDeclarator
+    Identifier:"f"
+    (DeclaratorSuffix IsFunction:true (TypeSpecifier TypeSpecifier:"void")))
+  (JumpStatement
+    IsReturn:true
+    (UnaryExpression
+      (UnaryOperator Operator:"!")
+      (PrimaryExpression Identifier:"i"))))`},
+	{`int x; void f(int x, ...) { if (x) return; }`, `(TranslationUnit
+  (Declaration
+    (TypeSpecifier TypeSpecifier:"int")
+    (DirectDeclarator Identifier:"x"))
+  (FunctionDefinition
+    (TypeSpecifier TypeSpecifier:"void")
+    (DirectDeclarator
+      Identifier:"f"
+      (DeclaratorSuffix
+        IsFunction:true
+        (ParameterTypeList
+          (ParameterDeclaration
+            (TypeSpecifier TypeSpecifier:"int")
+            (DirectDeclarator Identifier:"x"))
+          Ellipsis:true)))
+    (SelectionStatement
+      (PrimaryExpression Identifier:"x")
+      (JumpStatement IsReturn:true))))`},
+	{`char c = '"';`, `(Declaration
+  (TypeSpecifier TypeSpecifier:"char")
+  (InitDeclarator
+    (DirectDeclarator Identifier:"c")
+    (PrimaryExpression Char:"\"")))`},
+}
+
+func TestSExpr(t *testing.T) {
+	for _, test := range sexprs {
+		var unit, err = ParseString(test.src)
+		if err != nil {
+			t.Errorf("parsing %q: %v", test.src, err)
+			continue
+		}
+		if got := SExpr(unit); got != test.sexpr {
+			t.Errorf("%s: written as\n%s\ninstead of\n%s", test.src, got, test.sexpr)
+		}
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var unit, err = ParseString(`char *s = "a\"b\\"; void f(int x) { if (x) return; }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		node Node
+		dot  string
+	}{
+		{find[*PrimaryExpression](unit), `digraph AST {
+	node [shape=box, fontname="monospace"];
+	n0 [label="PrimaryExpression\n1:11\nStringLiteral: \"a\\\"b\\\\\""];
+}
+`},
+		{find[*SelectionStatement](unit).IfBody, `digraph AST {
+	node [shape=box, fontname="monospace"];
+	n0 [label="Statement\n1:44"];
+	n1 [label="JumpStatement\n1:44\nIsReturn: true"];
+	n0 -> n1 [label="JumpStatement"];
+}
+`},
+	} {
+		var out strings.Builder
+		if err := WriteDOT(&out, test.node); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != test.dot {
+			t.Errorf("%s: written as\n%s\ninstead of\n%s", typeName(test.node), out.String(), test.dot)
+		}
+	}
+}
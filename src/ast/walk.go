@@ -0,0 +1,93 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// field is a single non-positional value held by a node: either a lexeme
+// captured by the parser, a boolean flag or a child node.
+type field struct {
+	Name   string
+	Index  int
+	Lexeme *string
+	Flag   bool
+	Child  Node
+}
+
+func (f field) label() string {
+	if f.Index < 0 {
+		return f.Name
+	}
+	return fmt.Sprintf("%s[%d]", f.Name, f.Index)
+}
+
+// fields lists the lexemes, set flags and children of n in declaration
+// order. Nil pointers and false flags are left out.
+func fields(n Node) []field {
+	var out []field
+	var nodeVal = reflect.ValueOf(n).Elem()
+	var nodeType = nodeVal.Type()
+
+	for idx := 0; idx < nodeVal.NumField(); idx++ {
+		var name = nodeType.Field(idx).Name
+		var fieldVal = nodeVal.Field(idx)
+		switch fieldVal.Kind() {
+		case reflect.Bool:
+			if fieldVal.Bool() {
+				out = append(out, field{Name: name, Index: -1, Flag: true})
+			}
+		case reflect.Pointer:
+			if !fieldVal.IsNil() {
+				out = append(out, makeField(name, -1, fieldVal))
+			}
+		case reflect.Slice:
+			for _idx := 0; _idx < fieldVal.Len(); _idx++ {
+				var elemVal = fieldVal.Index(_idx)
+				if elemVal.Kind() == reflect.String {
+					out = append(out, makeField(name, _idx, elemVal.Addr()))
+				} else if !elemVal.IsNil() {
+					out = append(out, makeField(name, _idx, elemVal))
+				}
+			}
+		}
+	}
+
+	return out
+}
+
+func makeField(name string, index int, val reflect.Value) field {
+	if lexeme, ok := val.Interface().(*string); ok {
+		return field{Name: name, Index: index, Lexeme: lexeme}
+	}
+	return field{Name: name, Index: index, Child: val.Interface().(Node)}
+}
+
+func typeName(n Node) string {
+	return reflect.TypeOf(n).Elem().Name()
+}
+
+// Inspect traverses the tree rooted at n depth-first, calling f for each
+// node before its children. The children are skipped when f returns
+// false.
+func Inspect(n Node, f func(Node) bool) {
+	if !f(n) {
+		return
+	}
+	for _, field := range fields(n) {
+		if field.Child != nil {
+			Inspect(field.Child, f)
+		}
+	}
+}
+
+// Children returns the child nodes of n in declaration order.
+func Children(n Node) []Node {
+	var out []Node
+	for _, field := range fields(n) {
+		if field.Child != nil {
+			out = append(out, field.Child)
+		}
+	}
+	return out
+}
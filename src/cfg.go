@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/cfg"
+	"lazarus-c/src/diag"
+	"lazarus-c/src/sema"
+	"os"
+)
+
+func cfgCommand(args []string) error {
+	var flags = flag.NewFlagSet("cfg", flag.ExitOnError)
+	var name = flags.String("func", "", "only print the graph of the named function")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: lazarus cfg [-func=name] [file]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var unit, err = parseInput(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var diags diag.List
+	var info = sema.Check(unit, &diags)
+	diags = diags.Filter(warningFlags{}.enabled)
+	diags.Sort()
+	diags.Print(os.Stderr)
+	if diags.HasErrors() {
+		return fmt.Errorf("%s has errors", flags.Arg(0))
+	}
+
+	var found = false
+	for _, decl := range unit.ExternalDeclarations {
+		var fn = decl.FunctionDefinition
+		if fn == nil {
+			continue
+		}
+		var fnName = *ast.DeclaredName(fn.Declarator).Identifier
+		if *name != "" && fnName != *name {
+			continue
+		}
+		found = true
+		var g = sema.FlowConfig(info).New(fn)
+		if err := cfg.WriteDOT(os.Stdout, fnName, g); err != nil {
+			return err
+		}
+	}
+	if !found && *name != "" {
+		return fmt.Errorf("no definition of function %s", *name)
+	}
+	return nil
+}
@@ -0,0 +1,400 @@
+package cfg
+
+import "lazarus-c/src/ast"
+
+type builder struct {
+	cfg     *CFG
+	config  *Config
+	current *Block
+	labels  map[string]*Block
+	// breakTarget and continueTarget are the blocks that break and
+	// continue statements jump to, nil outside loops and switches.
+	breakTarget    *Block
+	continueTarget *Block
+	// cases maps the case and default labels of the enclosing switch
+	// statements to their blocks.
+	cases map[*ast.LabeledStatement]*Block
+	// full is the full expression whose operators are being evaluated,
+	// nil in conditions.
+	full ast.Node
+}
+
+func (b *builder) newBlock(kind Kind, stmt ast.Node) *Block {
+	var block = &Block{Index: len(b.cfg.Blocks), Kind: kind, Stmt: stmt}
+	b.cfg.Blocks = append(b.cfg.Blocks, block)
+	return block
+}
+
+func (b *builder) add(n ast.Node) {
+	b.current.Nodes = append(b.current.Nodes, n)
+}
+
+// jump ends the current block with an edge to target.
+func (b *builder) jump(target *Block) {
+	b.current.Succs = append(b.current.Succs, target)
+}
+
+// unreachable starts the block following a jump statement, only reached
+// through a label if at all.
+func (b *builder) unreachable(stmt ast.Node) {
+	b.current = b.newBlock(KindUnreachable, stmt)
+}
+
+// label returns the block of the label name, created by its definition
+// or by the first goto statement naming it.
+func (b *builder) label(name string, stmt ast.Node) *Block {
+	var block = b.labels[name]
+	if block == nil {
+		block = b.newBlock(KindLabel, stmt)
+		b.labels[name] = block
+	}
+	return block
+}
+
+func (b *builder) compoundStatement(n *ast.CompoundStatement) {
+	if n.DeclarationList != nil {
+		for _, decl := range n.DeclarationList.Declarations {
+			if decl.InitDeclaratorList == nil {
+				continue
+			}
+			for _, d := range decl.InitDeclaratorList.InitDeclarators {
+				b.expression(d)
+			}
+		}
+	}
+	if n.StatementList != nil {
+		for _, stmt := range n.StatementList.Statements {
+			b.statement(stmt)
+		}
+	}
+}
+
+func (b *builder) statement(n *ast.Statement) {
+	switch {
+	case n.LabeledStatement != nil:
+		b.labeledStatement(n.LabeledStatement)
+	case n.CompoundStatement != nil:
+		b.compoundStatement(n.CompoundStatement)
+	case n.ExpressionStatement != nil:
+		var expr = n.ExpressionStatement.Expression
+		if expr != nil {
+			b.expression(expr)
+			if b.config.NoReturn != nil && b.config.NoReturn(expr) {
+				b.unreachable(n)
+			}
+		}
+	case n.SelectionStatement != nil:
+		b.selectionStatement(n.SelectionStatement)
+	case n.IterationStatement != nil:
+		b.iterationStatement(n.IterationStatement)
+	case n.JumpStatement != nil:
+		b.jumpStatement(n.JumpStatement)
+	}
+}
+
+func (b *builder) labeledStatement(n *ast.LabeledStatement) {
+	var block *Block
+	var body *ast.Statement
+	switch {
+	case n.GotoLabel != nil:
+		block, body = b.label(*n.GotoLabel, n), n.GotoStatement
+		block.Stmt = n
+	case n.CaseExpression != nil:
+		block, body = b.cases[n], n.CaseStatement
+	default:
+		block, body = b.cases[n], n.DefaultStatement
+	}
+	if block == nil {
+		// A case or default label outside a switch statement.
+		block = b.newBlock(KindUnreachable, n)
+	}
+	b.jump(block)
+	b.current = block
+	b.statement(body)
+}
+
+func (b *builder) selectionStatement(n *ast.SelectionStatement) {
+	if n.IfTest != nil {
+		var then = b.newBlock(KindIfThen, n)
+		var done = b.newBlock(KindIfDone, n)
+		var els = done
+		if n.ElseBody != nil {
+			els = b.newBlock(KindIfElse, n)
+		}
+		b.condition(n.IfTest, then, els)
+		b.current = then
+		b.statement(n.IfBody)
+		b.jump(done)
+		if n.ElseBody != nil {
+			b.current = els
+			b.statement(n.ElseBody)
+			b.jump(done)
+		}
+		b.current = done
+		return
+	}
+
+	b.expression(n.SwitchExpression)
+	var done = b.newBlock(KindSwitchDone, n)
+	var def *Block
+	var cases = map[*ast.LabeledStatement]*Block{}
+	ast.Inspect(n.SwitchBody, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.SelectionStatement:
+			// The labels of a nested switch belong to it.
+			return node.SwitchExpression == nil
+		case *ast.LabeledStatement:
+			if node.CaseExpression != nil {
+				cases[node] = b.newBlock(KindSwitchCase, node)
+				b.jump(cases[node])
+			} else if node.GotoLabel == nil && def == nil {
+				def = b.newBlock(KindSwitchDefault, node)
+				cases[node] = def
+			}
+		}
+		return true
+	})
+	if def == nil {
+		def = done
+	}
+	b.jump(def)
+
+	var outer, break_ = b.cases, b.breakTarget
+	b.cases, b.breakTarget = cases, done
+	b.unreachable(n)
+	b.statement(n.SwitchBody)
+	b.jump(done)
+	b.cases, b.breakTarget = outer, break_
+	b.current = done
+}
+
+func (b *builder) iterationStatement(n *ast.IterationStatement) {
+	switch {
+	case n.WhileTest != nil:
+		var loop = b.newBlock(KindWhileLoop, n)
+		var body = b.newBlock(KindWhileBody, n)
+		var done = b.newBlock(KindWhileDone, n)
+		b.jump(loop)
+		b.current = loop
+		b.condition(n.WhileTest, body, done)
+		b.current = body
+		b.loopBody(n.WhileBody, done, loop)
+		b.jump(loop)
+		b.current = done
+
+	case n.DoBody != nil:
+		var body = b.newBlock(KindDoBody, n)
+		var test = b.newBlock(KindDoTest, n)
+		var done = b.newBlock(KindDoDone, n)
+		b.jump(body)
+		b.current = body
+		b.loopBody(n.DoBody, done, test)
+		b.jump(test)
+		b.current = test
+		b.condition(n.DoTest, body, done)
+		b.current = done
+
+	default:
+		if n.ForInit.Expression != nil {
+			b.expression(n.ForInit.Expression)
+		}
+		var loop = b.newBlock(KindForLoop, n)
+		var body = b.newBlock(KindForBody, n)
+		var post = b.newBlock(KindForPost, n)
+		var done = b.newBlock(KindForDone, n)
+		b.jump(loop)
+		b.current = loop
+		if n.ForTest.Expression != nil {
+			b.condition(n.ForTest.Expression, body, done)
+		} else {
+			b.jump(body)
+		}
+		b.current = body
+		b.loopBody(n.ForBody, done, post)
+		b.jump(post)
+		b.current = post
+		if n.ForUpdate != nil {
+			b.expression(n.ForUpdate)
+		}
+		b.jump(loop)
+		b.current = done
+	}
+}
+
+func (b *builder) loopBody(n *ast.Statement, break_, continue_ *Block) {
+	var outerBreak, outerContinue = b.breakTarget, b.continueTarget
+	b.breakTarget, b.continueTarget = break_, continue_
+	b.statement(n)
+	b.breakTarget, b.continueTarget = outerBreak, outerContinue
+}
+
+func (b *builder) jumpStatement(n *ast.JumpStatement) {
+	b.expression(n)
+	switch {
+	case n.GotoIdent != nil:
+		b.jump(b.label(*n.GotoIdent, n))
+	case n.IsContinue:
+		if b.continueTarget != nil {
+			b.jump(b.continueTarget)
+		}
+	case n.IsBreak:
+		if b.breakTarget != nil {
+			b.jump(b.breakTarget)
+		}
+	default:
+		b.jump(b.cfg.Exit)
+	}
+	b.unreachable(n)
+}
+
+// condition ends the current block with the evaluation of the condition
+// n, branching to t when it is true and to f otherwise. The operands of
+// &&, || and ?: are evaluated in blocks of their own.
+func (b *builder) condition(n ast.Node, t, f *Block) {
+	switch n := unparen(n).(type) {
+	case *ast.LogicalOrExpression:
+		var operands = n.LogicalAndExpressions
+		for _, operand := range operands[:len(operands)-1] {
+			var next = b.newBlock(KindCondFalse, n)
+			b.condition(operand, t, next)
+			b.current = next
+		}
+		b.condition(operands[len(operands)-1], t, f)
+	case *ast.LogicalAndExpression:
+		var operands = n.InclusiveOrExpressions
+		for _, operand := range operands[:len(operands)-1] {
+			var next = b.newBlock(KindCondTrue, n)
+			b.condition(operand, next, f)
+			b.current = next
+		}
+		b.condition(operands[len(operands)-1], t, f)
+	case *ast.ConditionalExpression:
+		var then = b.newBlock(KindCondTrue, n)
+		var els = b.newBlock(KindCondFalse, n)
+		b.condition(n.LogicalOrExpression, then, els)
+		b.current = then
+		b.condition(n.TernaryTrueExpression, t, f)
+		b.current = els
+		b.condition(n.TernaryFalseExpression, t, f)
+	default:
+		if b.full != nil {
+			b.cfg.Full[n] = b.full
+		}
+		b.expression(n)
+		b.current.Cond = n
+		if b.config.Constant != nil {
+			if value, ok := b.config.Constant(n); ok {
+				if value != 0 {
+					b.jump(t)
+					b.current.Pruned = f
+				} else {
+					b.jump(f)
+					b.current.Pruned = t
+				}
+				return
+			}
+		}
+		b.jump(t)
+		b.jump(f)
+	}
+}
+
+// expression adds the node n, after the operands of the &&, || and ?:
+// operators of its expressions, evaluated in blocks of their own. The
+// operand of sizeof is not evaluated and is left whole.
+func (b *builder) expression(n ast.Node) {
+	var outer = b.full
+	if outer == nil {
+		b.full = n
+	}
+	ast.Inspect(n, func(node ast.Node) bool {
+		if unary, ok := node.(*ast.UnaryExpression); ok && sizeof(unary) {
+			return false
+		}
+		if !ShortCircuit(node) {
+			return true
+		}
+		b.value(node)
+		return false
+	})
+	b.full = outer
+	b.add(n)
+}
+
+// value evaluates the operands of the &&, || or ?: operator n for its
+// value, continuing in the block joining them.
+func (b *builder) value(n ast.Node) {
+	var done = b.newBlock(KindCondDone, n)
+	switch n := n.(type) {
+	case *ast.LogicalOrExpression:
+		var operands = n.LogicalAndExpressions
+		for _, operand := range operands[:len(operands)-1] {
+			var next = b.newBlock(KindCondFalse, n)
+			b.condition(operand, done, next)
+			b.current = next
+		}
+		b.operand(operands[len(operands)-1])
+	case *ast.LogicalAndExpression:
+		var operands = n.InclusiveOrExpressions
+		for _, operand := range operands[:len(operands)-1] {
+			var next = b.newBlock(KindCondTrue, n)
+			b.condition(operand, next, done)
+			b.current = next
+		}
+		b.operand(operands[len(operands)-1])
+	case *ast.ConditionalExpression:
+		var then = b.newBlock(KindCondTrue, n)
+		var els = b.newBlock(KindCondFalse, n)
+		b.condition(n.LogicalOrExpression, then, els)
+		b.current = then
+		b.operand(n.TernaryTrueExpression)
+		b.jump(done)
+		b.current = els
+		b.operand(n.TernaryFalseExpression)
+	}
+	b.jump(done)
+	b.current = done
+}
+
+// operand adds the operand n of a &&, || or ?: operator whose value is
+// not tested, as the last operand of && and || and the last two of ?:.
+func (b *builder) operand(n ast.Node) {
+	if inner := unparen(n); ShortCircuit(inner) {
+		b.value(inner)
+		return
+	}
+	b.cfg.Full[n] = b.full
+	b.expression(n)
+}
+
+// sizeof reports whether n is a sizeof expression.
+func sizeof(n *ast.UnaryExpression) bool {
+	if n.SizeOfTypeName != nil {
+		return true
+	}
+	for _, op := range n.UnaryOperators {
+		if op == "sizeof" {
+			return true
+		}
+	}
+	return false
+}
+
+// unparen returns the outermost node of the expression n that is not a
+// mere wrapper of a single operand, looking through parentheses, so that
+// the &&, || and ?: operators of a condition are found.
+func unparen(n ast.Node) ast.Node {
+	for {
+		if ShortCircuit(n) {
+			return n
+		}
+		var children = ast.Children(n)
+		if len(children) != 1 {
+			return n
+		}
+		if _, ok := children[0].(*ast.TypeName); ok {
+			return n
+		}
+		n = children[0]
+	}
+}
@@ -0,0 +1,169 @@
+// Package cfg builds the control-flow graphs of function definitions.
+//
+// A graph is made of basic blocks holding the syntax nodes evaluated one
+// after the other: the init declarators of declarations, the expressions
+// of expression statements and loop headers, controlling expressions and
+// jump statements. Expressions are split at the short-circuit operators
+// &&, || and ?: so that each operand is a node of a block of its own.
+// Outside conditions, the expression holding these operators follows
+// their operands in the block joining them, as r = a ? b : 0 follows a, b
+// and 0.
+package cfg
+
+import "lazarus-c/src/ast"
+
+// CFG is the control-flow graph of a function body. Blocks[0] is the
+// entry block.
+type CFG struct {
+	Blocks []*Block
+	// End is the block reached at the closing brace of the body, live
+	// when the function can end without a return statement.
+	End *Block
+	// Exit follows the End block and every return statement.
+	Exit *Block
+	// Full maps the operands of the &&, || and ?: operators of a node to
+	// that node, the full expression following them.
+	Full map[ast.Node]ast.Node
+}
+
+// Block is a basic block. When its last node is a condition, the block
+// has two successors, taken when the condition is true and when it is
+// false, unless the condition is constant and only one of them remains.
+// Blocks ending with a switch expression have the blocks of the case
+// labels as successors, in source order, followed by the block of the
+// default label or the one following the switch.
+type Block struct {
+	Index int
+	Kind  Kind
+	Nodes []ast.Node
+	Succs []*Block
+	// Cond is the condition ending the block, if any.
+	Cond ast.Node
+	// Pruned is the successor that the constant condition ending the
+	// block never branches to, if any.
+	Pruned *Block
+	// Stmt is the statement the block was created for.
+	Stmt ast.Node
+	// Live is set when the block is reachable from the entry.
+	Live bool
+}
+
+// Kind describes why a block was created.
+type Kind int
+
+const (
+	KindEntry Kind = iota
+	KindExit
+	KindEnd
+	KindUnreachable
+	KindIfThen
+	KindIfElse
+	KindIfDone
+	KindCondTrue
+	KindCondFalse
+	KindCondDone
+	KindSwitchCase
+	KindSwitchDefault
+	KindSwitchDone
+	KindWhileLoop
+	KindWhileBody
+	KindWhileDone
+	KindDoBody
+	KindDoTest
+	KindDoDone
+	KindForLoop
+	KindForBody
+	KindForPost
+	KindForDone
+	KindLabel
+)
+
+var kindNames = [...]string{
+	KindEntry:         "entry",
+	KindExit:          "exit",
+	KindEnd:           "end",
+	KindUnreachable:   "unreachable",
+	KindIfThen:        "if.then",
+	KindIfElse:        "if.else",
+	KindIfDone:        "if.done",
+	KindCondTrue:      "cond.true",
+	KindCondFalse:     "cond.false",
+	KindCondDone:      "cond.done",
+	KindSwitchCase:    "switch.case",
+	KindSwitchDefault: "switch.default",
+	KindSwitchDone:    "switch.done",
+	KindWhileLoop:     "while.loop",
+	KindWhileBody:     "while.body",
+	KindWhileDone:     "while.done",
+	KindDoBody:        "do.body",
+	KindDoTest:        "do.test",
+	KindDoDone:        "do.done",
+	KindForLoop:       "for.loop",
+	KindForBody:       "for.body",
+	KindForPost:       "for.post",
+	KindForDone:       "for.done",
+	KindLabel:         "label",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Config describes what the builder of a graph knows beyond the syntax.
+type Config struct {
+	// Constant returns the value of the conditions that are integer
+	// constant expressions, so that the graph of a loop like while (1)
+	// has no edge out of its condition.
+	Constant func(ast.Node) (int64, bool)
+	// NoReturn reports whether the expression of an expression statement
+	// calls a function that never returns, like exit, so that nothing
+	// follows it in the graph.
+	NoReturn func(*ast.Expression) bool
+}
+
+// New builds the control-flow graph of fn from its syntax alone.
+func New(fn *ast.FunctionDefinition) *CFG {
+	return (&Config{}).New(fn)
+}
+
+// New builds the control-flow graph of fn.
+func (c *Config) New(fn *ast.FunctionDefinition) *CFG {
+	var b = &builder{cfg: &CFG{Full: map[ast.Node]ast.Node{}}, config: c, labels: map[string]*Block{}}
+	b.current = b.newBlock(KindEntry, fn)
+	b.cfg.Exit = &Block{Kind: KindExit, Stmt: fn}
+	b.compoundStatement(fn.CompoundStatement)
+	b.cfg.End = b.newBlock(KindEnd, fn.CompoundStatement)
+	b.jump(b.cfg.End)
+	b.cfg.End.Succs = []*Block{b.cfg.Exit}
+
+	b.cfg.Exit.Index = len(b.cfg.Blocks)
+	b.cfg.Blocks = append(b.cfg.Blocks, b.cfg.Exit)
+	mark(b.cfg.Blocks[0])
+	return b.cfg
+}
+
+// ShortCircuit reports whether n is a && or || operator with several
+// operands or a ?: operator, whose operands are nodes of their own in the
+// graph.
+func ShortCircuit(n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.LogicalOrExpression:
+		return len(n.LogicalAndExpressions) > 1
+	case *ast.LogicalAndExpression:
+		return len(n.InclusiveOrExpressions) > 1
+	case *ast.ConditionalExpression:
+		return n.TernaryTrueExpression != nil
+	}
+	return false
+}
+
+// mark sets Live on the blocks reachable from block.
+func mark(block *Block) {
+	if block.Live {
+		return
+	}
+	block.Live = true
+	for _, succ := range block.Succs {
+		mark(succ)
+	}
+}
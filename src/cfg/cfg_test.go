@@ -0,0 +1,161 @@
+package cfg
+
+import (
+	"fmt"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/printer"
+	"strings"
+	"testing"
+)
+
+// describe writes the blocks of g one per line, as their index and kind,
+// their nodes between brackets and the indexes of their successors.
+func describe(t *testing.T, g *CFG) string {
+	t.Helper()
+	var lines []string
+	for _, block := range g.Blocks {
+		var nodes []string
+		for _, n := range block.Nodes {
+			var src, err = printer.Sprint(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nodes = append(nodes, strings.Join(strings.Fields(src), " "))
+		}
+		var succs []string
+		for _, succ := range block.Succs {
+			succs = append(succs, fmt.Sprint(succ.Index))
+		}
+		lines = append(lines, fmt.Sprintf("%d %s [%s] -> %s", block.Index, block.Kind, strings.Join(nodes, "; "), strings.Join(succs, " ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// graphs lists function definitions with the blocks of their graph.
+var graphs = []struct {
+	name  string
+	src   string
+	graph string
+}{
+	{
+		"straight line",
+		"int f(int a) { int b = a; a = b; return a; }",
+		`0 entry [b = a; a = b; return a;] -> 3
+1 unreachable [] -> 2
+2 end [] -> 3
+3 exit [] -> `,
+	},
+	{
+		"if with && condition",
+		"void f(int a, int b) { if (a && b) a = 0; else b = 0; }",
+		`0 entry [a] -> 4 3
+1 if.then [a = 0] -> 2
+2 if.done [] -> 5
+3 if.else [b = 0] -> 2
+4 cond.true [b] -> 1 3
+5 end [] -> 6
+6 exit [] -> `,
+	},
+	{
+		"?: in an expression statement",
+		"void f(int c) { int x; c ? (x = 1) : (x = 2); }",
+		`0 entry [x; c] -> 2 3
+1 cond.done [c ? (x = 1) : (x = 2)] -> 4
+2 cond.true [(x = 1)] -> 1
+3 cond.false [(x = 2)] -> 1
+4 end [] -> 5
+5 exit [] -> `,
+	},
+	{
+		"|| in an initializer and && in a return",
+		"int f(int a, int b) { int c = a || b; return c && a; }",
+		`0 entry [a] -> 1 2
+1 cond.done [c = a || b; c] -> 4 3
+2 cond.false [b] -> 1
+3 cond.done [return c && a;] -> 7
+4 cond.true [a] -> 3
+5 unreachable [] -> 6
+6 end [] -> 7
+7 exit [] -> `,
+	},
+	{
+		"sizeof operand",
+		"int f(int a) { return sizeof(a && a); }",
+		`0 entry [return sizeof(a && a);] -> 3
+1 unreachable [] -> 2
+2 end [] -> 3
+3 exit [] -> `,
+	},
+	{
+		"while loop with break",
+		"void f(int a) { while (a) { if (a > 2) break; a--; } }",
+		`0 entry [] -> 1
+1 while.loop [a] -> 2 3
+2 while.body [a > 2] -> 4 5
+3 while.done [] -> 7
+4 if.then [break;] -> 3
+5 if.done [a--] -> 1
+6 unreachable [] -> 5
+7 end [] -> 8
+8 exit [] -> `,
+	},
+	{
+		"switch with default",
+		"int f(int a) { switch (a) { case 1: a = 2; default: return a; } return 0; }",
+		`0 entry [a] -> 2 3
+1 switch.done [return 0;] -> 8
+2 switch.case [a = 2] -> 3
+3 switch.default [return a;] -> 8
+4 unreachable [] -> 2
+5 unreachable [] -> 1
+6 unreachable [] -> 7
+7 end [] -> 8
+8 exit [] -> `,
+	},
+	{
+		"goto",
+		"void f(int a) { again: if (a--) goto again; }",
+		`0 entry [] -> 1
+1 label [a--] -> 2 3
+2 if.then [goto again;] -> 1
+3 if.done [] -> 5
+4 unreachable [] -> 3
+5 end [] -> 6
+6 exit [] -> `,
+	},
+}
+
+func TestNew(t *testing.T) {
+	for _, test := range graphs {
+		var unit, err = ast.ParseString(test.src)
+		if err != nil {
+			t.Errorf("%s: parsing: %v", test.name, err)
+			continue
+		}
+		var g = New(unit.ExternalDeclarations[0].FunctionDefinition)
+		if got := describe(t, g); got != test.graph {
+			t.Errorf("%s: got graph\n%s\ninstead of\n%s", test.name, got, test.graph)
+		}
+	}
+}
+
+func TestFull(t *testing.T) {
+	var unit, err = ast.ParseString("int f(int a, int b) { int c = a || b; if (a && b) c = 0; return c; }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var g = New(unit.ExternalDeclarations[0].FunctionDefinition)
+	var full []string
+	for _, block := range g.Blocks {
+		for _, n := range block.Nodes {
+			if g.Full[n] != nil {
+				var operand, _ = printer.Sprint(n)
+				var expr, _ = printer.Sprint(g.Full[n])
+				full = append(full, operand+" in "+expr)
+			}
+		}
+	}
+	if got, want := strings.Join(full, ", "), "a in c = a || b, b in c = a || b"; got != want {
+		t.Errorf("got full expressions %s instead of %s", got, want)
+	}
+}
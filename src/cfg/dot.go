@@ -0,0 +1,50 @@
+package cfg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"lazarus-c/src/printer"
+	"strings"
+)
+
+// WriteDOT writes g to w as a Graphviz digraph called name. Every block
+// becomes one vertex labelled with its index, its kind and the source of
+// its nodes. The edges out of a condition are labelled true and false,
+// and unreachable blocks are drawn dashed.
+func WriteDOT(w io.Writer, name string, g *CFG) error {
+	var out = bufio.NewWriter(w)
+	fmt.Fprintf(out, "digraph %q {\n", name)
+	out.WriteString("\tnode [shape=box, fontname=\"monospace\"];\n")
+	for _, block := range g.Blocks {
+		var label = []string{fmt.Sprintf("%d: %s", block.Index, block.Kind)}
+		for _, n := range block.Nodes {
+			var src, err = printer.Sprint(n)
+			if err != nil {
+				return err
+			}
+			label = append(label, strings.Join(strings.Fields(src), " "))
+		}
+		var style = ""
+		if !block.Live {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(out, "\tb%d [label=\"%s\\l\"%s];\n", block.Index, dotEscape(strings.Join(label, "\n")), style)
+	}
+	for _, block := range g.Blocks {
+		for idx, succ := range block.Succs {
+			var attrs = ""
+			if block.Cond != nil && len(block.Succs) == 2 {
+				attrs = fmt.Sprintf(" [label=\"%t\"]", idx == 0)
+			}
+			fmt.Fprintf(out, "\tb%d -> b%d%s;\n", block.Index, succ.Index, attrs)
+		}
+	}
+	out.WriteString("}\n")
+	return out.Flush()
+}
+
+func dotEscape(s string) string {
+	var replacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\l`)
+	return replacer.Replace(s)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/diag"
+	"lazarus-c/src/sema"
+	"os"
+	"sort"
+	"strings"
+)
+
+func check(args []string) error {
+	var flags = flag.NewFlagSet("check", flag.ExitOnError)
+	var warnings = warningFlags{}
+	flags.Var(warnings, "W", "enable the warning `name`, or disable it with no-name; may be repeated")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: lazarus check [-W name]... [files]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var filenames = flags.Args()
+	if len(filenames) == 0 {
+		filenames = []string{"-"}
+	}
+	var errors = 0
+	var report = func(diags diag.List) {
+		diags = diags.Filter(warnings.enabled)
+		diags.Sort()
+		diags.Print(os.Stderr)
+		for _, d := range diags {
+			if d.Severity == diag.Error {
+				errors++
+			}
+		}
+	}
+	var units []*sema.Unit
+	for _, filename := range filenames {
+		var name, src, err = readInput(filename)
+		if err != nil {
+			return err
+		}
+		unit, err := ast.ParseBytes(name, src)
+		if err != nil {
+			return err
+		}
+		var diags diag.List
+		var info = (&sema.Config{Source: src}).Check(unit, &diags)
+		report(diags)
+		units = append(units, &sema.Unit{Syntax: unit, Info: info})
+	}
+	// Files checked together are taken to form one program.
+	if len(units) > 1 {
+		var diags diag.List
+		sema.Link(units, &diags)
+		report(diags)
+	}
+	if errors > 0 {
+		return fmt.Errorf("%d errors", errors)
+	}
+	return nil
+}
+
+// warningFlags records the warnings enabled and disabled by name on the
+// command line. Warnings are enabled by default, except those of
+// sema.OptIn.
+type warningFlags map[string]bool
+
+func (w warningFlags) String() string {
+	var names []string
+	for name, enabled := range w {
+		if !enabled {
+			name = "no-" + name
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (w warningFlags) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		if name == "" {
+			return fmt.Errorf("empty warning name")
+		}
+		var disabled, off = strings.CutPrefix(name, "no-")
+		if off {
+			name = disabled
+		}
+		if !sema.Warnings[name] {
+			return fmt.Errorf("unknown warning %q", name)
+		}
+		w[name] = !off
+	}
+	return nil
+}
+
+func (w warningFlags) enabled(name string) bool {
+	if enabled, ok := w[name]; ok {
+		return enabled
+	}
+	return !sema.OptIn[name]
+}
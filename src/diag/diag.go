@@ -0,0 +1,142 @@
+// Package diag holds the diagnostics reported by the analyses of a
+// program.
+package diag
+
+import (
+	"fmt"
+	"io"
+	"lazarus-c/src/lexer"
+	"sort"
+	"strings"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Note
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	}
+	return "note"
+}
+
+// Diagnostic is a message about a position of the source.
+type Diagnostic struct {
+	Pos      lexer.Position
+	Severity Severity
+	// Name identifies the check reporting a warning, so that it can be
+	// enabled or disabled.
+	Name    string
+	Message string
+	// Notes point at other positions involved, such as a previous
+	// declaration.
+	Notes []*Diagnostic
+	// Fixes are edits of the source suggested to address d.
+	Fixes []*Fix
+}
+
+// Fix is a suggested edit of the source: the insertion of Text at Pos.
+type Fix struct {
+	Pos  lexer.Position
+	Text string
+}
+
+// Note adds a note at pos to d and returns d.
+func (d *Diagnostic) Note(pos lexer.Position, format string, args ...any) *Diagnostic {
+	d.Notes = append(d.Notes, &Diagnostic{Pos: pos, Severity: Note, Message: fmt.Sprintf(format, args...)})
+	return d
+}
+
+// Fix suggests inserting text at pos to address d and returns d.
+func (d *Diagnostic) Fix(pos lexer.Position, text string) *Diagnostic {
+	d.Fixes = append(d.Fixes, &Fix{pos, text})
+	return d
+}
+
+// String formats d like compilers do, followed by its suggested fixes and
+// its notes on lines of their own.
+func (d *Diagnostic) String() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "%s: %s: %s", d.Pos, d.Severity, d.Message)
+	if d.Name != "" {
+		fmt.Fprintf(&out, " [-W%s]", d.Name)
+	}
+	for _, fix := range d.Fixes {
+		fmt.Fprintf(&out, "\n%s: fix-it: insert %q", fix.Pos, fix.Text)
+	}
+	for _, note := range d.Notes {
+		out.WriteString("\n")
+		out.WriteString(note.String())
+	}
+	return out.String()
+}
+
+func (d *Diagnostic) Error() string {
+	return d.String()
+}
+
+// List collects diagnostics.
+type List []*Diagnostic
+
+// Errorf adds an error at pos.
+func (l *List) Errorf(pos lexer.Position, format string, args ...any) *Diagnostic {
+	var d = &Diagnostic{Pos: pos, Severity: Error, Message: fmt.Sprintf(format, args...)}
+	*l = append(*l, d)
+	return d
+}
+
+// Warnf adds a warning at pos reported by the check called name.
+func (l *List) Warnf(pos lexer.Position, name string, format string, args ...any) *Diagnostic {
+	var d = &Diagnostic{Pos: pos, Severity: Warning, Name: name, Message: fmt.Sprintf(format, args...)}
+	*l = append(*l, d)
+	return d
+}
+
+// HasErrors reports whether l holds any error.
+func (l List) HasErrors() bool {
+	for _, d := range l {
+		if d.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the diagnostics of l that are errors or warnings whose
+// name enabled accepts.
+func (l List) Filter(enabled func(name string) bool) List {
+	var filtered List
+	for _, d := range l {
+		if d.Severity != Warning || enabled(d.Name) {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// Sort orders l by file and position, keeping the order of diagnostics
+// reported at the same position.
+func (l List) Sort() {
+	sort.SliceStable(l, func(i, j int) bool {
+		var a, b = l[i].Pos, l[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+}
+
+// Print writes the diagnostics of l to w, one after the other.
+func (l List) Print(w io.Writer) {
+	for _, d := range l {
+		fmt.Fprintln(w, d)
+	}
+}
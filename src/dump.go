@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"lazarus-c/src/ast"
+	"os"
+	"strings"
+)
+
+func dump(args []string) error {
+	var flags = flag.NewFlagSet("dump", flag.ExitOnError)
+	var format = flags.String("format", "tree", "output format: tree, dot or sexpr")
+	var positions = flags.Bool("positions", true, "show node positions in tree output")
+	var collapse = flags.Bool("collapse", false, "collapse single-child nodes in tree output")
+	var depth = flags.Int("depth", 0, "maximum depth of tree output, 0 for no limit")
+	var types = flags.String("types", "", "comma-separated node types to keep in tree output")
+	var color = flags.Bool("color", false, "colorize tree output")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: lazarus dump [-format=tree|dot|sexpr] [flags] [file]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var config = &ast.PrintConfig{
+		Positions: *positions,
+		Collapse:  *collapse,
+		MaxDepth:  *depth,
+		Color:     *color,
+	}
+	if *types != "" {
+		config.Types = strings.Split(*types, ",")
+	}
+
+	var unit, err = parseInput(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "tree":
+		_, err = fmt.Println(config.Sprint(unit))
+	case "dot":
+		err = ast.WriteDOT(os.Stdout, unit)
+	case "sexpr":
+		err = ast.WriteSExpr(os.Stdout, unit)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	return err
+}
+
+// parseInput parses the named file, or standard input when the name is
+// empty or "-".
+func parseInput(filename string) (*ast.TranslationUnit, error) {
+	var r io.Reader = os.Stdin
+	if filename != "" && filename != "-" {
+		var file, err = os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+	return ast.Parse(r)
+}
+
+// readInput returns the contents of the named file, or of standard input
+// when the name is empty or "-", with the name to report positions in.
+func readInput(filename string) (string, []byte, error) {
+	if filename == "" || filename == "-" {
+		var src, err = io.ReadAll(os.Stdin)
+		return "<standard input>", src, err
+	}
+	var src, err = os.ReadFile(filename)
+	return filename, src, err
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"lazarus-c/src/format"
+	"os"
+)
+
+func fmtCommand(args []string) error {
+	var flags = flag.NewFlagSet("fmt", flag.ExitOnError)
+	var write = flags.Bool("w", false, "write result to the source file instead of standard output")
+	var list = flags.Bool("l", false, "list files whose formatting differs")
+	var diff = flags.Bool("d", false, "display diffs instead of rewriting files")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: lazarus fmt [-w] [-l] [-d] [files]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		if *write {
+			return fmt.Errorf("cannot use -w with standard input")
+		}
+		var src, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		return formatFile("<standard input>", src, false, *list, *diff)
+	}
+
+	for _, filename := range flags.Args() {
+		var src, err = os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		if err := formatFile(filename, src, *write, *list, *diff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatFile formats src and reports the result as selected by the flags.
+// Without any flag the formatted source goes to standard output.
+func formatFile(filename string, src []byte, write, list, diff bool) error {
+	var res, err = format.Source(filename, src)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(src, res) {
+		if list {
+			fmt.Println(filename)
+		}
+		if write {
+			var info, err = os.Stat(filename)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(filename, res, info.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+		if diff {
+			os.Stdout.Write(format.Diff(filename+".orig", filename, src, res))
+		}
+	}
+
+	if !list && !write && !diff {
+		_, err = os.Stdout.Write(res)
+	}
+	return err
+}
@@ -0,0 +1,163 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+type edit struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Diff returns the unified diff turning a into b, or nil when they are
+// equal. The names are used in the header of the diff.
+func Diff(oldName, newName string, a, b []byte) []byte {
+	if bytes.Equal(a, b) {
+		return nil
+	}
+	var edits = diffLines(splitLines(a), splitLines(b))
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+	for start := 0; start < len(edits); {
+		if edits[start].kind == ' ' {
+			start++
+			continue
+		}
+
+		// A hunk runs until more than twice the context separates two
+		// changes.
+		var end = start
+		for idx := start; idx < len(edits) && idx-end <= 2*diffContext; idx++ {
+			if edits[idx].kind != ' ' {
+				end = idx + 1
+			}
+		}
+		var first = max(start-diffContext, 0)
+		var last = min(end+diffContext, len(edits))
+
+		var oldStart, newStart = 1, 1
+		for _, e := range edits[:first] {
+			if e.kind != '+' {
+				oldStart++
+			}
+			if e.kind != '-' {
+				newStart++
+			}
+		}
+		var oldCount, newCount = 0, 0
+		for _, e := range edits[first:last] {
+			if e.kind != '+' {
+				oldCount++
+			}
+			if e.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		for _, e := range edits[first:last] {
+			out.WriteByte(e.kind)
+			out.WriteString(e.line)
+			if len(e.line) == 0 || e.line[len(e.line)-1] != '\n' {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = last
+	}
+	return out.Bytes()
+}
+
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprint(start)
+	default:
+		return fmt.Sprintf("%d,%d", start, count)
+	}
+}
+
+// splitLines cuts s after each newline.
+func splitLines(s []byte) []string {
+	var lines []string
+	for len(s) > 0 {
+		var idx = bytes.IndexByte(s, '\n') + 1
+		if idx == 0 {
+			idx = len(s)
+		}
+		lines = append(lines, string(s[:idx]))
+		s = s[idx:]
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script from a to b with the greedy
+// algorithm of Myers.
+func diffLines(a, b []string) []edit {
+	var n, m = len(a), len(b)
+	var offset = n + m
+	var v = make([]int, 2*offset+2)
+	var trace [][]int
+
+search:
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			var y = x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk the trace backwards to recover the edits.
+	var edits []edit
+	var x, y = n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		var v = trace[d]
+		var k = x - y
+		var prevK int
+		if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		var prevX = v[offset+prevK]
+		var prevY = prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{' ', a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, edit{'+', b[y]})
+		} else {
+			x--
+			edits = append(edits, edit{'-', a[x]})
+		}
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
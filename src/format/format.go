@@ -0,0 +1,61 @@
+// Package format implements the canonical formatting of C source files.
+package format
+
+import (
+	"bytes"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/lexer"
+	"lazarus-c/src/printer"
+	"strings"
+
+	plexer "github.com/alecthomas/participle/v2/lexer"
+)
+
+// Source formats src, the content of the named file. Comments are kept
+// and blank lines between declarations and statements are collapsed to
+// one, everything else follows the layout of the printer package.
+func Source(filename string, src []byte) ([]byte, error) {
+	var unit, err = ast.ParseBytes(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := Comments(filename, src)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	var config = &printer.Config{Comments: comments, Source: src}
+	if err := config.Fprint(&out, unit); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Comments returns the comments of src in the order they appear.
+func Comments(filename string, src []byte) ([]printer.Comment, error) {
+	var lex, err = lexer.Lexer.Lex(filename, bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := plexer.ConsumeAll(lex)
+	if err != nil {
+		return nil, err
+	}
+
+	var comment = lexer.Lexer.Symbols()["Comment"]
+	var comments []printer.Comment
+	for _, token := range tokens {
+		if token.Type != comment {
+			continue
+		}
+		var lineStart = bytes.LastIndexByte(src[:token.Pos.Offset], '\n') + 1
+		var before = src[lineStart:token.Pos.Offset]
+		comments = append(comments, printer.Comment{
+			Pos:      lexer.Position(token.Pos),
+			Text:     strings.TrimRight(token.Value, " \t\r"),
+			Trailing: len(bytes.TrimSpace(before)) > 0,
+		})
+	}
+	return comments, nil
+}
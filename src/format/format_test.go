@@ -0,0 +1,56 @@
+package format
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// TestGolden formats every testdata/*.input file, compares the result with
+// the matching .golden file, and checks that formatting it again changes
+// nothing.
+func TestGolden(t *testing.T) {
+	var inputs, err = filepath.Glob(filepath.Join("testdata", "*.input"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range inputs {
+		var golden = strings.TrimSuffix(input, ".input") + ".golden"
+		src, err := os.ReadFile(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Source(input, src)
+		if err != nil {
+			t.Errorf("%s: %v", input, err)
+			continue
+		}
+
+		if *update {
+			if err := os.WriteFile(golden, got, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: formatted as\n%s\ninstead of\n%s", input, got, want)
+		}
+
+		again, err := Source(golden, got)
+		if err != nil {
+			t.Errorf("%s: %v", golden, err)
+			continue
+		}
+		if !bytes.Equal(again, got) {
+			t.Errorf("%s: formatted again as\n%s\ninstead of\n%s", input, again, got)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package interp
+
+import (
+	"fmt"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/types"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// builtins implements the functions of the C library a program may call
+// without defining them. They receive their arguments converted as in a
+// call through their prototype, or promoted for variadic arguments.
+var builtins = map[string]func(in *interp, n ast.Node, args []value) value{
+	"printf": printf,
+	"malloc": malloc,
+	"free":   free,
+	"memcpy": memcpy,
+	"strlen": strlen,
+}
+
+// argument returns args[idx], failing when the call passes fewer
+// arguments.
+func argument(in *interp, n ast.Node, args []value, idx int) value {
+	if idx >= len(args) {
+		in.errorf(n, "too few arguments in call")
+	}
+	return args[idx]
+}
+
+func malloc(in *interp, n ast.Node, args []value) value {
+	var size = argument(in, n, args, 0).bits
+	if size > 1<<32 {
+		return value{t: &types.Pointer{Elem: types.Typ[types.Void]}}
+	}
+	var b = in.alloc(int64(size), "memory allocated by malloc", false)
+	b.heap = true
+	return value{t: &types.Pointer{Elem: types.Typ[types.Void]}, bits: b.addr, prov: b}
+}
+
+func free(in *interp, n ast.Node, args []value) value {
+	var p = argument(in, n, args, 0)
+	if p.bits == 0 {
+		return value{t: types.Typ[types.Void]}
+	}
+	var b = p.prov
+	if !in.checked || b == nil {
+		b = in.block(p.bits)
+	}
+	switch {
+	case b != nil && b.heap && b.freed:
+		in.errorf(n, "double free of %s", b.what)
+	case b == nil || !b.heap || b.addr != p.bits:
+		in.errorf(n, "free of address %#x not returned by malloc", p.bits)
+	}
+	in.free(b)
+	return value{t: types.Typ[types.Void]}
+}
+
+func memcpy(in *interp, n ast.Node, args []value) value {
+	var dst, src = argument(in, n, args, 0), argument(in, n, args, 1)
+	var size = int64(argument(in, n, args, 2).bits)
+	if size != 0 {
+		var from, offset = in.object(n, src.bits, src.prov, size, false)
+		var to, at = in.object(n, dst.bits, dst.prov, size, true)
+		copy(to.data[at:at+uint64(size)], from.data[offset:offset+uint64(size)])
+		to.shadow.restore(at, from.shadow.slice(offset, size))
+	}
+	return dst
+}
+
+func strlen(in *interp, n ast.Node, args []value) value {
+	var s = in.cstring(n, argument(in, n, args, 0))
+	return value{t: sizeType, bits: uint64(len(s))}
+}
+
+// printf writes its arguments formatted as the C function does and
+// returns the number of bytes written.
+func printf(in *interp, n ast.Node, args []value) value {
+	var format = in.cstring(n, argument(in, n, args, 0))
+	var next = 1
+	var arg = func() value {
+		next++
+		return argument(in, n, args, next-1)
+	}
+
+	var out strings.Builder
+	for idx := 0; idx < len(format); idx++ {
+		if format[idx] != '%' {
+			out.WriteByte(format[idx])
+			continue
+		}
+		var spec = conversion{precision: -1}
+		idx++
+		for ; idx < len(format) && strings.IndexByte("-+ #0", format[idx]) >= 0; idx++ {
+			spec.flags += string(format[idx])
+		}
+		if idx < len(format) && format[idx] == '*' {
+			spec.width = int(int32(arg().bits))
+			if spec.width < 0 {
+				spec.flags += "-"
+				spec.width = -spec.width
+			}
+			idx++
+		}
+		for ; idx < len(format) && format[idx] >= '0' && format[idx] <= '9'; idx++ {
+			spec.width = spec.width*10 + int(format[idx]-'0')
+		}
+		if idx < len(format) && format[idx] == '.' {
+			spec.precision = 0
+			idx++
+			if idx < len(format) && format[idx] == '*' {
+				if spec.precision = int(int32(arg().bits)); spec.precision < 0 {
+					spec.precision = -1
+				}
+				idx++
+			}
+			for ; idx < len(format) && format[idx] >= '0' && format[idx] <= '9'; idx++ {
+				spec.precision = spec.precision*10 + int(format[idx]-'0')
+			}
+		}
+		for ; idx < len(format) && strings.IndexByte("hlzjtL", format[idx]) >= 0; idx++ {
+			spec.length += string(format[idx])
+		}
+		if idx == len(format) {
+			in.errorf(n, "incomplete conversion specification in format %q", format)
+		}
+		spec.verb = format[idx]
+		if spec.verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+		out.WriteString(in.format(n, spec, arg()))
+	}
+
+	var written, _ = in.stdout.Write([]byte(out.String()))
+	return value{t: intType, bits: uint64(written)}
+}
+
+// conversion is a conversion specification of printf.
+type conversion struct {
+	flags     string
+	width     int
+	precision int
+	length    string
+	verb      byte
+}
+
+func (c conversion) has(flag byte) bool {
+	return strings.IndexByte(c.flags, flag) >= 0
+}
+
+// format returns v formatted by the conversion specification c.
+func (in *interp) format(n ast.Node, c conversion, v value) string {
+	var s string
+	var numeric = true
+	switch c.verb {
+	case 'd', 'i':
+		var i = int64(in.norm(c.lengthType(true), v.bits))
+		s = strconv.FormatUint(uint64(i), 10)
+		if i < 0 {
+			s = strconv.FormatUint(uint64(-i), 10)
+		}
+		s = c.integer(s, i < 0)
+	case 'u', 'x', 'X', 'o':
+		var u = in.norm(c.lengthType(false), v.bits)
+		var base = map[byte]int{'u': 10, 'x': 16, 'X': 16, 'o': 8}[c.verb]
+		s = strconv.FormatUint(u, base)
+		if c.verb == 'X' {
+			s = strings.ToUpper(s)
+		}
+		c.flags = strings.NewReplacer("+", "", " ", "").Replace(c.flags)
+		switch {
+		case c.has('#') && c.verb == 'o' && s[0] != '0':
+			s = "0" + s
+		case c.has('#') && u != 0 && c.verb != 'o' && c.verb != 'u':
+			c.flags = strings.ReplaceAll(c.flags, "#", "")
+			s = c.integer(s, false)
+			if c.has('0') && !c.has('-') && c.precision < 0 {
+				return "0" + string(c.verb) + pad(s, c.width-2, '0', false)
+			}
+			s = "0" + string(c.verb) + s
+			return pad(s, c.width, ' ', c.has('-'))
+		}
+		s = c.integer(s, false)
+	case 'c':
+		s = string([]byte{byte(v.bits)})
+		numeric = false
+	case 's':
+		s = in.cstring(n, v)
+		if c.precision >= 0 && c.precision < len(s) {
+			s = s[:c.precision]
+		}
+		numeric = false
+	case 'p':
+		s = "(nil)"
+		if v.bits != 0 {
+			s = fmt.Sprintf("%#x", v.bits)
+		}
+		numeric = false
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		s = c.float(v.f)
+		numeric = !math.IsInf(v.f, 0) && !math.IsNaN(v.f)
+	default:
+		in.errorf(n, "unsupported conversion %%%c in format", c.verb)
+	}
+
+	if numeric && c.has('0') && !c.has('-') && (c.precision < 0 || strings.IndexByte("fFeEgG", c.verb) >= 0) {
+		var sign = ""
+		if s != "" && strings.IndexByte("+- ", s[0]) >= 0 {
+			sign, s = s[:1], s[1:]
+		}
+		return sign + pad(s, c.width-len(sign), '0', false)
+	}
+	return pad(s, c.width, ' ', c.has('-'))
+}
+
+// lengthType returns the integer type the length modifier of c gives to
+// its argument.
+func (c conversion) lengthType(signed bool) types.Type {
+	var kinds = map[string][2]types.Kind{
+		"hh": {types.SignedChar, types.UnsignedChar},
+		"h":  {types.Short, types.UnsignedShort},
+		"":   {types.Int, types.UnsignedInt},
+		"l":  {types.Long, types.UnsignedLong},
+		"ll": {types.LongLong, types.UnsignedLongLong},
+		"z":  {types.Long, types.UnsignedLong},
+		"j":  {types.LongLong, types.UnsignedLongLong},
+		"t":  {types.Long, types.UnsignedLong},
+	}
+	var kind, ok = kinds[c.length]
+	if !ok {
+		kind = kinds[""]
+	}
+	if signed {
+		return types.Typ[kind[0]]
+	}
+	return types.Typ[kind[1]]
+}
+
+// integer applies the precision and the sign flags of c to the digits s
+// of an integer, negative when minus is set.
+func (c conversion) integer(s string, minus bool) string {
+	if c.precision == 0 && s == "0" {
+		s = ""
+	}
+	s = pad(s, c.precision, '0', false)
+	switch {
+	case minus:
+		return "-" + s
+	case c.has('+'):
+		return "+" + s
+	case c.has(' '):
+		return " " + s
+	}
+	return s
+}
+
+// float formats f for the conversion c.
+func (c conversion) float(f float64) string {
+	var sign = ""
+	switch {
+	case math.Signbit(f) && !math.IsNaN(f):
+		sign = "-"
+	case c.has('+'):
+		sign = "+"
+	case c.has(' '):
+		sign = " "
+	}
+	var upper = c.verb >= 'A' && c.verb <= 'Z'
+	var s string
+	switch {
+	case math.IsInf(f, 0):
+		s = "inf"
+	case math.IsNaN(f):
+		s = "nan"
+	default:
+		var precision = c.precision
+		if precision < 0 {
+			precision = 6
+		}
+		var verb = c.verb | 0x20
+		if verb == 'g' && precision == 0 {
+			precision = 1
+		}
+		s = strconv.FormatFloat(math.Abs(f), verb, precision, 64)
+		if verb == 'g' && !c.has('#') {
+			s = trimZeros(s)
+		}
+		if c.has('#') && precision == 0 && verb != 'g' && !strings.Contains(s, ".") {
+			var e = strings.IndexByte(s, 'e')
+			if e < 0 {
+				s += "."
+			} else {
+				s = s[:e] + "." + s[e:]
+			}
+		}
+	}
+	if upper {
+		s = strings.ToUpper(s)
+	}
+	return sign + s
+}
+
+// trimZeros removes the trailing zeros of the fraction of s, as %g does.
+func trimZeros(s string) string {
+	var mantissa, exp = s, ""
+	if e := strings.IndexByte(s, 'e'); e >= 0 {
+		mantissa, exp = s[:e], s[e:]
+	}
+	if strings.Contains(mantissa, ".") {
+		mantissa = strings.TrimRight(strings.TrimRight(mantissa, "0"), ".")
+	}
+	return mantissa + exp
+}
+
+// pad pads s to width with c, on the left unless right is set.
+func pad(s string, width int, c byte, right bool) string {
+	if len(s) >= width {
+		return s
+	}
+	var padding = strings.Repeat(string(c), width-len(s))
+	if right {
+		return s + padding
+	}
+	return padding + s
+}
@@ -0,0 +1,533 @@
+package interp
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/sema"
+	"lazarus-c/src/types"
+	"strconv"
+	"strings"
+)
+
+// lvalue designates an object of type t at addr, or the bit-field field
+// of the structure at addr, in the object prov the address was derived
+// from. Functions are designated like objects, by the address standing
+// for their code.
+type lvalue struct {
+	addr  uint64
+	prov  *block
+	t     types.Type
+	field *types.FieldLayout
+}
+
+// lvalue returns the lvalue designating the object of type t held by b.
+func (b *block) lvalue(t types.Type) lvalue {
+	return lvalue{addr: b.addr, prov: b, t: t}
+}
+
+// operand is the result of evaluating the expression node: the object
+// lv when it is an lvalue, or else the value v.
+type operand struct {
+	node     ast.Node
+	isLvalue bool
+	lv       lvalue
+	v        value
+}
+
+// value evaluates the expression n for its value.
+func (in *interp) value(n ast.Node) value {
+	return in.rvalue(in.eval(n))
+}
+
+// rvalue returns the value of the operand x: arrays and functions become
+// pointers to their first element and to themselves, and objects are
+// read.
+func (in *interp) rvalue(x operand) value {
+	if !x.isLvalue {
+		return x.v
+	}
+	switch t := unqualified(x.lv.t).(type) {
+	case *types.Array:
+		return value{t: &types.Pointer{Elem: t.Elem}, bits: x.lv.addr, prov: x.lv.prov}
+	case *types.Function:
+		return value{t: &types.Pointer{Elem: t}, bits: x.lv.addr, prov: x.lv.prov}
+	}
+	return in.load(x.node, x.lv)
+}
+
+// assignTo stores v, converted to the type of the object lv, in lv and
+// returns the value stored. n is the expression designating lv.
+func (in *interp) assignTo(n ast.Node, lv lvalue, v value) value {
+	v = in.convert(v, lv.t)
+	in.store(n, lv, v)
+	if lv.field != nil {
+		return in.load(n, lv)
+	}
+	return v
+}
+
+// eval evaluates the expression n. Levels of the grammar without
+// operators are skipped by unwrap, so the cases below only handle nodes
+// applying some.
+func (in *interp) eval(n ast.Node) operand {
+	n = unwrap(n)
+	var result = func(v value) operand {
+		return operand{node: n, v: v}
+	}
+	switch n := n.(type) {
+	case *ast.Expression:
+		var v value
+		for _, e := range n.AssignmentExpressions {
+			v = in.value(e)
+		}
+		return result(v)
+	case *ast.AssignmentExpression:
+		var v = in.value(n.ConditionalExpression)
+		for idx := len(n.UnaryExpressions) - 1; idx >= 0; idx-- {
+			v = in.assign(n.UnaryExpressions[idx], *n.AssignmentOperators[idx].AssignmentOperator, v)
+		}
+		return result(v)
+	case *ast.ConditionalExpression:
+		var chosen ast.Node = n.TernaryFalseExpression
+		if isTrue(in.value(n.LogicalOrExpression)) {
+			chosen = n.TernaryTrueExpression
+		}
+		var v = in.value(chosen)
+		if t := in.info.Types[n].Type; !types.IsVoid(t) {
+			v = in.convert(v, t)
+		}
+		return result(v)
+	case *ast.LogicalOrExpression:
+		for _, e := range n.LogicalAndExpressions {
+			if isTrue(in.value(e)) {
+				return result(truth(true))
+			}
+		}
+		return result(truth(false))
+	case *ast.LogicalAndExpression:
+		for _, e := range n.InclusiveOrExpressions {
+			if !isTrue(in.value(e)) {
+				return result(truth(false))
+			}
+		}
+		return result(truth(true))
+	case *ast.InclusiveOrExpression:
+		return fold(in, n, "|", n.ExclusiveOrExpressions)
+	case *ast.ExclusiveOrExpression:
+		return fold(in, n, "^", n.AndExpressions)
+	case *ast.AndExpression:
+		return fold(in, n, "&", n.EqualityExpressions)
+	case *ast.EqualityExpression:
+		var v = in.value(n.HeadRelationalExpression)
+		for idx, e := range n.TailRelationalExpressions {
+			v = in.compare(n.Operators[idx], v, in.value(e))
+		}
+		return result(v)
+	case *ast.RelationalExpression:
+		var v = in.value(n.HeadShiftExpression)
+		for idx, e := range n.TailShiftExpressions {
+			v = in.compare(n.Operators[idx], v, in.value(e))
+		}
+		return result(v)
+	case *ast.ShiftExpression:
+		return chain(in, n, n.HeadAdditiveExpression, n.Operators, n.TailAdditiveExpressions)
+	case *ast.AdditiveExpression:
+		return chain(in, n, n.HeadMultiplicativeExpression, n.Operators, n.TailMultiplicativeExpression)
+	case *ast.MultiplicativeExpression:
+		return chain(in, n, n.HeadCastExpression, n.Operators, n.TailCastExpression)
+	case *ast.CastExpression:
+		var v = in.value(n.UnaryExpression)
+		for idx := len(n.TypeNames) - 1; idx >= 0; idx-- {
+			var t = in.info.Types[n.TypeNames[idx]].Type
+			in.lengths(t)
+			if types.IsVoid(t) {
+				v = value{t: unqualified(t)}
+			} else {
+				v = in.convert(v, t)
+			}
+		}
+		return result(v)
+	case *ast.UnaryExpression:
+		return in.unary(n)
+	case *ast.PostfixExpression:
+		var x = in.primary(n.PrimaryExpression)
+		for _, op := range n.PostfixOperators {
+			x = in.postfix(op, x)
+		}
+		return x
+	case *ast.PrimaryExpression:
+		return in.primary(n)
+	}
+	in.errorf(n, "cannot evaluate %T", n)
+	return operand{}
+}
+
+// unwrap returns the innermost of the nodes that make up the expression
+// n by themselves, skipping the levels of the grammar without operators.
+func unwrap(n ast.Node) ast.Node {
+	for {
+		switch e := n.(type) {
+		case *ast.Expression:
+			if len(e.AssignmentExpressions) != 1 {
+				return n
+			}
+			n = e.AssignmentExpressions[0]
+		case *ast.AssignmentExpression:
+			if len(e.UnaryExpressions) != 0 {
+				return n
+			}
+			n = e.ConditionalExpression
+		case *ast.ConditionalExpression:
+			if e.TernaryTrueExpression != nil {
+				return n
+			}
+			n = e.LogicalOrExpression
+		case *ast.LogicalOrExpression:
+			if len(e.LogicalAndExpressions) != 1 {
+				return n
+			}
+			n = e.LogicalAndExpressions[0]
+		case *ast.LogicalAndExpression:
+			if len(e.InclusiveOrExpressions) != 1 {
+				return n
+			}
+			n = e.InclusiveOrExpressions[0]
+		case *ast.InclusiveOrExpression:
+			if len(e.ExclusiveOrExpressions) != 1 {
+				return n
+			}
+			n = e.ExclusiveOrExpressions[0]
+		case *ast.ExclusiveOrExpression:
+			if len(e.AndExpressions) != 1 {
+				return n
+			}
+			n = e.AndExpressions[0]
+		case *ast.AndExpression:
+			if len(e.EqualityExpressions) != 1 {
+				return n
+			}
+			n = e.EqualityExpressions[0]
+		case *ast.EqualityExpression:
+			if len(e.TailRelationalExpressions) != 0 {
+				return n
+			}
+			n = e.HeadRelationalExpression
+		case *ast.RelationalExpression:
+			if len(e.TailShiftExpressions) != 0 {
+				return n
+			}
+			n = e.HeadShiftExpression
+		case *ast.ShiftExpression:
+			if len(e.TailAdditiveExpressions) != 0 {
+				return n
+			}
+			n = e.HeadAdditiveExpression
+		case *ast.AdditiveExpression:
+			if len(e.TailMultiplicativeExpression) != 0 {
+				return n
+			}
+			n = e.HeadMultiplicativeExpression
+		case *ast.MultiplicativeExpression:
+			if len(e.TailCastExpression) != 0 {
+				return n
+			}
+			n = e.HeadCastExpression
+		case *ast.CastExpression:
+			if len(e.TypeNames) != 0 {
+				return n
+			}
+			n = e.UnaryExpression
+		case *ast.UnaryExpression:
+			if e.PostfixExpression == nil || len(e.UnaryOperators) != 0 {
+				return n
+			}
+			n = e.PostfixExpression
+		case *ast.PostfixExpression:
+			if len(e.PostfixOperators) != 0 {
+				return n
+			}
+			n = e.PrimaryExpression
+		default:
+			return n
+		}
+	}
+}
+
+// chain evaluates the binary operators ops applied from left to right to
+// head and tails.
+func chain[T ast.Node](in *interp, n, head ast.Node, ops []string, tails []T) operand {
+	var v = in.value(head)
+	for idx, e := range tails {
+		v = in.binary(n, ops[idx], v, in.value(e))
+	}
+	return operand{node: n, v: v}
+}
+
+// fold evaluates the bitwise operator op applied from left to right to
+// operands.
+func fold[T ast.Node](in *interp, n ast.Node, op string, operands []T) operand {
+	var v = in.value(operands[0])
+	for _, e := range operands[1:] {
+		v = in.binary(n, op, v, in.value(e))
+	}
+	return operand{node: n, v: v}
+}
+
+// assign stores v in the object designated by target with the assignment
+// operator op, and returns the value stored.
+func (in *interp) assign(target ast.Node, op string, v value) value {
+	var x = in.eval(target)
+	if op != "=" {
+		v = in.binary(target, strings.TrimSuffix(op, "="), in.rvalue(x), v)
+	}
+	return in.assignTo(target, x.lv, v)
+}
+
+// increment adds 1 to, or with op "--" subtracts 1 from, the object x.
+// It returns the new value, or the old one when postfix is set.
+func (in *interp) increment(n ast.Node, op string, x operand, postfix bool) value {
+	var old = in.rvalue(x)
+	var v = in.assignTo(n, x.lv, in.binary(n, op[:1], old, value{t: intType, bits: 1}))
+	if postfix {
+		return old
+	}
+	return v
+}
+
+// constant returns the value sema computed for the expression n, if it
+// is an integer constant.
+func (in *interp) constant(n ast.Node) (operand, bool) {
+	var x, ok = in.info.Types[n]
+	if !ok || !x.Constant || !types.IsScalar(x.Type) || types.IsFloating(x.Type) {
+		return operand{}, false
+	}
+	var t = unqualified(x.Type)
+	return operand{node: n, v: value{t: t, bits: in.norm(t, uint64(x.Value))}}, true
+}
+
+func (in *interp) unary(n *ast.UnaryExpression) operand {
+	// The operands of sizeof are not evaluated, its value being known
+	// unless they have a variable length array type.
+	if n.SizeOfTypeName != nil || len(n.UnaryOperators) > 0 && n.UnaryOperators[0] == "sizeof" {
+		if x, ok := in.constant(n); ok {
+			return x
+		}
+		var t types.Type
+		switch {
+		case n.SizeOfTypeName != nil:
+			t = in.info.Types[n.SizeOfTypeName].Type
+			in.lengths(t)
+		case n.PostfixExpression != nil:
+			t = in.eval(n.PostfixExpression).lv.t
+		default:
+			t = in.prefix(n, *n.UnaryOperatorOnCast.Operator, n.CastExpression).lv.t
+		}
+		var size = unqualified(in.info.Types[n].Type)
+		return operand{node: n, v: value{t: size, bits: uint64(in.sizeof(t))}}
+	}
+	var x operand
+	if n.PostfixExpression != nil {
+		x = in.eval(n.PostfixExpression)
+	} else {
+		x = in.prefix(n, *n.UnaryOperatorOnCast.Operator, n.CastExpression)
+	}
+	for idx := len(n.UnaryOperators) - 1; idx >= 0; idx-- {
+		x = operand{node: n, v: in.increment(n, n.UnaryOperators[idx], x, false)}
+	}
+	return x
+}
+
+// prefix applies the unary operator op to the operand e of n.
+func (in *interp) prefix(n ast.Node, op string, e ast.Node) operand {
+	switch op {
+	case "&":
+		var x = in.eval(e)
+		return operand{node: n, v: value{t: &types.Pointer{Elem: x.lv.t}, bits: x.lv.addr, prov: x.lv.prov}}
+	case "*":
+		var p = in.value(e)
+		return operand{node: n, isLvalue: true, lv: lvalue{addr: p.bits, prov: p.prov, t: pointee(p.t)}}
+	}
+
+	var v = in.value(e)
+	switch {
+	case op == "!":
+		return operand{node: n, v: truth(!isTrue(v))}
+	case types.IsFloating(v.t):
+		if op == "-" {
+			v.f = -v.f
+		}
+		return operand{node: n, v: v}
+	}
+	var t = types.IntegerPromotion(v.t)
+	v = in.convert(v, t)
+	switch op {
+	case "-":
+		if lo, _ := in.limits(t); in.checked && in.target.IsSigned(t) && int64(v.bits) == lo {
+			in.errorf(n, "negation of %d cannot be represented in type %s", lo, t)
+		}
+		v.bits = in.norm(t, -v.bits)
+	case "~":
+		v.bits = in.norm(t, ^v.bits)
+	}
+	return operand{node: n, v: v}
+}
+
+// postfix applies the postfix operator op to x.
+func (in *interp) postfix(op *ast.PostfixOperator, x operand) operand {
+	switch {
+	case op.ArrayAccessExpression != nil:
+		var p, i = in.rvalue(x), in.value(op.ArrayAccessExpression)
+		if pointee(p.t) == nil {
+			p, i = i, p
+		}
+		p = in.offset(p, i, false)
+		return operand{node: op, isLvalue: true, lv: lvalue{addr: p.bits, prov: p.prov, t: pointee(p.t)}}
+	case op.IsCall:
+		return operand{node: op, v: in.callValue(op, in.rvalue(x))}
+	case op.IdentifierAccess != nil:
+		if !x.isLvalue {
+			return in.temporaryMember(op, x.v)
+		}
+		return in.member(op, x.lv)
+	case op.IdentifierPtrAccess != nil:
+		var p = in.rvalue(x)
+		return in.member(op, lvalue{addr: p.bits, prov: p.prov, t: pointee(p.t)})
+	}
+	return operand{node: op, v: in.increment(op, *op.Operator, x, true)}
+}
+
+// member returns the member accessed by op of the structure or union s.
+func (in *interp) member(op *ast.PostfixOperator, s lvalue) operand {
+	var name = op.IdentifierAccess
+	if name == nil {
+		name = op.IdentifierPtrAccess
+	}
+	var f = in.field(unqualified(s.t).(*types.Struct), *name)
+	var lv = lvalue{addr: s.addr + uint64(f.Offset), prov: s.prov, t: in.info.Types[op].Type}
+	if f.BitField {
+		lv = lvalue{addr: s.addr, prov: s.prov, t: lv.t, field: f}
+	}
+	return operand{node: op, isLvalue: true, lv: lv}
+}
+
+// temporaryMember returns the member accessed by op of the structure or
+// union v, which is not an object. Arrays need one to decay into a
+// pointer, so the value is copied in a temporary living until the
+// function returns.
+func (in *interp) temporaryMember(op *ast.PostfixOperator, v value) operand {
+	var b = in.alloc(int64(len(v.data)), "temporary", false)
+	in.store(op, b.lvalue(v.t), v)
+	var x = in.member(op, b.lvalue(v.t))
+	if _, ok := unqualified(x.lv.t).(*types.Array); ok && in.frame != nil {
+		in.frame.blocks = append(in.frame.blocks, b)
+		return x
+	}
+	var result = operand{node: op, v: in.rvalue(x)}
+	in.free(b)
+	return result
+}
+
+// field returns the layout of the field called name of s.
+func (in *interp) field(s *types.Struct, name string) *types.FieldLayout {
+	var layout = in.layouts[s]
+	if layout == nil {
+		layout = in.target.Layout(s)
+		in.layouts[s] = layout
+	}
+	for idx := range layout.Fields {
+		if layout.Fields[idx].Name == name {
+			return &layout.Fields[idx]
+		}
+	}
+	return nil
+}
+
+// callValue calls the function pointed to by fn with the arguments of op
+// and returns its result.
+func (in *interp) callValue(op *ast.PostfixOperator, fn value) value {
+	var t = unqualified(pointee(fn.t)).(*types.Function)
+	var args []value
+	if op.ArgumentExpressionList != nil {
+		for idx, e := range op.ArgumentExpressionList.AssignmentExpressions {
+			var v = in.value(e)
+			if t.Prototype && idx < len(t.Params) {
+				v = in.convert(v, t.Params[idx].Type)
+			} else if types.IsArithmetic(v.t) {
+				v = in.convert(v, types.DefaultPromotion(v.t))
+			}
+			args = append(args, v)
+		}
+	}
+
+	var b = fn.prov
+	if b == nil {
+		b = in.block(fn.bits)
+	}
+	if b == nil || b.function == "" || b.addr != fn.bits || b.freed {
+		in.errorf(op, "call through invalid function pointer %#x", fn.bits)
+	}
+	var result value
+	if def := in.functions[b.function]; def != nil {
+		result = in.call(op, def, args)
+	} else if builtin := builtins[b.function]; builtin != nil {
+		result = builtin(in, op, args)
+	} else {
+		in.errorf(op, "call to undefined function %s", b.function)
+	}
+	if t := in.info.Types[op].Type; !types.IsVoid(t) {
+		return in.convert(result, t)
+	}
+	return value{t: unqualified(in.info.Types[op].Type)}
+}
+
+func (in *interp) primary(n *ast.PrimaryExpression) operand {
+	if n.Expression != nil {
+		return in.eval(n.Expression)
+	}
+	if x, ok := in.constant(n); ok {
+		return x
+	}
+	var t = in.info.Types[n].Type
+	switch {
+	case n.Identifier != nil:
+		var obj = in.info.Uses[n]
+		if obj.Kind == sema.Function {
+			var b = in.addresses[obj.Name]
+			if b == nil {
+				b = in.function(obj.Name)
+				in.addresses[obj.Name] = b
+			}
+			return operand{node: n, isLvalue: true, lv: b.lvalue(obj.Type)}
+		}
+		var b *block
+		if in.frame != nil {
+			b = in.frame.locals[obj]
+		}
+		if b == nil {
+			b = in.objects[obj]
+		}
+		if b == nil {
+			b = in.globals[obj.Name]
+		}
+		if b == nil {
+			in.errorf(n, "%s is not defined", obj.Name)
+		}
+		return operand{node: n, isLvalue: true, lv: b.lvalue(t)}
+	case n.Float != nil:
+		var f, err = strconv.ParseFloat(*n.Float, 64)
+		if err != nil && !strings.Contains(err.Error(), "range") {
+			in.errorf(n, "invalid floating constant %s", *n.Float)
+		}
+		return operand{node: n, v: in.convert(value{t: types.Typ[types.Double], f: f}, t)}
+	case n.StringLiteral != nil:
+		var b = in.literals[n]
+		if b == nil {
+			b = in.alloc(int64(len(*n.StringLiteral)+1), "string literal", true)
+			copy(b.data, *n.StringLiteral)
+			b.readOnly = true
+			in.literals[n] = b
+		}
+		return operand{node: n, isLvalue: true, lv: b.lvalue(t)}
+	}
+	in.errorf(n, "cannot evaluate constant")
+	return operand{}
+}
@@ -0,0 +1,94 @@
+package interp
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/types"
+)
+
+// initialize stores the initializer n in the object lv. The parts of the
+// object n leaves out are zero.
+func (in *interp) initialize(lv lvalue, n *ast.Initializer) {
+	clear(in.bytes(n, lv.addr, lv.prov, in.target.Sizeof(lv.t), true))
+	in.initializer(lv, n)
+}
+
+func (in *interp) initializer(lv lvalue, n *ast.Initializer) {
+	if n.InitializerList == nil {
+		in.initializeValue(lv, n)
+		return
+	}
+	var inits = n.InitializerList.Initializers
+	if types.IsScalar(lv.t) {
+		in.initializer(lv, inits[0])
+		return
+	}
+	var idx = 0
+	in.aggregate(lv, inits, &idx)
+}
+
+// initializeValue stores the expression of n in lv, copying the
+// characters of string literals initializing arrays.
+func (in *interp) initializeValue(lv lvalue, n *ast.Initializer) {
+	if _, ok := unqualified(lv.t).(*types.Array); !ok {
+		in.assignTo(n, lv, in.value(n.AssignmentExpression))
+		return
+	}
+	var literal = ast.StringLiteral(n.AssignmentExpression)
+	copy(in.bytes(n, lv.addr, lv.prov, in.target.Sizeof(lv.t), true), *literal.StringLiteral)
+}
+
+// aggregate stores the initializers of the elements or members of the
+// array, structure or union lv, starting at inits[*idx], taking as many
+// as sema did when their braces are left out.
+func (in *interp) aggregate(lv lvalue, inits []*ast.Initializer, idx *int) {
+	var element = func(elem lvalue) {
+		var init = inits[*idx]
+		_, isArray := unqualified(elem.t).(*types.Array)
+		switch {
+		case init.InitializerList != nil || types.IsScalar(elem.t):
+			in.initializer(elem, init)
+			*idx++
+		case isArray && ast.StringLiteral(init.AssignmentExpression) != nil:
+			in.initializer(elem, init)
+			*idx++
+		case !isArray && in.initializesWhole(elem.t, init):
+			in.initializer(elem, init)
+			*idx++
+		default:
+			in.aggregate(elem, inits, idx)
+		}
+	}
+
+	switch u := unqualified(lv.t).(type) {
+	case *types.Array:
+		var size = uint64(in.target.Sizeof(u.Elem))
+		for count := int64(0); (u.Kind != types.Sized || count < u.Len) && *idx < len(inits); count++ {
+			element(lvalue{addr: lv.addr + uint64(count)*size, prov: lv.prov, t: u.Elem})
+		}
+	case *types.Struct:
+		for _, field := range u.Fields {
+			if *idx == len(inits) {
+				break
+			}
+			if field.BitField && field.Name == "" {
+				continue
+			}
+			var f = in.field(u, field.Name)
+			if f.BitField {
+				element(lvalue{addr: lv.addr, prov: lv.prov, t: f.Type, field: f})
+			} else {
+				element(lvalue{addr: lv.addr + uint64(f.Offset), prov: lv.prov, t: f.Type})
+			}
+			if u.Union {
+				break
+			}
+		}
+	}
+}
+
+// initializesWhole reports whether the expression init initializes a
+// structure or union of type t as a whole, rather than its first member.
+func (in *interp) initializesWhole(t types.Type, init *ast.Initializer) bool {
+	var x, ok = in.info.Types[init.AssignmentExpression]
+	return ok && types.Compatible(unqualified(x.Type), unqualified(t))
+}
@@ -0,0 +1,233 @@
+// Package interp executes checked translation units by walking their
+// syntax trees. Objects live in a byte-addressed memory laid out for the
+// target, so that pointers, arrays, structures and function pointers
+// behave as in compiled programs, and a few functions of the C library
+// are provided.
+package interp
+
+import (
+	"fmt"
+	"io"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/lexer"
+	"lazarus-c/src/sema"
+	"lazarus-c/src/types"
+	"os"
+	"sort"
+)
+
+// Config describes how to run a program.
+type Config struct {
+	// Target sets the sizes of types; it must be the target the unit was
+	// checked for. X86_64 is used when nil.
+	Target *types.Target
+	// Stdout receives the output of the program, os.Stdout when nil.
+	Stdout io.Writer
+	// Args are the arguments passed to main, the name of the program
+	// first.
+	Args []string
+	// Checked instruments the program to stop at undefined behavior:
+	// accesses outside of the object a pointer was derived from or to
+	// freed objects, double frees, null pointer dereferences, reads of
+	// uninitialised memory, signed integer overflow and shifts out of
+	// range. Without it, only accesses outside of every object are
+	// detected.
+	Checked bool
+}
+
+// Error is an error of the program detected while running it, such as an
+// access outside of any object. Pos is the position of the expression or
+// statement at fault, and Stack holds the names of the functions being
+// executed, innermost first.
+type Error struct {
+	Pos     lexer.Position
+	Message string
+	Stack   []string
+}
+
+// Error returns the message of e followed by its stack, of which only the
+// innermost and outermost stackEnds functions are listed when longer.
+func (e *Error) Error() string {
+	var s = fmt.Sprintf("%s: runtime error: %s", e.Pos, e.Message)
+	for idx, name := range e.Stack {
+		if len(e.Stack) > 2*stackEnds+1 && idx >= stackEnds && idx < len(e.Stack)-stackEnds {
+			if idx == stackEnds {
+				s += fmt.Sprintf("\n\t... %d more frames", len(e.Stack)-2*stackEnds)
+			}
+			continue
+		}
+		s += "\n\tin " + name
+	}
+	return s
+}
+
+const stackEnds = 5
+
+// maxDepth bounds the nesting of calls, so that runaway recursion is
+// reported rather than exhausting memory.
+const maxDepth = 10000
+
+type interp struct {
+	info    *sema.Info
+	target  *types.Target
+	stdout  io.Writer
+	checked bool
+
+	blocks []*block
+	next   uint64
+
+	functions map[string]*ast.FunctionDefinition
+	// addresses holds the block standing for every function by name.
+	addresses map[string]*block
+	// objects maps variables of static storage duration to their
+	// block, and globals those with external linkage by name, for
+	// declarations in blocks.
+	objects  map[*sema.Object]*block
+	globals  map[string]*block
+	literals map[*ast.PrimaryExpression]*block
+	switches map[*ast.SelectionStatement]*switchTable
+	layouts  map[*types.Struct]*types.Layout
+
+	frame *frame
+	depth int
+}
+
+// Run executes the program made of unit, checked without errors with the
+// results in info, by calling its main function. It returns the status
+// of the program: the value returned by main.
+func Run(unit *ast.TranslationUnit, info *sema.Info) (int, error) {
+	return (&Config{}).Run(unit, info)
+}
+
+// Run executes a program like the Run function, as described by conf.
+func (conf *Config) Run(unit *ast.TranslationUnit, info *sema.Info) (status int, err error) {
+	var in = &interp{
+		info:      info,
+		target:    conf.Target,
+		stdout:    conf.Stdout,
+		checked:   conf.Checked,
+		next:      base,
+		functions: map[string]*ast.FunctionDefinition{},
+		addresses: map[string]*block{},
+		objects:   map[*sema.Object]*block{},
+		globals:   map[string]*block{},
+		literals:  map[*ast.PrimaryExpression]*block{},
+		switches:  map[*ast.SelectionStatement]*switchTable{},
+		layouts:   map[*types.Struct]*types.Layout{},
+	}
+	if in.target == nil {
+		in.target = types.X86_64
+	}
+	if in.stdout == nil {
+		in.stdout = os.Stdout
+	}
+	defer func() {
+		var r = recover()
+		if e, ok := r.(*Error); ok {
+			err = e
+		} else if r != nil {
+			panic(r)
+		}
+	}()
+
+	in.declare(unit)
+	var main = in.functions["main"]
+	if main == nil {
+		return 0, fmt.Errorf("%s: no main function", unit.Pos.Filename)
+	}
+	var args []value
+	if params := in.parameters(main); len(params) >= 2 {
+		args = in.arguments(conf.Args)
+	}
+	var result = in.call(main, main, args)
+	if !types.IsInteger(result.t) {
+		return 0, nil
+	}
+	return int(int32(result.bits)), nil
+}
+
+// errorf stops the program with an error about the expression or
+// statement n.
+func (in *interp) errorf(n ast.Node, format string, args ...any) {
+	var e = &Error{Pos: ast.Pos(n), Message: fmt.Sprintf(format, args...)}
+	for f := in.frame; f != nil; f = f.caller {
+		e.Stack = append(e.Stack, *ast.DeclaredName(f.fn.Declarator).Identifier)
+	}
+	panic(e)
+}
+
+// declare gives addresses to the functions and the variables of static
+// storage duration of unit, then initializes the variables.
+func (in *interp) declare(unit *ast.TranslationUnit) {
+	var names []string
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		in.addresses[name] = in.function(name)
+	}
+	var inits []func()
+	ast.Inspect(unit, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionDefinition:
+			var name = *ast.DeclaredName(n.Declarator).Identifier
+			in.functions[name] = n
+			in.addresses[name] = in.function(name)
+		case *ast.Declaration:
+			if n.InitDeclaratorList == nil {
+				return true
+			}
+			for _, d := range n.InitDeclaratorList.InitDeclarators {
+				var obj = in.info.Defs[ast.DeclaredName(d.Declarator)]
+				if obj == nil || obj.Kind != sema.Variable || obj.Storage == sema.Extern {
+					continue
+				}
+				if obj.Scope != in.info.Scope && obj.Storage != sema.Static {
+					continue
+				}
+				var b = in.objects[obj]
+				if b == nil {
+					b = in.alloc(in.target.Sizeof(obj.Type), "variable "+obj.Name, true)
+					in.objects[obj] = b
+					if obj.Scope == in.info.Scope && obj.Storage != sema.Static {
+						in.globals[obj.Name] = b
+					}
+				}
+				if d.Initializer != nil {
+					var d = d
+					inits = append(inits, func() { in.initialize(b.lvalue(obj.Type), d.Initializer) })
+				}
+			}
+		}
+		return true
+	})
+	// Initializers may take the address of any variable.
+	for _, init := range inits {
+		init()
+	}
+}
+
+// function returns a new block standing for the code of the function
+// called name.
+func (in *interp) function(name string) *block {
+	var b = in.alloc(1, "function "+name, true)
+	b.function = name
+	return b
+}
+
+// arguments returns argc and argv for the strings args.
+func (in *interp) arguments(args []string) []value {
+	var charPtr = &types.Pointer{Elem: types.Typ[types.Char]}
+	var argv = in.alloc(int64(len(args)+1)*in.target.PointerSize, "argv", true)
+	for idx, arg := range args {
+		var b = in.alloc(int64(len(arg)+1), "argument", true)
+		copy(b.data, arg)
+		var lv = lvalue{addr: argv.addr + uint64(idx)*uint64(in.target.PointerSize), t: charPtr, prov: argv}
+		in.store(nil, lv, value{t: charPtr, bits: b.addr, prov: b})
+	}
+	return []value{
+		{t: intType, bits: uint64(len(args))},
+		{t: &types.Pointer{Elem: charPtr}, bits: argv.addr, prov: argv},
+	}
+}
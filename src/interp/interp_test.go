@@ -0,0 +1,201 @@
+package interp
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/diag"
+	"lazarus-c/src/sema"
+	"strings"
+	"testing"
+)
+
+// run checks and runs src, returning its exit status, its output and the
+// runtime error it stopped at.
+func run(t *testing.T, src string, checked bool) (int, string, error) {
+	t.Helper()
+	var unit, err = ast.ParseString(src)
+	if err != nil {
+		t.Fatalf("parsing %q: %v", src, err)
+	}
+	var diags diag.List
+	var info = sema.Check(unit, &diags)
+	if diags.HasErrors() {
+		t.Fatalf("checking %q: %v", src, diags)
+	}
+	var out strings.Builder
+	status, err := (&Config{Stdout: &out, Args: []string{"test"}, Checked: checked}).Run(unit, info)
+	return status, out.String(), err
+}
+
+// programs lists programs with their exit status and output, the same in
+// checked and unchecked mode.
+var programs = []struct {
+	name   string
+	src    string
+	status int
+	stdout string
+}{
+	{"exit status", "int main(void) { return 3; }", 3, ""},
+	{"end of main", "int main(void) { }", 0, ""},
+	{"arguments", "int main(int argc, char **argv) { return argc * 10 + argv[0][0] - 't' + (argv[1] == 0); }", 11, ""},
+	{
+		"printf conversions",
+		`int printf(const char *, ...);
+int main(void) {
+	int n = printf("%d|%5d|%-4d|%05d|%x|%X|%o|%u\n", -42, 7, 7, 42, 255, 255, 8, (unsigned)-1);
+	printf("%c%s|%.2s|%6s|%p|%%\n", 'a', "bc", "xyz", "right", (void *)0);
+	printf("%.3f|%g|%e\n", 3.14159, 0.5, 1500.0);
+	printf("%ld %lu %hhd\n", (long)-1, (unsigned long)-1, 300);
+	return n;
+}`,
+		41,
+		"-42|    7|7   |00042|ff|FF|10|4294967295\nabc|xy| right|(nil)|%\n3.142|0.5|1.500000e+03\n-1 18446744073709551615 44\n",
+	},
+	{
+		"recursion and globals",
+		`int printf(const char *, ...);
+int calls;
+int fib(int n) { calls++; return n < 2 ? n : fib(n - 1) + fib(n - 2); }
+int main(void) { printf("%d %d\n", fib(10), calls); return 0; }`,
+		0,
+		"55 177\n",
+	},
+	{
+		"structures and pointers",
+		`struct point { int x, y; };
+struct point move(struct point p, int d) { p.x += d; return p; }
+int main(void) {
+	struct point a = { 1, 2 }, b = move(a, 3), *p = &b;
+	int v[3] = { 4, 5, 6 }, *q = v + 1;
+	return a.x * 100 + p->x * 10 + q[1] - *q;
+}`,
+		141,
+		"",
+	},
+	{
+		"variable length arrays",
+		`int printf(const char *, ...);
+int sum(int n, int m, int a[][m]) {
+	int s = 0, i, j;
+	for (i = 0; i < n; i++)
+		for (j = 0; j < m; j++)
+			s += a[i][j];
+	return s;
+}
+int main(int argc, char **argv) {
+	int n = argc + 2, m = n * 2, i, j;
+	int a[n][m];
+	int (*p)[m] = a;
+	for (i = 0; i < n; i++)
+		for (j = 0; j < m; j++)
+			a[i][j] = i * 10 + j;
+	printf("%d %d %d %d\n", (int)sizeof a, (int)sizeof a[1], (int)sizeof(int[n]), (int)sizeof *p);
+	printf("%d %d\n", p[2][3], sum(n, m, a));
+	return a[n - 1][m - 1];
+}`,
+		25,
+		"72 24 12 24\n23 225\n",
+	},
+}
+
+func TestRun(t *testing.T) {
+	for _, test := range programs {
+		for _, checked := range []bool{false, true} {
+			var status, stdout, err = run(t, test.src, checked)
+			if err != nil {
+				t.Errorf("%s (checked %t): %v", test.name, checked, err)
+				continue
+			}
+			if status != test.status || stdout != test.stdout {
+				t.Errorf("%s (checked %t): exited with %d and printed\n%s\ninstead of %d and\n%s", test.name, checked, status, stdout, test.status, test.stdout)
+			}
+		}
+	}
+}
+
+// traps lists programs stopped with a runtime error, in unchecked and in
+// checked mode. Only checked mode stops at most undefined behavior;
+// unchecked is empty when the program runs to its end.
+var traps = []struct {
+	name               string
+	src                string
+	unchecked, checked string
+}{
+	{
+		"division by zero",
+		"int main(int argc, char **argv) { return 1 / (argc - 1); }",
+		"division by zero",
+		"division by zero",
+	},
+	{
+		"null pointer",
+		"int main(void) { int *p = 0; return *p; }",
+		"invalid memory access at address 0x0",
+		"null pointer dereference",
+	},
+	{
+		"stack overflow",
+		"int f(int n) { return f(n + 1); } int main(void) { return f(0); }",
+		"stack overflow: more than 10000 nested calls",
+		"stack overflow: more than 10000 nested calls",
+	},
+	{
+		"signed overflow",
+		"int main(int argc, char **argv) { int x = 2147483647; x += argc; return x < 0; }",
+		"",
+		"signed integer overflow: 2147483647 + 1 cannot be represented in type int",
+	},
+	{
+		"shift count",
+		"int main(int argc, char **argv) { return 1 << (argc + 31); }",
+		"",
+		"shift count 32 out of range for type int",
+	},
+	{
+		"uninitialized read",
+		"int main(void) { int a[2]; a[0] = 1; return a[1]; }",
+		"",
+		"read of uninitialised variable a",
+	},
+	{
+		"out-of-bounds",
+		"int main(void) { int *p = malloc(16); int q = 1; p[16] = 9; return q; }",
+		"invalid memory access at address 0x100b0",
+		"out-of-bounds access to memory allocated by malloc: 4 bytes at offset 64, size 16",
+	},
+	{
+		"use-after-free",
+		"int main(void) { int *p = malloc(16); int *q = malloc(16); q[0] = 7; free(p); return p[4]; }",
+		"",
+		"use of memory allocated by malloc after free",
+	},
+	{
+		"double-free",
+		"int main(void) { int *p = malloc(16); free(p); free(p); return 0; }",
+		"free of address 0x10070 not returned by malloc",
+		"double free of memory allocated by malloc",
+	},
+	{
+		"variable length array bound",
+		"int main(int argc, char **argv) { int a[argc - 1]; return sizeof a; }",
+		"variable length array bound evaluates to non-positive value 0",
+		"variable length array bound evaluates to non-positive value 0",
+	},
+}
+
+func TestTraps(t *testing.T) {
+	for _, test := range traps {
+		for _, mode := range []struct {
+			checked bool
+			message string
+		}{{false, test.unchecked}, {true, test.checked}} {
+			var _, _, err = run(t, test.src, mode.checked)
+			var e, ok = err.(*Error)
+			switch {
+			case mode.message == "" && err != nil:
+				t.Errorf("%s (checked %t): stopped with %v", test.name, mode.checked, err)
+			case mode.message != "" && (!ok || e.Message != mode.message):
+				t.Errorf("%s (checked %t): stopped with %v instead of %q", test.name, mode.checked, err, mode.message)
+			}
+		}
+	}
+}
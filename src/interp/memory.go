@@ -0,0 +1,267 @@
+package interp
+
+import (
+	"encoding/binary"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/types"
+	"math"
+	"sort"
+)
+
+// base is the address of the first block. Lower addresses, null
+// included, designate no object.
+const base = 0x10000
+
+// block is a region of memory holding one object: a variable, a string
+// literal, an allocation of malloc, or the code of a function, which
+// only has an address.
+type block struct {
+	addr     uint64
+	data     []byte
+	what     string
+	readOnly bool
+	heap     bool
+	function string
+	// freed is set once the block is freed, or its variable goes out of
+	// scope. Pointers derived from it may still refer to it.
+	freed bool
+	// shadow is set in checked mode.
+	shadow *shadow
+}
+
+// shadow records the state of the bytes of an object, or of the value of
+// a structure: which ones were written, and the objects the pointers
+// stored at some offsets were derived from.
+type shadow struct {
+	init     []bool
+	pointers map[uint64]*block
+}
+
+func (b *block) end() uint64 {
+	return b.addr + uint64(len(b.data))
+}
+
+// alloc returns a new zeroed block of size bytes, described by what. In
+// checked mode, its bytes count as written when zeroed is set, as for
+// objects of static storage duration.
+func (in *interp) alloc(size int64, what string, zeroed bool) *block {
+	var b = &block{addr: in.next, data: make([]byte, size), what: what}
+	if in.checked {
+		b.shadow = &shadow{init: make([]bool, size), pointers: map[uint64]*block{}}
+		if zeroed {
+			for idx := range b.shadow.init {
+				b.shadow.init[idx] = true
+			}
+		}
+	}
+	in.next += uint64(max(size, 1)+15) &^ 15
+	in.blocks = append(in.blocks, b)
+	return b
+}
+
+// free releases b. Its address is not given to later blocks.
+func (in *interp) free(b *block) {
+	var idx = sort.Search(len(in.blocks), func(i int) bool { return in.blocks[i].addr >= b.addr })
+	in.blocks = append(in.blocks[:idx], in.blocks[idx+1:]...)
+	b.freed = true
+}
+
+// block returns the block holding the address addr, or nil.
+func (in *interp) block(addr uint64) *block {
+	var idx = sort.Search(len(in.blocks), func(i int) bool { return in.blocks[i].addr > addr }) - 1
+	if idx < 0 || addr >= in.blocks[idx].end() && addr != in.blocks[idx].addr {
+		return nil
+	}
+	return in.blocks[idx]
+}
+
+// object returns the block holding the size bytes at addr, and their
+// offset in it, for a read, or a write when write is set. In checked
+// mode, the bytes must lie in the object prov the address was derived
+// from, which must still exist. n is the expression making the access.
+func (in *interp) object(n ast.Node, addr uint64, prov *block, size int64, write bool) (*block, uint64) {
+	var b = prov
+	if !in.checked || b == nil {
+		if in.checked && addr < base {
+			in.errorf(n, "null pointer dereference")
+		}
+		b = in.block(addr)
+		if b == nil || addr+uint64(size) > b.end() || b.function != "" {
+			in.errorf(n, "invalid memory access at address %#x", addr)
+		}
+	}
+	switch {
+	case b.function != "":
+		in.errorf(n, "access to the code of %s", b.what)
+	case b.freed && b.heap:
+		in.errorf(n, "use of %s after free", b.what)
+	case b.freed:
+		in.errorf(n, "use of %s after the end of its lifetime", b.what)
+	case addr < b.addr || addr+uint64(size) > b.end() || addr+uint64(size) < addr:
+		in.errorf(n, "out-of-bounds access to %s: %d bytes at offset %d, size %d", b.what, size, int64(addr-b.addr), len(b.data))
+	case write && b.readOnly:
+		in.errorf(n, "write to read-only memory at address %#x", addr)
+	}
+	var offset = addr - b.addr
+	if write && b.shadow != nil {
+		for idx := offset; idx < offset+uint64(size); idx++ {
+			b.shadow.init[idx] = true
+		}
+		for ptr := offset - min(offset, uint64(in.target.PointerSize-1)); ptr < offset+uint64(size); ptr++ {
+			delete(b.shadow.pointers, ptr)
+		}
+	}
+	return b, offset
+}
+
+// bytes returns the size bytes of memory at addr, derived from prov, as
+// object does.
+func (in *interp) bytes(n ast.Node, addr uint64, prov *block, size int64, write bool) []byte {
+	var b, offset = in.object(n, addr, prov, size, write)
+	return b.data[offset : offset+uint64(size)]
+}
+
+// initialized fails unless the size bytes at offset in b were written.
+func (in *interp) initialized(n ast.Node, b *block, offset uint64, size int64) {
+	if b.shadow == nil {
+		return
+	}
+	for _, init := range b.shadow.init[offset : offset+uint64(size)] {
+		if !init {
+			in.errorf(n, "read of uninitialised %s", b.what)
+		}
+	}
+}
+
+// cstring returns the string terminated by a null byte at the address
+// held by the pointer p.
+func (in *interp) cstring(n ast.Node, p value) string {
+	var b, offset = in.object(n, p.bits, p.prov, 0, false)
+	for idx, c := range b.data[offset:] {
+		in.initialized(n, b, offset+uint64(idx), 1)
+		if c == 0 {
+			return string(b.data[offset : offset+uint64(idx)])
+		}
+	}
+	if in.checked {
+		in.errorf(n, "out-of-bounds access to %s: string without a terminating null byte", b.what)
+	}
+	in.errorf(n, "invalid string at address %#x", p.bits)
+	return ""
+}
+
+// load returns the value of the object lv.
+func (in *interp) load(n ast.Node, lv lvalue) value {
+	if lv.field != nil {
+		return in.loadField(n, lv)
+	}
+	var t = unqualified(lv.t)
+	var size = in.target.Sizeof(t)
+	var b, offset = in.object(n, lv.addr, lv.prov, size, false)
+	var data = b.data[offset : offset+uint64(size)]
+	if !types.IsScalar(t) {
+		return value{t: t, data: append([]byte(nil), data...), shadow: b.shadow.slice(offset, size)}
+	}
+	in.initialized(n, b, offset, size)
+	switch {
+	case types.IsFloating(t) && size == 4:
+		return value{t: t, f: float64(math.Float32frombits(binary.LittleEndian.Uint32(data)))}
+	case types.IsFloating(t):
+		return value{t: t, f: math.Float64frombits(binary.LittleEndian.Uint64(data))}
+	}
+	var buf [8]byte
+	copy(buf[:], data)
+	var v = value{t: t, bits: in.norm(t, binary.LittleEndian.Uint64(buf[:]))}
+	if b.shadow != nil && pointee(t) != nil {
+		v.prov = b.shadow.pointers[offset]
+	}
+	return v
+}
+
+// store writes v, of the type of the object lv, to lv.
+func (in *interp) store(n ast.Node, lv lvalue, v value) {
+	if lv.field != nil {
+		in.storeField(n, lv, v)
+		return
+	}
+	var t = unqualified(lv.t)
+	var size = in.target.Sizeof(t)
+	var b, offset = in.object(n, lv.addr, lv.prov, size, true)
+	var data = b.data[offset : offset+uint64(size)]
+	switch {
+	case types.IsFloating(t) && size == 4:
+		binary.LittleEndian.PutUint32(data, math.Float32bits(float32(v.f)))
+	case types.IsFloating(t):
+		binary.LittleEndian.PutUint64(data, math.Float64bits(v.f))
+	case types.IsScalar(t):
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], v.bits)
+		copy(data, buf[:])
+		if b.shadow != nil && v.prov != nil {
+			b.shadow.pointers[offset] = v.prov
+		}
+	default:
+		copy(data, v.data)
+		b.shadow.restore(offset, v.shadow)
+	}
+}
+
+// loadField returns the value of the bit-field lv.
+func (in *interp) loadField(n ast.Node, lv lvalue) value {
+	var f = lv.field
+	var t = unqualified(f.Type)
+	var size = int64(f.Bit+f.Bits+7) / 8
+	var b, offset = in.object(n, lv.addr+uint64(f.Offset), lv.prov, size, false)
+	in.initialized(n, b, offset, size)
+	var data = b.data[offset:]
+	var word uint64
+	for idx := 0; idx < f.Bits; idx++ {
+		var bit = f.Bit + idx
+		word |= uint64(data[bit/8]>>(bit%8)&1) << idx
+	}
+	if in.target.IsSigned(t) && word>>(f.Bits-1)&1 == 1 {
+		word |= ^uint64(0) << f.Bits
+	}
+	return value{t: t, bits: in.norm(t, word)}
+}
+
+// storeField writes v to the bit-field lv.
+func (in *interp) storeField(n ast.Node, lv lvalue, v value) {
+	var f = lv.field
+	var data = in.bytes(n, lv.addr+uint64(f.Offset), lv.prov, int64(f.Bit+f.Bits+7)/8, true)
+	for idx := 0; idx < f.Bits; idx++ {
+		var bit = f.Bit + idx
+		if v.bits>>idx&1 == 1 {
+			data[bit/8] |= 1 << (bit % 8)
+		} else {
+			data[bit/8] &^= 1 << (bit % 8)
+		}
+	}
+}
+
+// slice returns the state of the size bytes at offset, or nil for the
+// nil shadow of unchecked mode.
+func (s *shadow) slice(offset uint64, size int64) *shadow {
+	if s == nil {
+		return nil
+	}
+	var result = &shadow{init: append([]bool(nil), s.init[offset:offset+uint64(size)]...), pointers: map[uint64]*block{}}
+	for ptr, prov := range s.pointers {
+		if ptr >= offset && ptr < offset+uint64(size) {
+			result.pointers[ptr-offset] = prov
+		}
+	}
+	return result
+}
+
+// restore sets the state of the bytes at offset to src, as taken by
+// slice.
+func (s *shadow) restore(offset uint64, src *shadow) {
+	if s == nil || src == nil {
+		return
+	}
+	copy(s.init[offset:], src.init)
+	for ptr, prov := range src.pointers {
+		s.pointers[offset+ptr] = prov
+	}
+}
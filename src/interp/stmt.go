@@ -0,0 +1,344 @@
+package interp
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/sema"
+	"lazarus-c/src/types"
+)
+
+// control tells how the execution of a statement ended.
+type control int
+
+const (
+	next control = iota
+	breakLoop
+	continueLoop
+	returnFunction
+	// jump is a goto, or a switch looking for a case, whose target is
+	// frame.seek.
+	jump
+)
+
+// frame is the activation of a function.
+type frame struct {
+	fn     *ast.FunctionDefinition
+	locals map[*sema.Object]*block
+	// blocks hold the parameters and temporaries, freed on return.
+	blocks []*block
+	// seek is the labeled statement where execution resumes after a
+	// jump. Until it is reached, statements are skipped, entering only
+	// those enclosing it.
+	seek   ast.Node
+	result value
+	caller *frame
+	// lengths holds the lengths of the arrays of variable length of the
+	// function, evaluated when their declaration is reached.
+	lengths map[*types.Array]int64
+}
+
+// contains reports whether the statement target lies within n.
+func contains(n, target ast.Node) bool {
+	return target != nil && ast.Pos(target).Offset >= ast.Pos(n).Offset && ast.Pos(target).Offset < ast.End(n).Offset
+}
+
+// parameters returns the parameters of fn, in order.
+func (in *interp) parameters(fn *ast.FunctionDefinition) []*sema.Object {
+	var direct = ast.DeclaredName(fn.Declarator)
+	if len(direct.DeclaratorSuffixes) == 0 {
+		return nil
+	}
+	var suffix = direct.DeclaratorSuffixes[0]
+	var names []string
+	switch {
+	case suffix.ParameterTypeList != nil:
+		for _, decl := range suffix.ParameterTypeList.ParameterList.ParameterDeclarations {
+			if decl.Declarator != nil {
+				names = append(names, *ast.DeclaredName(decl.Declarator).Identifier)
+			}
+		}
+	case suffix.IdentifierList != nil:
+		names = suffix.IdentifierList.Identifiers
+	}
+	var scope = in.info.Scopes[fn.CompoundStatement]
+	var params []*sema.Object
+	for _, name := range names {
+		params = append(params, scope.Objects[name])
+	}
+	return params
+}
+
+// call runs the function fn with the arguments args, converted to the
+// types of its parameters, and returns its result. n is the call.
+func (in *interp) call(n ast.Node, fn *ast.FunctionDefinition, args []value) value {
+	if in.depth == maxDepth {
+		in.errorf(n, "stack overflow: more than %d nested calls", maxDepth)
+	}
+	var params = in.parameters(fn)
+	if len(args) < len(params) {
+		in.errorf(n, "too few arguments in call to %s", *ast.DeclaredName(fn.Declarator).Identifier)
+	}
+	var f = &frame{fn: fn, locals: map[*sema.Object]*block{}, caller: in.frame, lengths: map[*types.Array]int64{}}
+	for idx, param := range params {
+		var b = in.alloc(in.target.Sizeof(param.Type), "parameter "+param.Name, false)
+		f.blocks = append(f.blocks, b)
+		f.locals[param] = b
+		in.store(n, b.lvalue(param.Type), in.convert(args[idx], param.Type))
+	}
+	in.frame = f
+	for _, param := range params {
+		in.lengths(param.Type)
+	}
+	in.depth++
+	in.compound(fn.CompoundStatement)
+	in.depth--
+	in.frame = f.caller
+	for _, b := range f.blocks {
+		in.free(b)
+	}
+
+	var result = in.info.Defs[ast.DeclaredName(fn.Declarator)].Type.(*types.Function).Result
+	if f.result.t == nil {
+		return value{t: unqualified(result)}
+	}
+	return f.result
+}
+
+// statement executes n.
+func (in *interp) statement(n *ast.Statement) control {
+	if n.CompoundStatement != nil {
+		return in.compound(n.CompoundStatement)
+	}
+	for {
+		var c = in.simpleStatement(n)
+		if c != jump || !contains(n, in.frame.seek) {
+			return c
+		}
+	}
+}
+
+// simpleStatement executes n, which is not a compound statement, once.
+// When a jump leads back into n, statement executes it again, seeking
+// the target.
+func (in *interp) simpleStatement(n *ast.Statement) control {
+	var f = in.frame
+	if f.seek != nil && !contains(n, f.seek) {
+		return next
+	}
+	switch {
+	case n.LabeledStatement != nil:
+		var s = n.LabeledStatement
+		if f.seek == s {
+			f.seek = nil
+		}
+		switch {
+		case s.GotoStatement != nil:
+			return in.statement(s.GotoStatement)
+		case s.CaseStatement != nil:
+			return in.statement(s.CaseStatement)
+		}
+		return in.statement(s.DefaultStatement)
+	case n.ExpressionStatement != nil:
+		if n.ExpressionStatement.Expression != nil {
+			in.value(n.ExpressionStatement.Expression)
+		}
+		return next
+	case n.SelectionStatement != nil:
+		return in.selection(n.SelectionStatement)
+	case n.IterationStatement != nil:
+		return in.iteration(n.IterationStatement)
+	}
+	return in.jump(n.JumpStatement)
+}
+
+// compound executes n, whose automatic variables exist until it ends.
+func (in *interp) compound(n *ast.CompoundStatement) control {
+	var f = in.frame
+	var blocks []*block
+	if n.DeclarationList != nil {
+		for _, decl := range n.DeclarationList.Declarations {
+			blocks = append(blocks, in.declaration(decl)...)
+		}
+	}
+	defer func() {
+		for _, b := range blocks {
+			in.free(b)
+		}
+	}()
+	if n.StatementList == nil {
+		return next
+	}
+	for {
+		var c = next
+		for _, s := range n.StatementList.Statements {
+			if c = in.statement(s); c != next {
+				break
+			}
+		}
+		if c != jump || !contains(n, f.seek) {
+			return c
+		}
+	}
+}
+
+// declaration creates the automatic variables of decl and initializes
+// them, unless a jump skips the declaration. It returns their blocks.
+func (in *interp) declaration(decl *ast.Declaration) []*block {
+	if decl.InitDeclaratorList == nil {
+		return nil
+	}
+	var blocks []*block
+	for _, d := range decl.InitDeclaratorList.InitDeclarators {
+		var obj = in.info.Defs[ast.DeclaredName(d.Declarator)]
+		if obj.Kind != sema.Variable || obj.Storage == sema.Extern || obj.Storage == sema.Static {
+			continue
+		}
+		in.lengths(obj.Type)
+		var b = in.alloc(in.sizeof(obj.Type), "variable "+obj.Name, false)
+		blocks = append(blocks, b)
+		in.frame.locals[obj] = b
+		if d.Initializer != nil && in.frame.seek == nil {
+			in.initialize(b.lvalue(obj.Type), d.Initializer)
+		}
+	}
+	return blocks
+}
+
+func (in *interp) selection(n *ast.SelectionStatement) control {
+	var f = in.frame
+	if n.IfTest != nil {
+		switch {
+		case f.seek != nil && contains(n.IfBody, f.seek):
+			return in.statement(n.IfBody)
+		case f.seek != nil:
+			return in.statement(n.ElseBody)
+		case isTrue(in.value(n.IfTest)):
+			return in.statement(n.IfBody)
+		case n.ElseBody != nil:
+			return in.statement(n.ElseBody)
+		}
+		return next
+	}
+
+	if f.seek == nil {
+		var target = in.switchTarget(n, in.value(n.SwitchExpression))
+		if target == nil {
+			return next
+		}
+		f.seek = target
+	}
+	var c = in.statement(n.SwitchBody)
+	if c == breakLoop {
+		return next
+	}
+	return c
+}
+
+func (in *interp) iteration(n *ast.IterationStatement) control {
+	var f = in.frame
+	var body, test = n.WhileBody, n.WhileTest
+	switch {
+	case n.DoBody != nil:
+		body, test = n.DoBody, n.DoTest
+	case n.ForBody != nil:
+		body = n.ForBody
+		if f.seek == nil && n.ForInit.Expression != nil {
+			in.value(n.ForInit.Expression)
+		}
+	}
+
+	// The body of do statements, and of loops entered by a jump, comes
+	// before the test.
+	var first = n.DoBody != nil || f.seek != nil
+	for {
+		if !first {
+			switch {
+			case test != nil:
+				if !isTrue(in.value(test)) {
+					return next
+				}
+			case n.ForTest.Expression != nil:
+				if !isTrue(in.value(n.ForTest.Expression)) {
+					return next
+				}
+			}
+		}
+		first = false
+		switch c := in.statement(body); c {
+		case breakLoop:
+			return next
+		case returnFunction, jump:
+			return c
+		}
+		if n.ForUpdate != nil {
+			in.value(n.ForUpdate)
+		}
+	}
+}
+
+func (in *interp) jump(n *ast.JumpStatement) control {
+	var f = in.frame
+	switch {
+	case n.GotoIdent != nil:
+		f.seek = in.info.Uses[n].Decl
+		return jump
+	case n.IsContinue:
+		return continueLoop
+	case n.IsBreak:
+		return breakLoop
+	}
+	if n.ReturnExpression != nil {
+		var result = in.info.Defs[ast.DeclaredName(f.fn.Declarator)].Type.(*types.Function).Result
+		var v = in.value(n.ReturnExpression)
+		if !types.IsVoid(result) {
+			v = in.convert(v, result)
+		}
+		f.result = v
+	}
+	return returnFunction
+}
+
+// switchTable holds the case and default statements of a switch
+// statement, leaving out those of nested switch statements.
+type switchTable struct {
+	cases  []*ast.LabeledStatement
+	values []int64
+	def    *ast.LabeledStatement
+}
+
+// switchTarget returns the statement of the switch n labeled with the
+// value v, or its default statement, or nil.
+func (in *interp) switchTarget(n *ast.SelectionStatement, v value) ast.Node {
+	var table = in.switches[n]
+	if table == nil {
+		table = &switchTable{}
+		ast.Inspect(n.SwitchBody, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.SelectionStatement:
+				return node.SwitchExpression == nil
+			case *ast.LabeledStatement:
+				switch {
+				case node.CaseExpression != nil:
+					table.cases = append(table.cases, node)
+					table.values = append(table.values, in.info.Types[node.CaseExpression].Value)
+				case node.DefaultStatement != nil:
+					table.def = node
+				}
+			}
+			return true
+		})
+		in.switches[n] = table
+	}
+
+	var t = types.IntegerPromotion(v.t)
+	v = in.convert(v, t)
+	for idx, c := range table.cases {
+		var x = in.info.Types[c.CaseExpression]
+		if in.convert(value{t: unqualified(x.Type), bits: uint64(table.values[idx])}, t).bits == v.bits {
+			return c
+		}
+	}
+	if table.def == nil {
+		return nil
+	}
+	return table.def
+}
@@ -0,0 +1,358 @@
+package interp
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/types"
+	"math"
+	"strconv"
+)
+
+// value is the value of an expression, of the unqualified type t.
+// Integers and pointers are held in bits, truncated to the width of t
+// and sign-extended for signed types, floating values in f and the bytes
+// of structures and unions in data, with their shadow in checked mode.
+// Pointers derived from an object, rather than made from integers, hold
+// it in prov.
+type value struct {
+	t      types.Type
+	bits   uint64
+	f      float64
+	data   []byte
+	shadow *shadow
+	prov   *block
+}
+
+var (
+	intType     = types.Typ[types.Int]
+	sizeType    = types.Typ[types.UnsignedLong]
+	ptrdiffType = types.Typ[types.Long]
+)
+
+func unqualified(t types.Type) types.Type {
+	var u, _ = types.Unqualified(t)
+	return u
+}
+
+// pointee returns the type pointed to by t, or nil if t is not a pointer.
+func pointee(t types.Type) types.Type {
+	if p, ok := unqualified(t).(*types.Pointer); ok {
+		return p.Elem
+	}
+	return nil
+}
+
+func truth(b bool) value {
+	if b {
+		return value{t: intType, bits: 1}
+	}
+	return value{t: intType}
+}
+
+// norm truncates bits to the width of the integer or pointer type t,
+// sign-extending the values of signed types.
+func (in *interp) norm(t types.Type, bits uint64) uint64 {
+	var width = int(in.target.Sizeof(t) * 8)
+	if width >= 64 {
+		return bits
+	}
+	bits &= 1<<width - 1
+	if types.IsInteger(t) && in.target.IsSigned(t) && bits>>(width-1) == 1 {
+		bits |= ^uint64(0) << width
+	}
+	return bits
+}
+
+// isTrue reports whether the scalar v compares unequal to 0.
+func isTrue(v value) bool {
+	if types.IsFloating(v.t) {
+		return v.f != 0
+	}
+	return v.bits != 0
+}
+
+// convert returns v converted to the type t.
+func (in *interp) convert(v value, t types.Type) value {
+	t = unqualified(t)
+	switch {
+	case types.IsFloating(t):
+		var f = v.f
+		if !types.IsFloating(v.t) {
+			if in.target.IsSigned(v.t) && types.IsInteger(v.t) {
+				f = float64(int64(v.bits))
+			} else {
+				f = float64(v.bits)
+			}
+		}
+		if in.target.Sizeof(t) == 4 {
+			f = float64(float32(f))
+		}
+		return value{t: t, f: f}
+	case types.IsScalar(t):
+		var bits = v.bits
+		if types.IsFloating(v.t) {
+			if v.f >= 1<<63 {
+				bits = uint64(v.f)
+			} else {
+				bits = uint64(int64(v.f))
+			}
+		}
+		var result = value{t: t, bits: in.norm(t, bits)}
+		if pointee(t) != nil && pointee(v.t) != nil {
+			result.prov = v.prov
+		}
+		return result
+	}
+	v.t = t
+	return v
+}
+
+// elemSize returns the size of the objects pointed to by the pointer type
+// t, taking void as a byte.
+func (in *interp) elemSize(t types.Type) int64 {
+	var elem = pointee(t)
+	if types.IsVoid(elem) {
+		return 1
+	}
+	return in.sizeof(elem)
+}
+
+// sizeof returns the size of objects of type t, whose arrays of variable
+// length have the length their declaration gave them.
+func (in *interp) sizeof(t types.Type) int64 {
+	if array, ok := unqualified(t).(*types.Array); ok && array.Kind != types.Unsized {
+		var length = array.Len
+		if array.Kind == types.VariableLength {
+			length = in.frame.lengths[array]
+		}
+		return length * in.sizeof(array.Elem)
+	}
+	return in.target.Sizeof(t)
+}
+
+// lengths evaluates the lengths of the arrays of variable length making
+// up the type t, or the types it points to, when their declaration is
+// reached.
+func (in *interp) lengths(t types.Type) {
+	for {
+		switch u := unqualified(t).(type) {
+		case *types.Array:
+			if n := in.info.Lengths[u]; n != nil {
+				var length = int64(in.convert(in.value(n.ConditionalExpression), types.Typ[types.Long]).bits)
+				if length <= 0 {
+					in.errorf(n, "variable length array bound evaluates to non-positive value %d", length)
+				}
+				in.frame.lengths[u] = length
+			}
+			t = u.Elem
+		case *types.Pointer:
+			t = u.Elem
+		default:
+			return
+		}
+	}
+}
+
+// binary returns x op y for an arithmetic, shift or bitwise operator, n
+// being the expression.
+func (in *interp) binary(n ast.Node, op string, x, y value) value {
+	switch op {
+	case "+", "-":
+		switch {
+		case pointee(x.t) != nil && pointee(y.t) != nil:
+			var diff = int64(x.bits-y.bits) / in.elemSize(x.t)
+			return value{t: ptrdiffType, bits: uint64(diff)}
+		case pointee(x.t) != nil:
+			return in.offset(x, y, op == "-")
+		case pointee(y.t) != nil:
+			return in.offset(y, x, false)
+		}
+	case "<<", ">>":
+		return in.shift(n, op, x, y)
+	}
+
+	var t = in.target.ArithmeticConversion(x.t, y.t)
+	x, y = in.convert(x, t), in.convert(y, t)
+	if types.IsFloating(t) {
+		var f float64
+		switch op {
+		case "+":
+			f = x.f + y.f
+		case "-":
+			f = x.f - y.f
+		case "*":
+			f = x.f * y.f
+		case "/":
+			f = x.f / y.f
+		}
+		return in.convert(value{t: types.Typ[types.Double], f: f}, t)
+	}
+
+	var signed = in.target.IsSigned(t)
+	if (op == "/" || op == "%") && y.bits == 0 {
+		in.errorf(n, "division by zero")
+	}
+	if signed && in.checked {
+		in.overflow(n, op, x, y)
+	}
+	var bits uint64
+	switch op {
+	case "+":
+		bits = x.bits + y.bits
+	case "-":
+		bits = x.bits - y.bits
+	case "*":
+		bits = x.bits * y.bits
+	case "/", "%":
+		switch {
+		case signed && op == "/":
+			bits = uint64(int64(x.bits) / int64(y.bits))
+		case signed:
+			bits = uint64(int64(x.bits) % int64(y.bits))
+		case op == "/":
+			bits = x.bits / y.bits
+		default:
+			bits = x.bits % y.bits
+		}
+	case "&":
+		bits = x.bits & y.bits
+	case "^":
+		bits = x.bits ^ y.bits
+	case "|":
+		bits = x.bits | y.bits
+	}
+	return value{t: t, bits: in.norm(t, bits)}
+}
+
+// overflow fails unless x op y, for an arithmetic operator and operands
+// of a signed type, can be represented in their type.
+func (in *interp) overflow(n ast.Node, op string, x, y value) {
+	var a, b = int64(x.bits), int64(y.bits)
+	var lo, hi = in.limits(x.t)
+	var r int64
+	var ok bool
+	switch op {
+	case "+":
+		r = a + b
+		ok = (b >= 0) == (r >= a)
+	case "-":
+		r = a - b
+		ok = (b >= 0) == (r <= a)
+	case "*":
+		r = a * b
+		ok = a == 0 || r/a == b && !(a == -1 && b == math.MinInt64)
+	case "/", "%":
+		ok, r = a != lo || b != -1, 0
+	default:
+		return
+	}
+	if !ok || r < lo || r > hi {
+		in.errorf(n, "signed integer overflow: %d %s %d cannot be represented in type %s", a, op, b, x.t)
+	}
+}
+
+// limits returns the least and greatest values of the signed integer type
+// t.
+func (in *interp) limits(t types.Type) (int64, int64) {
+	var width = in.target.Sizeof(t) * 8
+	return -1 << (width - 1), 1<<(width-1) - 1
+}
+
+// offset returns the pointer p moved by the integer i elements forward,
+// or backward when back is set.
+func (in *interp) offset(p, i value, back bool) value {
+	var delta = int64(i.bits)
+	if back {
+		delta = -delta
+	}
+	return value{t: p.t, bits: p.bits + uint64(delta*in.elemSize(p.t)), prov: p.prov}
+}
+
+// shift returns x shifted by y. Like the hardware, counts are taken
+// modulo the width of the promoted x, unless checked mode stops at
+// counts out of range and at left shifts of signed values that overflow.
+func (in *interp) shift(n ast.Node, op string, x, y value) value {
+	var t = types.IntegerPromotion(x.t)
+	x = in.convert(x, t)
+	y = in.convert(y, types.IntegerPromotion(y.t))
+	var width = uint64(in.target.Sizeof(t) * 8)
+	if in.checked {
+		in.shiftRange(n, op, x, y, width)
+	}
+	var count = y.bits & (width - 1)
+	if op == "<<" {
+		return value{t: t, bits: in.norm(t, x.bits<<count)}
+	}
+	if in.target.IsSigned(t) {
+		return value{t: t, bits: in.norm(t, uint64(int64(x.bits)>>count))}
+	}
+	return value{t: t, bits: x.bits >> count}
+}
+
+// shiftRange fails when shifting x by y has no defined result: when the
+// count y is negative or not less than width, the width of x, or when a
+// left shift of a signed x is negative or overflows.
+func (in *interp) shiftRange(n ast.Node, op string, x, y value, width uint64) {
+	if in.target.IsSigned(y.t) && int64(y.bits) < 0 || y.bits >= width {
+		var count = strconv.FormatUint(y.bits, 10)
+		if in.target.IsSigned(y.t) {
+			count = strconv.FormatInt(int64(y.bits), 10)
+		}
+		in.errorf(n, "shift count %s out of range for type %s", count, x.t)
+	}
+	if op != "<<" || !in.target.IsSigned(x.t) {
+		return
+	}
+	var _, hi = in.limits(x.t)
+	switch {
+	case int64(x.bits) < 0:
+		in.errorf(n, "left shift of negative value %d", int64(x.bits))
+	case int64(x.bits) > hi>>y.bits:
+		in.errorf(n, "left shift of %d by %d cannot be represented in type %s", int64(x.bits), y.bits, x.t)
+	}
+}
+
+// compare returns x op y for a relational or equality operator.
+func (in *interp) compare(op string, x, y value) value {
+	var cmp int
+	switch {
+	case types.IsArithmetic(x.t) && types.IsArithmetic(y.t):
+		var t = in.target.ArithmeticConversion(x.t, y.t)
+		x, y = in.convert(x, t), in.convert(y, t)
+		switch {
+		case types.IsFloating(t):
+			if x.f != x.f || y.f != y.f {
+				return truth(op == "!=")
+			}
+			cmp = compareOrdered(x.f, y.f)
+		case in.target.IsSigned(t):
+			cmp = compareOrdered(int64(x.bits), int64(y.bits))
+		default:
+			cmp = compareOrdered(x.bits, y.bits)
+		}
+	default:
+		cmp = compareOrdered(x.bits, y.bits)
+	}
+	switch op {
+	case "==":
+		return truth(cmp == 0)
+	case "!=":
+		return truth(cmp != 0)
+	case "<":
+		return truth(cmp < 0)
+	case "<=":
+		return truth(cmp <= 0)
+	case ">":
+		return truth(cmp > 0)
+	}
+	return truth(cmp >= 0)
+}
+
+func compareOrdered[T int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
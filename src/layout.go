@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"lazarus-c/src/diag"
+	"lazarus-c/src/sema"
+	"lazarus-c/src/types"
+	"os"
+	"sort"
+	"strings"
+)
+
+const colorPadding = "\x1b[1;31m"
+
+func layoutCommand(args []string) error {
+	var flags = flag.NewFlagSet("layout", flag.ExitOnError)
+	var targetName = flags.String("target", types.X86_64.Name, "data model: "+strings.Join(targetNames(), " or "))
+	var color = flags.Bool("color", false, "highlight padding")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: lazarus layout [-target=name] [-color] file tag")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	var target = types.Targets[*targetName]
+	if target == nil {
+		return fmt.Errorf("unknown target %q", *targetName)
+	}
+
+	var unit, err = parseInput(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var diags diag.List
+	var info = (&sema.Config{Target: target}).Check(unit, &diags)
+	diags = diags.Filter(warningFlags{}.enabled)
+	diags.Sort()
+	diags.Print(os.Stderr)
+	if diags.HasErrors() {
+		return fmt.Errorf("%s has errors", flags.Arg(0))
+	}
+
+	var tag = flags.Arg(1)
+	var obj = info.Scope.LookupTag(tag)
+	if obj == nil {
+		return fmt.Errorf("no structure or union %s at file scope", tag)
+	}
+	var s, ok = obj.Type.(*types.Struct)
+	if !ok || !s.Complete {
+		return fmt.Errorf("%s is not a complete structure or union", obj.Type)
+	}
+	printLayout(os.Stdout, s, target.Layout(s), *color)
+	return nil
+}
+
+func targetNames() []string {
+	var names []string
+	for name := range types.Targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// printLayout writes the offsets and sizes of the fields of s, and the
+// padding between them. Bit-fields have offsets in bytes and bits, and
+// sizes in bits.
+func printLayout(w io.Writer, s *types.Struct, layout *types.Layout, color bool) {
+	fmt.Fprintf(w, "%s: size %d, align %d\n", s, layout.Size, layout.Align)
+	fmt.Fprintf(w, "%8s %8s  %s\n", "offset", "size", "field")
+
+	var padding = func(from, to int64) {
+		if to <= from {
+			return
+		}
+		var offset, size = fmt.Sprint(from / 8), fmt.Sprintf("%d", (to-from)/8)
+		if from%8 != 0 || to%8 != 0 {
+			offset, size = fmt.Sprintf("%d.%d", from/8, from%8), fmt.Sprintf("%d bits", to-from)
+		}
+		var line = fmt.Sprintf("%8s %8s  (padding)", offset, size)
+		if color {
+			line = colorPadding + line + "\x1b[0m"
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	var end int64
+	for _, field := range layout.Fields {
+		var start = field.Offset*8 + int64(field.Bit)
+		if !s.Union {
+			padding(end, start)
+		}
+		var name = types.Declaration(field.Type, field.Name)
+		var offset, size = fmt.Sprint(field.Offset), fmt.Sprint(field.Size)
+		var bits = field.Size * 8
+		if field.BitField {
+			name += fmt.Sprintf(" : %d", field.Bits)
+			offset, size = fmt.Sprintf("%d.%d", field.Offset, field.Bit), fmt.Sprintf("%d bits", field.Bits)
+			bits = int64(field.Bits)
+		}
+		fmt.Fprintf(w, "%8s %8s  %s\n", offset, size, name)
+		end = max(end, start+bits)
+	}
+	padding(end, layout.Size*8)
+}
@@ -9,10 +9,11 @@ func (p Position) String() string {
 
 var Lexer = lexer.MustSimple([]lexer.SimpleRule{
 	{Name: "Whitespace", Pattern: `\s+`},
-	{Name: "Comment", Pattern: `//[^\n]*`},
+	{Name: "Comment", Pattern: `//[^\n]*|/\*([^*]|\*+[^*/])*\*+/`},
 
-	{Name: "Char", Pattern: `'(\\?\p{Any})+'`},
-	{Name: "String", Pattern: `"(\\?\p{Any})*"`},
+	{Name: "Char", Pattern: `'(\\.|[^'\\\n])+'`},
+	{Name: "String", Pattern: `"(\\.|[^"\\\n])*"`},
+	{Name: "Keyword", Pattern: `(auto|break|case|char|const|continue|default|do|double|else|enum|extern|float|for|goto|if|int|long|register|return|short|signed|sizeof|static|struct|switch|typedef|union|unsigned|void|volatile|while)\b`},
 	{Name: "Ident", Pattern: `[\p{L}_][\p{L}\p{N}_]*`},
 	{Name: "Float", Pattern: `[0-9]+\.[0-9]+([eE][+-]?[0-9]+)?|[0-9]+(\.[0-9]+)?[eE][+-]?[0-9]+`},
 	{Name: "Int", Pattern: `0[xX][0-9a-fA-F]+|0[oO][0-7]+|0[bB][01]+|[0-9]+`},
@@ -0,0 +1,125 @@
+package lexer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Unquote removes the surrounding quotes of a string or character literal
+// and interprets its C escape sequences.
+func Unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != s[len(s)-1] || (s[0] != '"' && s[0] != '\'') {
+		return "", fmt.Errorf("invalid literal %s", s)
+	}
+
+	var out strings.Builder
+	var body = s[1 : len(s)-1]
+	for idx := 0; idx < len(body); idx++ {
+		if body[idx] != '\\' {
+			out.WriteByte(body[idx])
+			continue
+		}
+		idx++
+		if idx == len(body) {
+			return "", fmt.Errorf("invalid escape at end of %s", s)
+		}
+		switch c := body[idx]; c {
+		case 'a':
+			out.WriteByte('\a')
+		case 'b':
+			out.WriteByte('\b')
+		case 'f':
+			out.WriteByte('\f')
+		case 'n':
+			out.WriteByte('\n')
+		case 'r':
+			out.WriteByte('\r')
+		case 't':
+			out.WriteByte('\t')
+		case 'v':
+			out.WriteByte('\v')
+		case '\\', '\'', '"', '?':
+			out.WriteByte(c)
+		case 'x':
+			var value, digits = 0, 0
+			for idx+1 < len(body) && isHexDigit(body[idx+1]) {
+				idx++
+				value = value*16 + hexValue(body[idx])
+				digits++
+			}
+			if digits == 0 || value > 0xff {
+				return "", fmt.Errorf("invalid hexadecimal escape in %s", s)
+			}
+			out.WriteByte(byte(value))
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			var value = int(c - '0')
+			for digits := 1; digits < 3 && idx+1 < len(body) && body[idx+1] >= '0' && body[idx+1] <= '7'; digits++ {
+				idx++
+				value = value*8 + int(body[idx]-'0')
+			}
+			if value > 0xff {
+				return "", fmt.Errorf("invalid octal escape in %s", s)
+			}
+			out.WriteByte(byte(value))
+		default:
+			return "", fmt.Errorf("unknown escape sequence \\%c in %s", c, s)
+		}
+	}
+
+	return out.String(), nil
+}
+
+// Quote surrounds s with the given quote character, escaping it so that
+// Unquote gives back s.
+func Quote(s string, quote byte) string {
+	var out strings.Builder
+	out.WriteByte(quote)
+	for idx := 0; idx < len(s); idx++ {
+		switch c := s[idx]; c {
+		case '\a':
+			out.WriteString(`\a`)
+		case '\b':
+			out.WriteString(`\b`)
+		case '\f':
+			out.WriteString(`\f`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\r':
+			out.WriteString(`\r`)
+		case '\t':
+			out.WriteString(`\t`)
+		case '\v':
+			out.WriteString(`\v`)
+		case '\\':
+			out.WriteString(`\\`)
+		default:
+			if c == quote {
+				out.WriteByte('\\')
+				out.WriteByte(c)
+			} else if c == 0 && (idx+1 == len(s) || s[idx+1] < '0' || s[idx+1] > '7') {
+				out.WriteString(`\0`)
+			} else if c < ' ' || c == 0x7f {
+				fmt.Fprintf(&out, `\%03o`, c)
+			} else {
+				out.WriteByte(c)
+			}
+		}
+	}
+	out.WriteByte(quote)
+	return out.String()
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func hexValue(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	default:
+		return int(c-'A') + 10
+	}
+}
@@ -1,44 +1,52 @@
 package main
 
-// import (
-// 	"fmt"
-// 	"github.com/alecthomas/participle/v2"
-// 	"github.com/alecthomas/participle/v2/lexer"
-// )
-
-// type Lmao struct {
-// 	Words []Word `parser:"@@*"`
-// }
-// type Word struct {
-// 	Pos lexer.Position
-// 	W string `parser:"@Ident"`
-// }
-
-// var program, err = participle.MustBuild[Lmao]().ParseString("source:", `auto break case char const continue default do double else enum extern float for goto if int long register return short signed sizeof static struct switch typedef union unsigned void volatile while`)
-
-// func main() {
-// 	if err != nil {
-// 		panic(err)
-// 	}
-// 	for _, w := range program.Words {
-// 		fmt.Println(w)
-// 	}
-// }
-
 import (
 	"fmt"
-	"lazarus-c/src/ast"
+	"os"
 )
 
-func main() {
-	var myAst, err = ast.ParseString(`
-int main()
-{
-	int i = 10;
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands []command
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: lazarus <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "\t%-10s %s\n", cmd.name, cmd.usage)
+	}
 }
-`)
-	if err != nil {
-		panic(err)
+
+func main() {
+	commands = []command{
+		{"check", "report errors and warnings in source files", check},
+		{"cfg", "print the control-flow graphs of functions", cfgCommand},
+		{"dump", "print the syntax tree of a file", dump},
+		{"fmt", "format source files", fmtCommand},
+		{"layout", "print the memory layout of a structure or union", layoutCommand},
+		{"migrate", "rewrite obsolete constructs of source files", migrateCommand},
+		{"query", "search syntax trees for a pattern", queryCommand},
+		{"run", "execute a program with an interpreter", runCommand},
+	}
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "lazarus %s: %s\n", cmd.name, err)
+				os.Exit(1)
+			}
+			return
+		}
 	}
-	fmt.Println(myAst.ExternalDeclarations[0])
+	fmt.Fprintf(os.Stderr, "lazarus: unknown command %q\n", os.Args[1])
+	usage()
+	os.Exit(2)
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"lazarus-c/src/format"
+	"lazarus-c/src/migrate"
+	"os"
+	"sort"
+	"strings"
+)
+
+// migrations are the rewrites of migrateCommand, by name.
+var migrations = map[string]func(filename string, src []byte) ([]byte, error){
+	"kr": migrate.KR,
+}
+
+func migrateCommand(args []string) error {
+	var flags = flag.NewFlagSet("migrate", flag.ExitOnError)
+	var diff = flags.Bool("d", false, "display diffs instead of rewriting files")
+	flags.Usage = func() {
+		var names []string
+		for name := range migrations {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(os.Stderr, "usage: lazarus migrate %s [-d] [files]\n", strings.Join(names, "|"))
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	var name = flags.Arg(0)
+	var rewrite = migrations[name]
+	if rewrite == nil {
+		return fmt.Errorf("unknown migration %q", name)
+	}
+	// The flags may follow the name of the migration, as the flags of a
+	// command follow its name.
+	flags.Parse(flags.Args()[1:])
+
+	if flags.NArg() == 0 {
+		var src, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		res, err := rewrite("<standard input>", src)
+		if err != nil {
+			return err
+		}
+		if *diff {
+			_, err = os.Stdout.Write(format.Diff("<standard input>.orig", "<standard input>", src, res))
+		} else {
+			_, err = os.Stdout.Write(res)
+		}
+		return err
+	}
+
+	for _, filename := range flags.Args() {
+		var src, err = os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		res, err := rewrite(filename, src)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(src, res) {
+			continue
+		}
+		if *diff {
+			os.Stdout.Write(format.Diff(filename+".orig", filename, src, res))
+			continue
+		}
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filename, res, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// Package migrate rewrites C source files written in obsolete styles into
+// their modern equivalents, keeping the rest of the source as it is.
+package migrate
+
+import (
+	"fmt"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/format"
+	"lazarus-c/src/printer"
+	"sort"
+	"strings"
+)
+
+// edit replaces the bytes of the source between the offsets start and end
+// with text.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// apply returns src with the edits, which must not overlap, applied.
+func apply(src []byte, edits []edit) []byte {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var out strings.Builder
+	var last = 0
+	for _, e := range edits {
+		out.Write(src[last:e.start])
+		out.WriteString(e.text)
+		last = e.end
+	}
+	out.Write(src[last:])
+	return []byte(out.String())
+}
+
+// text returns the source of n.
+func text(src []byte, n ast.Node) string {
+	return string(src[ast.Pos(n).Offset:ast.End(n).Offset])
+}
+
+// KR rewrites the old-style function definitions of src, the content of
+// the named file, into prototypes. The parameters of an identifier list
+// take the types of the declarations following the declarator, or int
+// when not declared, and empty parentheses become (void):
+//
+//	int f(a, p)          int f(int a, char *p)
+//	char *p;       =>    {
+//	{
+//
+// The comments of the identifier list and of the declarations follow the
+// parameter they are next to in the prototype.
+func KR(filename string, src []byte) ([]byte, error) {
+	var unit, err = ast.ParseBytes(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := format.Comments(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	var edits []edit
+	for _, external := range unit.ExternalDeclarations {
+		var fn = external.FunctionDefinition
+		if fn == nil {
+			continue
+		}
+		var suffix = ast.FunctionSuffix(fn.Declarator)
+		if suffix == nil || suffix.ParameterTypeList != nil {
+			continue
+		}
+		params, err := parameters(src, fn, suffix, comments)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit{ast.Pos(suffix).Offset, ast.End(suffix).Offset, "(" + params + ")"})
+		if fn.DeclarationList != nil {
+			edits = append(edits, edit{ast.End(fn.Declarator).Offset, ast.End(fn.DeclarationList).Offset, ""})
+		}
+	}
+	return apply(src, edits), nil
+}
+
+// parameters returns the parameter type list equivalent to the identifier
+// list suffix of the definition fn and its parameter declarations, with
+// the comments among them.
+func parameters(src []byte, fn *ast.FunctionDefinition, suffix *ast.DeclaratorSuffix, comments []printer.Comment) (string, error) {
+	var declared = map[string]string{}
+	var names []*ast.DirectDeclarator
+	if fn.DeclarationList != nil {
+		for _, decl := range fn.DeclarationList.Declarations {
+			if decl.InitDeclaratorList == nil {
+				continue
+			}
+			var specifiers = text(src, decl.DeclarationSpecifiers)
+			for _, d := range decl.InitDeclaratorList.InitDeclarators {
+				var param = specifiers + " " + text(src, d.Declarator)
+				if d.Attributes != nil {
+					param += " " + text(src, d.Attributes)
+				}
+				var direct = ast.DeclaredName(d.Declarator)
+				declared[*direct.Identifier] = param
+				names = append(names, direct)
+			}
+		}
+	}
+
+	var notes, err = parameterComments(src, fn, suffix, names, comments)
+	if err != nil {
+		return "", err
+	}
+	var params []string
+	var listed = map[string]bool{}
+	if suffix.IdentifierList != nil {
+		for _, name := range suffix.IdentifierList.Identifiers {
+			var param, ok = declared[name]
+			if !ok {
+				param = "int " + name
+			}
+			listed[name] = true
+			params = append(params, strings.Join(append([]string{param}, notes[name]...), " "))
+		}
+	}
+	for _, direct := range names {
+		if !listed[*direct.Identifier] {
+			return "", fmt.Errorf("%s: declaration of %s, which is not a parameter", direct.Pos, *direct.Identifier)
+		}
+	}
+	if len(params) == 0 {
+		return strings.Join(append([]string{"void"}, notes[""]...), " "), nil
+	}
+	return strings.Join(params, ", "), nil
+}
+
+// parameterComments returns the comments of the identifier list suffix and
+// of the parameter declarations of fn, declaring names, by the parameter
+// they follow, or precede when first. Comments of empty parentheses are
+// listed under the empty name. Line comments become block comments.
+func parameterComments(src []byte, fn *ast.FunctionDefinition, suffix *ast.DeclaratorSuffix, names []*ast.DirectDeclarator, comments []printer.Comment) (map[string][]string, error) {
+	var identifiers []string
+	if suffix.IdentifierList != nil {
+		identifiers = suffix.IdentifierList.Identifiers
+	}
+	var notes = map[string][]string{}
+	for _, comment := range comments {
+		var offset = comment.Pos.Offset
+		var name string
+		switch {
+		case offset >= ast.Pos(suffix).Offset && offset < ast.End(suffix).Offset:
+			if len(identifiers) > 0 {
+				var idx = strings.Count(string(src[ast.Pos(suffix).Offset:offset]), ",")
+				name = identifiers[min(idx, len(identifiers)-1)]
+			}
+		case fn.DeclarationList != nil && offset >= ast.End(fn.Declarator).Offset && offset < ast.End(fn.DeclarationList).Offset:
+			if len(identifiers) > 0 {
+				name = identifiers[0]
+			}
+			for idx, direct := range names {
+				if idx == 0 || direct.Pos.Offset < offset {
+					name = *direct.Identifier
+				}
+			}
+		default:
+			continue
+		}
+
+		var text = comment.Text
+		if strings.HasPrefix(text, "//") {
+			if strings.Contains(text, "*/") {
+				return nil, fmt.Errorf("%s: comment cannot be moved into the prototype", comment.Pos)
+			}
+			text = "/*" + text[2:] + " */"
+		}
+		notes[name] = append(notes[name], text)
+	}
+	return notes, nil
+}
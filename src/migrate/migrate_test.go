@@ -0,0 +1,71 @@
+package migrate
+
+import "testing"
+
+// definitions lists old-style sources with their rewrite into prototypes.
+var definitions = []struct {
+	src, want string
+}{
+	{
+		"int f(a, p)\nchar *p;\n{\n\treturn a;\n}\n",
+		"int f(int a, char *p)\n{\n\treturn a;\n}\n",
+	},
+	{
+		"int g()\n{\n\treturn 0;\n}\n",
+		"int g(void)\n{\n\treturn 0;\n}\n",
+	},
+	{
+		"static long *h(n, s, v) unsigned n; const char *s, v[]; { return 0; }\n",
+		"static long *h(unsigned n, const char *s, const char v[]) { return 0; }\n",
+	},
+	{
+		"int (*k(x))(int) double x; { return 0; }\n",
+		"int (*k(double x))(int) { return 0; }\n",
+	},
+
+	// Prototypes and declarations are left alone.
+	{
+		"int f(int a);\nint g();\nint h(void) { return g(); }\n",
+		"int f(int a);\nint g();\nint h(void) { return g(); }\n",
+	},
+
+	// Comments follow their parameter.
+	{
+		"int f(a /* first */, b)\nint a; /* count */\nchar b;\n{\n\treturn a;\n}\n",
+		"int f(int a /* first */ /* count */, char b)\n{\n\treturn a;\n}\n",
+	},
+	{
+		"int f(/* none */)\n{\n\treturn 0;\n}\n",
+		"int f(void /* none */)\n{\n\treturn 0;\n}\n",
+	},
+	{
+		"int f(a, b)\nint a; // count\nchar b;\n{\n\treturn a;\n}\n",
+		"int f(int a /* count */, char b)\n{\n\treturn a;\n}\n",
+	},
+
+	// Comments after the declarations stay before the body.
+	{
+		"int f(a)\nint a; /* count */\n{\n\treturn a;\n}\n",
+		"int f(int a) /* count */\n{\n\treturn a;\n}\n",
+	},
+}
+
+func TestKR(t *testing.T) {
+	for _, test := range definitions {
+		var got, err = KR("test.c", []byte(test.src))
+		if err != nil {
+			t.Errorf("%q: %v", test.src, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("rewrote\n%s\nas\n%s\ninstead of\n%s", test.src, got, test.want)
+		}
+	}
+}
+
+func TestKRErrors(t *testing.T) {
+	var _, err = KR("test.c", []byte("int f(a) int a, b; { return a; }\n"))
+	if want := "test.c:1:17: declaration of b, which is not a parameter"; err == nil || err.Error() != want {
+		t.Errorf("got error %v instead of %q", err, want)
+	}
+}
@@ -0,0 +1,459 @@
+package printer
+
+import (
+	"lazarus-c/src/ast"
+)
+
+// translationUnit separates external declarations that span several
+// lines, such as function and struct definitions, with blank lines.
+func (p *printer) translationUnit(n *ast.TranslationUnit) {
+	var previous = false
+	for idx, ed := range n.ExternalDeclarations {
+		var current = multiline(ed)
+		if idx > 0 && (previous || current) {
+			p.trailingComments(ed.Pos.Offset)
+			p.blankLine()
+		}
+		p.separate(ed.Pos.Offset)
+		p.externalDeclaration(ed)
+		previous = current
+	}
+}
+
+func multiline(n *ast.ExternalDeclaration) bool {
+	if n.FunctionDefinition != nil {
+		return true
+	}
+	for s := n.Declaration.DeclarationSpecifiers; s != nil; s = s.DeclarationSpecifiers {
+		if t := s.TypeSpecifier; t != nil {
+			if t.StructOrUnionSpecifier != nil && t.StructOrUnionSpecifier.StructDeclarationList != nil {
+				return true
+			}
+			if t.EnumSpecifier != nil && t.EnumSpecifier.EnumeratorList != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *printer) externalDeclaration(n *ast.ExternalDeclaration) {
+	if n.FunctionDefinition != nil {
+		p.functionDefinition(n.FunctionDefinition)
+	} else {
+		p.startLine()
+		p.declaration(n.Declaration)
+		p.write("\n")
+	}
+}
+
+func (p *printer) functionDefinition(n *ast.FunctionDefinition) {
+	p.startLine()
+	if n.DeclarationSpecifiers != nil {
+		p.declarationSpecifiers(n.DeclarationSpecifiers)
+		p.write(" ")
+	}
+	p.declarator(n.Declarator)
+	if n.DeclarationList != nil {
+		p.indent++
+		p.declarationList(n.DeclarationList)
+		p.comments(n.CompoundStatement.Pos.Offset)
+		p.indent--
+	}
+	p.startLine()
+	p.compoundStatement(n.CompoundStatement)
+	p.write("\n")
+}
+
+func (p *printer) declarationList(n *ast.DeclarationList) {
+	for _, d := range n.Declarations {
+		p.separate(d.Pos.Offset)
+		p.startLine()
+		p.declaration(d)
+		p.write("\n")
+	}
+}
+
+func (p *printer) declaration(n *ast.Declaration) {
+	p.declarationSpecifiers(n.DeclarationSpecifiers)
+	if n.InitDeclaratorList != nil {
+		p.write(" ")
+		p.initDeclaratorList(n.InitDeclaratorList)
+	}
+	p.token(";")
+}
+
+func (p *printer) declarationSpecifiers(n *ast.DeclarationSpecifiers) {
+	for ; n != nil; n = n.DeclarationSpecifiers {
+		switch {
+		case n.StorageClassSpecifier != nil:
+			p.token(*n.StorageClassSpecifier)
+		case n.TypeSpecifier != nil:
+			p.typeSpecifier(n.TypeSpecifier)
+		case n.TypeQualifier != nil:
+			p.token(*n.TypeQualifier.Qualifier)
+		case n.Attributes != nil:
+			p.attributes(n.Attributes)
+		}
+		if n.DeclarationSpecifiers != nil {
+			p.write(" ")
+		}
+	}
+}
+
+func (p *printer) specifierQualifierList(n *ast.SpecifierQualifierList) {
+	for ; n != nil; n = n.SpecifierQualifierList {
+		if n.TypeSpecifier != nil {
+			p.typeSpecifier(n.TypeSpecifier)
+		} else {
+			p.token(*n.TypeQualifier.Qualifier)
+		}
+		if n.SpecifierQualifierList != nil {
+			p.write(" ")
+		}
+	}
+}
+
+func (p *printer) typeSpecifier(n *ast.TypeSpecifier) {
+	switch {
+	case n.TypeSpecifier != nil:
+		p.token(*n.TypeSpecifier)
+	case n.StructOrUnionSpecifier != nil:
+		p.structOrUnionSpecifier(n.StructOrUnionSpecifier)
+	case n.EnumSpecifier != nil:
+		p.enumSpecifier(n.EnumSpecifier)
+	}
+}
+
+func (p *printer) structOrUnionSpecifier(n *ast.StructOrUnionSpecifier) {
+	p.token(*n.StructOrUnion)
+	if n.Attributes != nil {
+		p.write(" ")
+		p.attributes(n.Attributes)
+	}
+	if n.Identifier != nil {
+		p.write(" ")
+		p.token(*n.Identifier)
+	}
+	if n.StructDeclarationList != nil {
+		p.write(" ")
+		p.token("{")
+		p.indent++
+		for _, d := range n.StructDeclarationList.StructDeclarations {
+			p.structDeclaration(d)
+		}
+		p.comments(n.EndPos.Offset)
+		p.indent--
+		p.startLine()
+		p.token("}")
+	}
+	if n.TrailingAttributes != nil {
+		p.write(" ")
+		p.attributes(n.TrailingAttributes)
+	}
+}
+
+func (p *printer) structDeclaration(n *ast.StructDeclaration) {
+	p.separate(n.Pos.Offset)
+	p.startLine()
+	p.specifierQualifierList(n.SpecifierQualifierList)
+	p.write(" ")
+	p.structDeclaratorList(n.StructDeclaratorList)
+	p.token(";")
+	p.write("\n")
+}
+
+func (p *printer) structDeclaratorList(n *ast.StructDeclaratorList) {
+	for idx, d := range n.StructDeclarators {
+		if idx > 0 {
+			p.comma(n.StructDeclarators[idx-1])
+		}
+		p.structDeclarator(d)
+	}
+}
+
+func (p *printer) structDeclarator(n *ast.StructDeclarator) {
+	p.before(n)
+	if n.Declarator != nil {
+		p.declarator(n.Declarator)
+		if n.ConstantExpression != nil {
+			p.write(" ")
+		}
+	}
+	if n.ConstantExpression != nil {
+		p.token(":")
+		p.write(" ")
+		p.conditionalExpression(n.ConstantExpression.ConditionalExpression)
+	}
+	if n.Attributes != nil {
+		p.write(" ")
+		p.attributes(n.Attributes)
+	}
+}
+
+func (p *printer) enumBase(n *ast.EnumBase) {
+	if n.Identifier != nil {
+		p.token(*n.Identifier)
+		return
+	}
+	p.specifierQualifierList(n.SpecifierQualifierList)
+}
+
+// attributes writes each attribute in its own __attribute__ list.
+func (p *printer) attributes(n *ast.Attributes) {
+	for idx, a := range n.Attributes {
+		if idx > 0 {
+			p.write(" ")
+		}
+		p.token("__attribute__")
+		p.token("(")
+		p.token("(")
+		p.attribute(a)
+		p.token(")")
+		p.token(")")
+	}
+}
+
+func (p *printer) attribute(n *ast.Attribute) {
+	p.token(*n.Name)
+	if len(n.Arguments) == 0 {
+		return
+	}
+	p.token("(")
+	for idx, arg := range n.Arguments {
+		if idx > 0 {
+			p.comma(n.Arguments[idx-1])
+		}
+		p.conditionalExpression(arg.ConditionalExpression)
+	}
+	p.token(")")
+}
+
+func (p *printer) enumSpecifier(n *ast.EnumSpecifier) {
+	p.token("enum")
+	if n.Identifier != nil {
+		p.write(" ")
+		p.token(*n.Identifier)
+	}
+	if n.EnumBase != nil {
+		p.write(" ")
+		p.token(":")
+		p.write(" ")
+		p.enumBase(n.EnumBase)
+	}
+	if n.EnumeratorList != nil {
+		p.write(" ")
+		p.token("{")
+		p.indent++
+		for _, e := range n.EnumeratorList.Enumerators {
+			p.enumerator(e)
+		}
+		p.comments(n.EndPos.Offset)
+		p.indent--
+		p.startLine()
+		p.token("}")
+	}
+}
+
+func (p *printer) enumerator(n *ast.Enumerator) {
+	p.separate(n.Pos.Offset)
+	p.startLine()
+	p.token(*n.Identifier)
+	if n.ConstantExpression != nil {
+		p.write(" ")
+		p.token("=")
+		p.write(" ")
+		p.conditionalExpression(n.ConstantExpression.ConditionalExpression)
+	}
+	p.token(",")
+	p.write("\n")
+}
+
+func (p *printer) initDeclaratorList(n *ast.InitDeclaratorList) {
+	for idx, d := range n.InitDeclarators {
+		if idx > 0 {
+			p.comma(n.InitDeclarators[idx-1])
+		}
+		p.initDeclarator(d)
+	}
+}
+
+func (p *printer) initDeclarator(n *ast.InitDeclarator) {
+	p.before(n)
+	p.declarator(n.Declarator)
+	if n.Attributes != nil {
+		p.write(" ")
+		p.attributes(n.Attributes)
+	}
+	if n.Initializer != nil {
+		p.write(" ")
+		p.token("=")
+		p.write(" ")
+		p.initializer(n.Initializer)
+	}
+}
+
+func (p *printer) initializer(n *ast.Initializer) {
+	if n.AssignmentExpression != nil {
+		p.assignmentExpression(n.AssignmentExpression)
+	} else {
+		p.initializerList(n.InitializerList)
+	}
+}
+
+func (p *printer) initializerList(n *ast.InitializerList) {
+	p.token("{")
+	for idx, i := range n.Initializers {
+		if idx > 0 {
+			p.comma(n.Initializers[idx-1])
+		}
+		p.initializer(i)
+	}
+	p.token("}")
+}
+
+func (p *printer) declarator(n *ast.Declarator) {
+	if n.Pointer != nil {
+		p.pointer(n.Pointer, true)
+	}
+	for _, d := range n.DirectDeclarators {
+		p.directDeclarator(d)
+	}
+}
+
+// pointer writes a chain of stars with their qualifiers. A space is left
+// after a trailing qualifier when more of the declarator follows.
+func (p *printer) pointer(n *ast.Pointer, more bool) {
+	for ; n != nil; n = n.Pointer {
+		p.token("*")
+		if n.TypeQualifierList != nil {
+			p.typeQualifierList(n.TypeQualifierList)
+			if n.Pointer != nil || more {
+				p.write(" ")
+			}
+		}
+	}
+}
+
+func (p *printer) typeQualifierList(n *ast.TypeQualifierList) {
+	for idx, q := range n.TypeQualifiers {
+		if idx > 0 {
+			p.write(" ")
+		}
+		p.token(*q.Qualifier)
+	}
+}
+
+func (p *printer) directDeclarator(n *ast.DirectDeclarator) {
+	if n.Identifier != nil {
+		p.token(*n.Identifier)
+	} else {
+		p.token("(")
+		p.declarator(n.Declarator)
+		p.token(")")
+	}
+	for _, s := range n.DeclaratorSuffixes {
+		p.declaratorSuffix(s)
+	}
+}
+
+func (p *printer) declaratorSuffix(n *ast.DeclaratorSuffix) {
+	if n.IsArray {
+		p.token("[")
+		if n.ArrayLength != nil {
+			p.conditionalExpression(n.ArrayLength.ConditionalExpression)
+		}
+		p.token("]")
+		return
+	}
+	p.token("(")
+	if n.ParameterTypeList != nil {
+		p.parameterTypeList(n.ParameterTypeList)
+	} else if n.IdentifierList != nil {
+		p.identifierList(n.IdentifierList)
+	}
+	p.inline(n.EndPos.Offset - 1)
+	p.token(")")
+}
+
+// identifierList follows the identifiers in the source, which the tree
+// does not locate, to place the comments among them.
+func (p *printer) identifierList(n *ast.IdentifierList) {
+	var offset = n.Pos.Offset
+	for idx, ident := range n.Identifiers {
+		if idx > 0 {
+			offset = p.skip(offset)
+			p.inline(offset)
+			p.token(",")
+			p.write(" ")
+			offset++
+		}
+		offset = p.skip(offset)
+		if p.inline(offset) {
+			p.write(" ")
+		}
+		p.token(ident)
+		offset += len(ident)
+	}
+}
+
+func (p *printer) parameterTypeList(n *ast.ParameterTypeList) {
+	p.parameterList(n.ParameterList)
+	if n.Ellipsis {
+		p.comma(n.ParameterList)
+		p.token("...")
+	}
+}
+
+func (p *printer) parameterList(n *ast.ParameterList) {
+	for idx, d := range n.ParameterDeclarations {
+		if idx > 0 {
+			p.comma(n.ParameterDeclarations[idx-1])
+		}
+		p.parameterDeclaration(d)
+	}
+}
+
+func (p *printer) parameterDeclaration(n *ast.ParameterDeclaration) {
+	p.before(n)
+	p.declarationSpecifiers(n.DeclarationSpecifiers)
+	if n.Declarator != nil {
+		p.write(" ")
+		p.declarator(n.Declarator)
+	} else if n.AbstractDeclarator != nil {
+		p.write(" ")
+		p.abstractDeclarator(n.AbstractDeclarator)
+	}
+	if n.Attributes != nil {
+		p.write(" ")
+		p.attributes(n.Attributes)
+	}
+}
+
+func (p *printer) typeName(n *ast.TypeName) {
+	p.specifierQualifierList(n.SpecifierQualifierList)
+	if n.AbstractDeclarator != nil {
+		p.write(" ")
+		p.abstractDeclarator(n.AbstractDeclarator)
+	}
+}
+
+func (p *printer) abstractDeclarator(n *ast.AbstractDeclarator) {
+	if n.Pointer != nil {
+		p.pointer(n.Pointer, n.DirectAbstractDeclarator != nil)
+	}
+	if n.DirectAbstractDeclarator != nil {
+		p.directAbstractDeclarator(n.DirectAbstractDeclarator)
+	}
+}
+
+func (p *printer) directAbstractDeclarator(n *ast.DirectAbstractDeclarator) {
+	if n.AbstractDeclarator != nil {
+		p.token("(")
+		p.abstractDeclarator(n.AbstractDeclarator)
+		p.token(")")
+	}
+	for _, s := range n.DeclaratorSuffixes {
+		p.declaratorSuffix(s)
+	}
+}
@@ -0,0 +1,221 @@
+package printer
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/lexer"
+)
+
+// Every precedence level of the grammar has its own node type, so the
+// tree itself decides how operands group. Parentheses are only written
+// where the source had them, as a PrimaryExpression holding an
+// Expression.
+
+func (p *printer) expression(n *ast.Expression) {
+	for idx, a := range n.AssignmentExpressions {
+		if idx > 0 {
+			p.comma(n.AssignmentExpressions[idx-1])
+		}
+		p.assignmentExpression(a)
+	}
+}
+
+func (p *printer) assignmentExpression(n *ast.AssignmentExpression) {
+	p.before(n)
+	for idx, u := range n.UnaryExpressions {
+		p.unaryExpression(u)
+		p.write(" ")
+		p.token(*n.AssignmentOperators[idx].AssignmentOperator)
+		p.write(" ")
+	}
+	p.conditionalExpression(n.ConditionalExpression)
+}
+
+func (p *printer) conditionalExpression(n *ast.ConditionalExpression) {
+	p.logicalOrExpression(n.LogicalOrExpression)
+	if n.TernaryTrueExpression != nil {
+		p.binary("?")
+		p.expression(n.TernaryTrueExpression)
+		p.binary(":")
+		p.conditionalExpression(n.TernaryFalseExpression)
+	}
+}
+
+// binary writes an infix operator surrounded by spaces.
+func (p *printer) binary(operator string) {
+	p.write(" ")
+	p.token(operator)
+	p.write(" ")
+}
+
+func (p *printer) logicalOrExpression(n *ast.LogicalOrExpression) {
+	for idx, e := range n.LogicalAndExpressions {
+		if idx > 0 {
+			p.binary("||")
+		}
+		p.logicalAndExpression(e)
+	}
+}
+
+func (p *printer) logicalAndExpression(n *ast.LogicalAndExpression) {
+	for idx, e := range n.InclusiveOrExpressions {
+		if idx > 0 {
+			p.binary("&&")
+		}
+		p.inclusiveOrExpression(e)
+	}
+}
+
+func (p *printer) inclusiveOrExpression(n *ast.InclusiveOrExpression) {
+	for idx, e := range n.ExclusiveOrExpressions {
+		if idx > 0 {
+			p.binary("|")
+		}
+		p.exclusiveOrExpression(e)
+	}
+}
+
+func (p *printer) exclusiveOrExpression(n *ast.ExclusiveOrExpression) {
+	for idx, e := range n.AndExpressions {
+		if idx > 0 {
+			p.binary("^")
+		}
+		p.andExpression(e)
+	}
+}
+
+func (p *printer) andExpression(n *ast.AndExpression) {
+	for idx, e := range n.EqualityExpressions {
+		if idx > 0 {
+			p.binary("&")
+		}
+		p.equalityExpression(e)
+	}
+}
+
+func (p *printer) equalityExpression(n *ast.EqualityExpression) {
+	p.relationalExpression(n.HeadRelationalExpression)
+	for idx, e := range n.TailRelationalExpressions {
+		p.binary(n.Operators[idx])
+		p.relationalExpression(e)
+	}
+}
+
+func (p *printer) relationalExpression(n *ast.RelationalExpression) {
+	p.shiftExpression(n.HeadShiftExpression)
+	for idx, e := range n.TailShiftExpressions {
+		p.binary(n.Operators[idx])
+		p.shiftExpression(e)
+	}
+}
+
+func (p *printer) shiftExpression(n *ast.ShiftExpression) {
+	p.additiveExpression(n.HeadAdditiveExpression)
+	for idx, e := range n.TailAdditiveExpressions {
+		p.binary(n.Operators[idx])
+		p.additiveExpression(e)
+	}
+}
+
+func (p *printer) additiveExpression(n *ast.AdditiveExpression) {
+	p.multiplicativeExpression(n.HeadMultiplicativeExpression)
+	for idx, e := range n.TailMultiplicativeExpression {
+		p.binary(n.Operators[idx])
+		p.multiplicativeExpression(e)
+	}
+}
+
+func (p *printer) multiplicativeExpression(n *ast.MultiplicativeExpression) {
+	p.castExpression(n.HeadCastExpression)
+	for idx, e := range n.TailCastExpression {
+		p.binary(n.Operators[idx])
+		p.castExpression(e)
+	}
+}
+
+func (p *printer) castExpression(n *ast.CastExpression) {
+	for _, t := range n.TypeNames {
+		p.token("(")
+		p.typeName(t)
+		p.token(")")
+	}
+	p.unaryExpression(n.UnaryExpression)
+}
+
+func (p *printer) unaryExpression(n *ast.UnaryExpression) {
+	if n.SizeOfTypeName != nil {
+		p.token("sizeof")
+		p.token("(")
+		p.typeName(n.SizeOfTypeName)
+		p.token(")")
+		return
+	}
+	for _, operator := range n.UnaryOperators {
+		p.token(operator)
+	}
+	if n.PostfixExpression != nil {
+		p.postfixExpression(n.PostfixExpression)
+	} else {
+		p.token(*n.UnaryOperatorOnCast.Operator)
+		p.castExpression(n.CastExpression)
+	}
+}
+
+func (p *printer) postfixExpression(n *ast.PostfixExpression) {
+	p.primaryExpression(n.PrimaryExpression)
+	for _, o := range n.PostfixOperators {
+		p.postfixOperator(o)
+	}
+}
+
+func (p *printer) postfixOperator(n *ast.PostfixOperator) {
+	switch {
+	case n.ArrayAccessExpression != nil:
+		p.token("[")
+		p.expression(n.ArrayAccessExpression)
+		p.token("]")
+	case n.IsCall:
+		p.token("(")
+		if n.ArgumentExpressionList != nil {
+			p.argumentExpressionList(n.ArgumentExpressionList)
+		}
+		p.inline(n.EndPos.Offset - 1)
+		p.token(")")
+	case n.IdentifierAccess != nil:
+		p.token(".")
+		p.token(*n.IdentifierAccess)
+	case n.IdentifierPtrAccess != nil:
+		p.token("->")
+		p.token(*n.IdentifierPtrAccess)
+	case n.Operator != nil:
+		p.token(*n.Operator)
+	}
+}
+
+func (p *printer) argumentExpressionList(n *ast.ArgumentExpressionList) {
+	for idx, a := range n.AssignmentExpressions {
+		if idx > 0 {
+			p.comma(n.AssignmentExpressions[idx-1])
+		}
+		p.assignmentExpression(a)
+	}
+}
+
+func (p *printer) primaryExpression(n *ast.PrimaryExpression) {
+	p.before(n)
+	switch {
+	case n.Identifier != nil:
+		p.token(*n.Identifier)
+	case n.Int != nil:
+		p.token(*n.Int)
+	case n.Float != nil:
+		p.token(*n.Float)
+	case n.Char != nil:
+		p.token(lexer.Quote(*n.Char, '\''))
+	case n.StringLiteral != nil:
+		p.token(lexer.Quote(*n.StringLiteral, '"'))
+	case n.Expression != nil:
+		p.token("(")
+		p.expression(n.Expression)
+		p.token(")")
+	}
+}
@@ -0,0 +1,412 @@
+// Package printer renders syntax trees back into C source code.
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/lexer"
+	"math"
+	"strings"
+)
+
+// Comment is a comment of the source, which the syntax tree does not
+// record.
+type Comment struct {
+	Pos  lexer.Position
+	Text string
+	// Trailing is set when the comment follows code on the same line.
+	Trailing bool
+}
+
+// Config carries what the printer needs to reproduce the parts of the
+// source that are not in the syntax tree.
+type Config struct {
+	// Comments, sorted by position, are written before the first
+	// statement, declaration or closing brace that follows them.
+	Comments []Comment
+	// Source is the text the tree was parsed from. When set, blank lines
+	// between statements and declarations are kept, collapsed to one.
+	Source []byte
+}
+
+type printer struct {
+	config *Config
+	out    bytes.Buffer
+	indent int
+	last   byte
+	next   int
+	// lineComment is the length of the output after the last line
+	// comment written, which no comment may follow on its line.
+	lineComment int
+}
+
+// Fprint writes the source code of node to w. Statements, declarations
+// and larger nodes are written as complete lines indented with tabs,
+// while expressions and other fragments are written inline.
+func Fprint(w io.Writer, node ast.Node) error {
+	return (&Config{}).Fprint(w, node)
+}
+
+// Fprint writes the source code of node to w like the Fprint function,
+// adding the comments and blank lines described by c.
+func (c *Config) Fprint(w io.Writer, node ast.Node) error {
+	var p = &printer{config: c}
+	if err := p.node(node); err != nil {
+		return err
+	}
+	p.comments(math.MaxInt)
+	var _, err = w.Write(p.out.Bytes())
+	return err
+}
+
+// Sprint returns the source code of node as written by Fprint.
+func Sprint(node ast.Node) (string, error) {
+	var out strings.Builder
+	var err = Fprint(&out, node)
+	return out.String(), err
+}
+
+func (p *printer) node(node ast.Node) error {
+	switch n := node.(type) {
+	case *ast.TranslationUnit:
+		p.translationUnit(n)
+	case *ast.ExternalDeclaration:
+		p.externalDeclaration(n)
+	case *ast.FunctionDefinition:
+		p.functionDefinition(n)
+	case *ast.CompoundStatement:
+		p.startLine()
+		p.compoundStatement(n)
+		p.write("\n")
+	case *ast.StatementList:
+		for _, s := range n.Statements {
+			p.separate(s.Pos.Offset)
+			p.statement(s)
+		}
+	case *ast.Statement:
+		p.statement(n)
+	case *ast.LabeledStatement:
+		p.statement(&ast.Statement{LabeledStatement: n})
+	case *ast.ExpressionStatement:
+		p.statement(&ast.Statement{ExpressionStatement: n})
+	case *ast.SelectionStatement:
+		p.statement(&ast.Statement{SelectionStatement: n})
+	case *ast.IterationStatement:
+		p.statement(&ast.Statement{IterationStatement: n})
+	case *ast.JumpStatement:
+		p.statement(&ast.Statement{JumpStatement: n})
+	case *ast.DeclarationSpecifiers:
+		p.declarationSpecifiers(n)
+	case *ast.TypeSpecifier:
+		p.typeSpecifier(n)
+	case *ast.StructOrUnionSpecifier:
+		p.structOrUnionSpecifier(n)
+	case *ast.StructDeclarationList:
+		for _, d := range n.StructDeclarations {
+			p.structDeclaration(d)
+		}
+	case *ast.StructDeclaration:
+		p.structDeclaration(n)
+	case *ast.SpecifierQualifierList:
+		p.specifierQualifierList(n)
+	case *ast.StructDeclaratorList:
+		p.structDeclaratorList(n)
+	case *ast.StructDeclarator:
+		p.structDeclarator(n)
+	case *ast.EnumSpecifier:
+		p.enumSpecifier(n)
+	case *ast.EnumeratorList:
+		for _, e := range n.Enumerators {
+			p.enumerator(e)
+		}
+	case *ast.Enumerator:
+		p.enumerator(n)
+	case *ast.EnumBase:
+		p.enumBase(n)
+	case *ast.Attributes:
+		p.attributes(n)
+	case *ast.Attribute:
+		p.attribute(n)
+	case *ast.DeclarationList:
+		p.declarationList(n)
+	case *ast.Declaration:
+		p.startLine()
+		p.declaration(n)
+		p.write("\n")
+	case *ast.InitDeclaratorList:
+		p.initDeclaratorList(n)
+	case *ast.InitDeclarator:
+		p.initDeclarator(n)
+	case *ast.Initializer:
+		p.initializer(n)
+	case *ast.InitializerList:
+		p.initializerList(n)
+	case *ast.Declarator:
+		p.declarator(n)
+	case *ast.Pointer:
+		p.pointer(n, false)
+	case *ast.TypeQualifierList:
+		p.typeQualifierList(n)
+	case *ast.TypeQualifier:
+		p.token(*n.Qualifier)
+	case *ast.DirectDeclarator:
+		p.directDeclarator(n)
+	case *ast.DeclaratorSuffix:
+		p.declaratorSuffix(n)
+	case *ast.IdentifierList:
+		p.identifierList(n)
+	case *ast.ParameterTypeList:
+		p.parameterTypeList(n)
+	case *ast.ParameterList:
+		p.parameterList(n)
+	case *ast.ParameterDeclaration:
+		p.parameterDeclaration(n)
+	case *ast.AbstractDeclarator:
+		p.abstractDeclarator(n)
+	case *ast.DirectAbstractDeclarator:
+		p.directAbstractDeclarator(n)
+	case *ast.ConstantExpression:
+		p.conditionalExpression(n.ConditionalExpression)
+	case *ast.ConditionalExpression:
+		p.conditionalExpression(n)
+	case *ast.LogicalOrExpression:
+		p.logicalOrExpression(n)
+	case *ast.LogicalAndExpression:
+		p.logicalAndExpression(n)
+	case *ast.InclusiveOrExpression:
+		p.inclusiveOrExpression(n)
+	case *ast.ExclusiveOrExpression:
+		p.exclusiveOrExpression(n)
+	case *ast.AndExpression:
+		p.andExpression(n)
+	case *ast.EqualityExpression:
+		p.equalityExpression(n)
+	case *ast.RelationalExpression:
+		p.relationalExpression(n)
+	case *ast.ShiftExpression:
+		p.shiftExpression(n)
+	case *ast.AdditiveExpression:
+		p.additiveExpression(n)
+	case *ast.MultiplicativeExpression:
+		p.multiplicativeExpression(n)
+	case *ast.CastExpression:
+		p.castExpression(n)
+	case *ast.UnaryExpression:
+		p.unaryExpression(n)
+	case *ast.UnaryOperator:
+		p.token(*n.Operator)
+	case *ast.TypeName:
+		p.typeName(n)
+	case *ast.PostfixExpression:
+		p.postfixExpression(n)
+	case *ast.PostfixOperator:
+		p.postfixOperator(n)
+	case *ast.ArgumentExpressionList:
+		p.argumentExpressionList(n)
+	case *ast.PrimaryExpression:
+		p.primaryExpression(n)
+	case *ast.Expression:
+		p.expression(n)
+	case *ast.AssignmentExpression:
+		p.assignmentExpression(n)
+	case *ast.AssignmentOperator:
+		p.token(*n.AssignmentOperator)
+	default:
+		return fmt.Errorf("printer: unsupported node type %T", node)
+	}
+	return nil
+}
+
+// write appends s to the output verbatim.
+func (p *printer) write(s string) {
+	if s == "" {
+		return
+	}
+	p.out.WriteString(s)
+	p.last = s[len(s)-1]
+}
+
+// token appends a single lexical token, separating it from the previous
+// one with a space when writing them next to each other would make the
+// lexer read something else.
+func (p *printer) token(s string) {
+	if p.last != 0 && s != "" && merges(p.last, s[0]) {
+		p.write(" ")
+	}
+	p.write(s)
+}
+
+// separate prepares for a statement or declaration starting at offset in
+// the source: it writes the comments that come before it and a blank line
+// where the source had at least one.
+func (p *printer) separate(offset int) {
+	p.comments(offset)
+	if p.blankBefore(offset) {
+		p.blankLine()
+	}
+}
+
+// comments writes the comments found before offset in the source.
+// Trailing comments are appended to the last line written.
+func (p *printer) comments(offset int) {
+	p.flush(offset, false)
+}
+
+// trailingComments only writes the trailing comments that come first
+// among those found before offset.
+func (p *printer) trailingComments(offset int) {
+	p.flush(offset, true)
+}
+
+func (p *printer) flush(offset int, trailingOnly bool) {
+	for ; p.next < len(p.config.Comments); p.next++ {
+		var comment = p.config.Comments[p.next]
+		if comment.Pos.Offset >= offset || trailingOnly && !comment.Trailing {
+			return
+		}
+
+		var content = bytes.TrimRight(p.out.Bytes(), "\n")
+		if comment.Trailing && p.last == '\n' && len(content) != p.lineComment {
+			var newlines = p.out.Len() - len(content)
+			p.out.Truncate(len(content))
+			p.write(" ")
+			p.write(comment.Text)
+			p.endComment(comment)
+			p.write(strings.Repeat("\n", newlines))
+			continue
+		}
+
+		if p.blankBefore(comment.Pos.Offset) {
+			p.blankLine()
+		}
+		p.startLine()
+		p.write(comment.Text)
+		p.endComment(comment)
+		p.write("\n")
+	}
+}
+
+// endComment records the end of comment, just written, when it is a line
+// comment.
+func (p *printer) endComment(comment Comment) {
+	if strings.HasPrefix(comment.Text, "//") {
+		p.lineComment = p.out.Len()
+	}
+}
+
+// inline writes the block comments found before offset in the source
+// within the line being written, stopping at the first one that is not
+// a block comment. It reports whether it wrote any.
+func (p *printer) inline(offset int) bool {
+	var written = false
+	for ; p.next < len(p.config.Comments); p.next++ {
+		var comment = p.config.Comments[p.next]
+		if comment.Pos.Offset >= offset || !strings.HasPrefix(comment.Text, "/*") || p.last == 0 || p.last == '\n' {
+			return written
+		}
+		if !strings.ContainsRune(" \t([", rune(p.last)) {
+			p.write(" ")
+		}
+		p.write(comment.Text)
+		written = true
+	}
+	return written
+}
+
+// before writes the block comments found before n in the source, within
+// the line being written.
+func (p *printer) before(n ast.Node) {
+	if p.inline(ast.Pos(n).Offset) {
+		p.write(" ")
+	}
+}
+
+// comma writes the comma following the list element prev, after the
+// block comments between them.
+func (p *printer) comma(prev ast.Node) {
+	p.inline(p.skip(ast.End(prev).Offset))
+	p.token(",")
+	p.write(" ")
+}
+
+// skip returns the offset of the first token at or after offset in the
+// source, past blanks and comments.
+func (p *printer) skip(offset int) int {
+	var source = p.config.Source
+	var next = p.next
+	for offset < len(source) {
+		for next < len(p.config.Comments) && p.config.Comments[next].Pos.Offset < offset {
+			next++
+		}
+		switch {
+		case strings.IndexByte(" \t\r\n\f\v", source[offset]) >= 0:
+			offset++
+		case next < len(p.config.Comments) && p.config.Comments[next].Pos.Offset == offset:
+			offset += len(p.config.Comments[next].Text)
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+// blankBefore reports whether the source has an empty line right before
+// offset.
+func (p *printer) blankBefore(offset int) bool {
+	var source = p.config.Source
+	if offset > len(source) {
+		return false
+	}
+	var newlines = 0
+	for idx := offset - 1; idx >= 0; idx-- {
+		switch source[idx] {
+		case '\n':
+			newlines++
+		case ' ', '\t', '\r', '\f', '\v':
+		default:
+			return newlines > 1
+		}
+	}
+	return false
+}
+
+// blankLine ends the current line and leaves an empty one, unless the
+// output is at the start of the file or of a block.
+func (p *printer) blankLine() {
+	var out = p.out.Bytes()
+	if len(out) == 0 || bytes.HasSuffix(out, []byte("\n\n")) || bytes.HasSuffix(out, []byte("{\n")) {
+		return
+	}
+	if p.last != '\n' {
+		p.write("\n")
+	}
+	p.write("\n")
+}
+
+// startLine begins a new line at the current indentation.
+func (p *printer) startLine() {
+	if p.last != 0 && p.last != '\n' {
+		p.write("\n")
+	}
+	p.write(strings.Repeat("\t", p.indent))
+}
+
+var mergingPairs = map[string]bool{
+	"++": true, "--": true, "&&": true, "||": true, "<<": true, ">>": true,
+	"->": true, "+=": true, "-=": true, "*=": true, "/=": true, "%=": true,
+	"&=": true, "|=": true, "^=": true, "<=": true, ">=": true, "==": true,
+	"!=": true, "//": true, "/*": true, "..": true,
+}
+
+func merges(left byte, right byte) bool {
+	if isWordByte(left) && isWordByte(right) {
+		return true
+	}
+	return mergingPairs[string([]byte{left, right})]
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
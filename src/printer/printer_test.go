@@ -0,0 +1,131 @@
+package printer
+
+import (
+	"lazarus-c/src/ast"
+	"testing"
+)
+
+// roundTrip lists sources which must parse to the same tree once printed.
+var roundTrip = []string{
+	// Keywords are not identifiers.
+	"int f(void) { return sizeof(int); }",
+	"unsigned long long int x; signed char c; long double d;",
+	"extern int e; static const volatile int s = 1; register int r;",
+
+	// Case and default labels.
+	`int f(int x) {
+		switch (x) {
+		case 1:
+		case 2: x++; break;
+		default: x = 0;
+		}
+		return x;
+	}`,
+	"int f(int x) { switch (x) { default: return 1; case -1: return 2; } }",
+
+	// Postfix operators and declarator suffixes, in order.
+	"void f(void) { a[1](2).b->c++--; g()()[0]; }",
+	"int (*fp[4])(char *, ...);",
+	"char *(*(*x)())[];",
+	"int (*signal(int, void (*)(int)))(int);",
+	"int a[], b[][3], c[2][3][4];",
+	"void f() { g(); }",
+
+	// Operator lists and precedence.
+	"int x = a + b - c * d / e % f << 1 >> 2;",
+	"int y = a < b <= c > d >= e == f != g & h ^ i | j && k || l;",
+	"int z = (a + b) * (c - d) / -(e);",
+	"void f(void) { x = y = z; x += 1; x -= 1; x *= 2; x /= 2; x %= 3; x <<= 1; x >>= 1; x &= 1; x ^= 1; x |= 1; }",
+	"void f(void) { r = a ? b : c ? d : e; r = (a ? b : c) ? d : e; }",
+	"void f(void) { x = (y, z); for (i = 0, j = 1; i < j; i++, j--) ; }",
+	"void f(void) { *p++ = *q--; ++*p; --p[0]; x = !~-+y; x = &*p; }",
+	"void f(void) { x = (int)y; x = (char *)(void *)p; x = (unsigned long)-1; }",
+	"int n = sizeof x + sizeof(int *) + sizeof(struct s) + sizeof(int (*)[3]);",
+
+	// Structures, unions, enumerations and bit-fields.
+	"struct s { int a; char *b; struct s *next; };",
+	"union u { int i; float f; } v, *pv;",
+	"struct { int : 3; unsigned x : 2, y : 1; int : 0; } bits;",
+	"struct s; struct s *p;",
+	"enum e { A, B = 2, C };",
+	"enum { X, Y, };",
+	"enum e : unsigned char { Z };",
+
+	// Pointers and qualifiers.
+	"int **p; int *const *volatile q; const char *const s;",
+
+	// Abstract declarators.
+	"void g(int (*)[3], char *, int (*)(void), int[]);",
+
+	// Literals with escapes.
+	`char c = '\n', q = '\'', b = '\\', z = '\0';`,
+	`char *s = "a\tb\"c\\d\n";`,
+	"int h = 0x1F, o = 017, n = 0;",
+	"double d = 1.5, e = 2e10;",
+
+	// Initializers.
+	"int a[3] = { 1, 2, 3 }, m[2][2] = { { 1, 2 }, { 3, 4 } };",
+	"struct s v = { 1, { 2, 3 }, };",
+
+	// Statements.
+	`int f(int n) {
+		int i, sum = 0;
+		for (i = 0; i < n; i++) {
+			if (i % 2)
+				continue;
+			else if (i > 10)
+				break;
+			else
+				sum += i;
+		}
+		while (n--)
+			sum--;
+		do {
+			sum++;
+		} while (sum < 0);
+		for (;;)
+			break;
+		goto end;
+	end:
+		return sum;
+	}`,
+	"void f(void) { if (a) if (b) x(); else y(); }",
+	"void f(void) { if (a) { if (b) x(); } else y(); }",
+	"void f(void) { ; {} { int x; } }",
+
+	// Old-style definitions.
+	"int f(a, b) int a; char *b; { return a; }",
+	"int g() { return 0; }",
+
+	// Attributes.
+	"int x __attribute__((aligned(8))), y __attribute__((unused, packed));",
+	"struct p { char c; int i __attribute__((packed)); };",
+}
+
+func TestRoundTrip(t *testing.T) {
+	var config = &ast.PrintConfig{}
+	for _, src := range roundTrip {
+		var unit, err = ast.ParseString(src)
+		if err != nil {
+			t.Errorf("parsing %q: %v", src, err)
+			continue
+		}
+		printed, err := Sprint(unit)
+		if err != nil {
+			t.Errorf("printing %q: %v", src, err)
+			continue
+		}
+		reparsed, err := ast.ParseString(printed)
+		if err != nil {
+			t.Errorf("parsing the printed %q: %v\n%s", src, err, printed)
+			continue
+		}
+		if want, got := config.Sprint(unit), config.Sprint(reparsed); got != want {
+			t.Errorf("printing %q changes its tree:\n%s\nparses to\n%s\ninstead of\n%s", src, printed, got, want)
+			continue
+		}
+		if again, _ := Sprint(reparsed); again != printed {
+			t.Errorf("printing %q again gives\n%s\ninstead of\n%s", src, again, printed)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package printer
+
+import (
+	"lazarus-c/src/ast"
+)
+
+// compoundStatement writes a braced block starting at the current
+// position and leaves the output right after the closing brace.
+func (p *printer) compoundStatement(n *ast.CompoundStatement) {
+	p.token("{")
+	p.write("\n")
+	p.indent++
+	if n.DeclarationList != nil {
+		p.declarationList(n.DeclarationList)
+	}
+	if n.StatementList != nil {
+		for _, s := range n.StatementList.Statements {
+			p.separate(s.Pos.Offset)
+			p.statement(s)
+		}
+	}
+	p.comments(n.EndPos.Offset)
+	p.indent--
+	p.startLine()
+	p.token("}")
+}
+
+// statement writes n on lines of its own.
+func (p *printer) statement(n *ast.Statement) {
+	p.comments(n.Pos.Offset)
+	switch {
+	case n.LabeledStatement != nil:
+		p.labeledStatement(n.LabeledStatement)
+	case n.CompoundStatement != nil:
+		p.startLine()
+		p.compoundStatement(n.CompoundStatement)
+		p.write("\n")
+	case n.ExpressionStatement != nil:
+		p.startLine()
+		p.expressionStatement(n.ExpressionStatement)
+		p.write("\n")
+	case n.SelectionStatement != nil:
+		p.startLine()
+		p.selectionStatement(n.SelectionStatement)
+	case n.IterationStatement != nil:
+		p.startLine()
+		p.iterationStatement(n.IterationStatement)
+	case n.JumpStatement != nil:
+		p.startLine()
+		p.jumpStatement(n.JumpStatement)
+		p.write("\n")
+	}
+}
+
+// labeledStatement writes the label one level to the left of the
+// statement it introduces.
+func (p *printer) labeledStatement(n *ast.LabeledStatement) {
+	if p.indent > 0 {
+		p.indent--
+		defer func() { p.indent++ }()
+	}
+	p.startLine()
+	switch {
+	case n.GotoLabel != nil:
+		p.token(*n.GotoLabel)
+		p.token(":")
+		if n.Attributes != nil {
+			p.write(" ")
+			p.attributes(n.Attributes)
+		}
+		p.write("\n")
+		p.indent++
+		p.statement(n.GotoStatement)
+	case n.CaseExpression != nil:
+		p.token("case")
+		p.write(" ")
+		p.conditionalExpression(n.CaseExpression.ConditionalExpression)
+		p.token(":")
+		p.write("\n")
+		p.indent++
+		p.statement(n.CaseStatement)
+	default:
+		p.token("default")
+		p.token(":")
+		p.write("\n")
+		p.indent++
+		p.statement(n.DefaultStatement)
+	}
+	p.indent--
+}
+
+func (p *printer) expressionStatement(n *ast.ExpressionStatement) {
+	if n.Expression != nil {
+		p.expression(n.Expression)
+	}
+	p.token(";")
+}
+
+// body writes the statement controlled by an if, else, while, do or for.
+// Blocks stay on the same line as their header, other statements move to
+// the next line one level deeper. The result reports whether a block was
+// written, in which case the output is left right after its brace.
+func (p *printer) body(n *ast.Statement) bool {
+	if n.CompoundStatement != nil {
+		p.write(" ")
+		p.compoundStatement(n.CompoundStatement)
+		return true
+	}
+	p.write("\n")
+	p.indent++
+	p.statement(n)
+	p.indent--
+	return false
+}
+
+func (p *printer) selectionStatement(n *ast.SelectionStatement) {
+	if n.SwitchExpression != nil {
+		p.token("switch")
+		p.write(" ")
+		p.token("(")
+		p.expression(n.SwitchExpression)
+		p.token(")")
+		if p.body(n.SwitchBody) {
+			p.write("\n")
+		}
+		return
+	}
+
+	p.token("if")
+	p.write(" ")
+	p.token("(")
+	p.expression(n.IfTest)
+	p.token(")")
+	var block = p.body(n.IfBody)
+	if n.ElseBody == nil {
+		if block {
+			p.write("\n")
+		}
+		return
+	}
+
+	if block {
+		p.write(" ")
+	} else {
+		p.startLine()
+	}
+	p.token("else")
+	if n.ElseBody.SelectionStatement != nil && n.ElseBody.SelectionStatement.IfTest != nil {
+		p.write(" ")
+		p.selectionStatement(n.ElseBody.SelectionStatement)
+		return
+	}
+	if p.body(n.ElseBody) {
+		p.write("\n")
+	}
+}
+
+func (p *printer) iterationStatement(n *ast.IterationStatement) {
+	switch {
+	case n.WhileTest != nil:
+		p.token("while")
+		p.write(" ")
+		p.token("(")
+		p.expression(n.WhileTest)
+		p.token(")")
+		if p.body(n.WhileBody) {
+			p.write("\n")
+		}
+	case n.DoBody != nil:
+		p.token("do")
+		if p.body(n.DoBody) {
+			p.write(" ")
+		} else {
+			p.startLine()
+		}
+		p.token("while")
+		p.write(" ")
+		p.token("(")
+		p.expression(n.DoTest)
+		p.token(")")
+		p.token(";")
+		p.write("\n")
+	default:
+		p.token("for")
+		p.write(" ")
+		p.token("(")
+		if n.ForInit.Expression != nil {
+			p.expression(n.ForInit.Expression)
+		}
+		p.token(";")
+		if n.ForTest.Expression != nil {
+			p.write(" ")
+			p.expression(n.ForTest.Expression)
+		}
+		p.token(";")
+		if n.ForUpdate != nil {
+			p.write(" ")
+			p.expression(n.ForUpdate)
+		}
+		p.token(")")
+		if p.body(n.ForBody) {
+			p.write("\n")
+		}
+	}
+}
+
+func (p *printer) jumpStatement(n *ast.JumpStatement) {
+	switch {
+	case n.GotoIdent != nil:
+		p.token("goto")
+		p.write(" ")
+		p.token(*n.GotoIdent)
+	case n.IsContinue:
+		p.token("continue")
+	case n.IsBreak:
+		p.token("break")
+	case n.IsReturn:
+		p.token("return")
+		if n.ReturnExpression != nil {
+			p.write(" ")
+			p.expression(n.ReturnExpression)
+		}
+	}
+	p.token(";")
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/query"
+	"os"
+)
+
+func queryCommand(args []string) error {
+	var flags = flag.NewFlagSet("query", flag.ExitOnError)
+	var count = flags.Bool("c", false, "only print the number of matches of each file")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: lazarus query [-c] query [files]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var q, err = query.Compile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var filenames = flags.Args()[1:]
+	if len(filenames) == 0 {
+		filenames = []string{"-"}
+	}
+	for _, filename := range filenames {
+		var name, src, err = readInput(filename)
+		if err != nil {
+			return err
+		}
+		unit, err := ast.ParseBytes(name, src)
+		if err != nil {
+			return err
+		}
+
+		var matches = q.Find(unit)
+		if *count {
+			fmt.Printf("%s: %d\n", name, len(matches))
+			continue
+		}
+		for _, match := range matches {
+			fmt.Printf("%s: %s\n", ast.Pos(match.Value.Node), match.Value.Text())
+			for _, capture := range match.Captures {
+				fmt.Printf("\t@%s %s: %s\n", capture.Name, ast.Pos(capture.Value.Node), capture.Value.Text())
+			}
+		}
+	}
+	return nil
+}
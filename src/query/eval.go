@@ -0,0 +1,255 @@
+package query
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/printer"
+	"reflect"
+	"strings"
+)
+
+// Value is a node, or a lexeme or set flag held by a node.
+type Value struct {
+	// Node is the node itself or the one holding the lexeme or flag.
+	Node   ast.Node
+	Lexeme *string
+	Flag   bool
+}
+
+// Text returns the text values compare with: the lexeme, "true" for a
+// flag, or the source code of the node on a single line.
+func (v Value) Text() string {
+	switch {
+	case v.Lexeme != nil:
+		return *v.Lexeme
+	case v.Flag:
+		return "true"
+	}
+	var source, err = printer.Sprint(v.Node)
+	if err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(source), " ")
+}
+
+func (v Value) isNode() bool {
+	return v.Lexeme == nil && !v.Flag
+}
+
+// Capture is a value recorded by an @name step.
+type Capture struct {
+	Name  string
+	Value Value
+}
+
+// Match is a value found by a query along with the captures made on the
+// way to it.
+type Match struct {
+	Value    Value
+	Captures []Capture
+}
+
+// Find returns the matches of q in the tree rooted at root, in the order
+// the matching nodes appear.
+func (q *Query) Find(root ast.Node) []Match {
+	var matches []Match
+	ast.Inspect(root, func(n ast.Node) bool {
+		if q.Test.matches(n) {
+			matches = append(matches, Match{Value: Value{Node: n}})
+		}
+		return true
+	})
+	matches = apply(q.Steps, matches)
+
+	// Descendant steps reach the same value from nested nodes.
+	var seen = make(map[Value]bool)
+	var out = matches[:0]
+	for _, match := range matches {
+		if !seen[match.Value] {
+			seen[match.Value] = true
+			out = append(out, match)
+		}
+	}
+	return out
+}
+
+func (t *Test) matches(n ast.Node) bool {
+	return t.Any || reflect.TypeOf(n).Elem().Name() == t.Type
+}
+
+func apply(steps []*Step, matches []Match) []Match {
+	for _, step := range steps {
+		var next []Match
+		for _, match := range matches {
+			if step.Capture != "" {
+				var captures = append(match.Captures[:len(match.Captures):len(match.Captures)], Capture{step.Capture, match.Value})
+				next = append(next, Match{match.Value, captures})
+				continue
+			}
+			if step.Filter != nil {
+				if ok, captures := step.Filter.eval(match); ok {
+					next = append(next, Match{match.Value, captures})
+				}
+				continue
+			}
+			if !match.Value.isNode() {
+				continue
+			}
+			for _, value := range step.values(match.Value.Node) {
+				next = append(next, Match{value, match.Captures})
+			}
+		}
+		matches = next
+	}
+	return matches
+}
+
+// values returns the values reached from n by a step that moves.
+func (s *Step) values(n ast.Node) []Value {
+	var out []Value
+	switch {
+	case s.Field != nil:
+		out = fieldValues(n, s.Field.Name)
+		if s.Field.Index != nil {
+			if *s.Field.Index >= len(out) {
+				return nil
+			}
+			out = out[*s.Field.Index : *s.Field.Index+1]
+		}
+	case s.Child != nil:
+		for _, child := range ast.Children(n) {
+			if s.Child.matches(child) {
+				out = append(out, Value{Node: child})
+			}
+		}
+	case s.Descendant != nil:
+		for _, child := range ast.Children(n) {
+			ast.Inspect(child, func(n ast.Node) bool {
+				if s.Descendant.matches(n) {
+					out = append(out, Value{Node: n})
+				}
+				return true
+			})
+		}
+	case s.Unwrap != nil:
+		for {
+			if s.Unwrap.matches(n) {
+				return []Value{{Node: n}}
+			}
+			var children = ast.Children(n)
+			if len(children) != 1 || len(fieldValues(n, "")) > 0 {
+				return nil
+			}
+			n = children[0]
+		}
+	}
+	return out
+}
+
+// fieldValues returns the values held by the named field of n. With an
+// empty name, it returns the lexemes and flags of all fields.
+func fieldValues(n ast.Node, name string) []Value {
+	var nodeVal = reflect.ValueOf(n).Elem()
+	var fields []reflect.Value
+	if name != "" {
+		fields = append(fields, nodeVal.FieldByName(name))
+	} else {
+		for idx := 0; idx < nodeVal.NumField(); idx++ {
+			fields = append(fields, nodeVal.Field(idx))
+		}
+	}
+
+	var out []Value
+	for _, fieldVal := range fields {
+		switch fieldVal.Kind() {
+		case reflect.Bool:
+			if fieldVal.Bool() {
+				out = append(out, Value{Node: n, Flag: true})
+			}
+		case reflect.Pointer:
+			if !fieldVal.IsNil() && (name != "" || fieldVal.Type().Elem().Kind() == reflect.String) {
+				out = append(out, makeValue(n, fieldVal))
+			}
+		case reflect.Slice:
+			for idx := 0; idx < fieldVal.Len(); idx++ {
+				var elemVal = fieldVal.Index(idx)
+				if elemVal.Kind() == reflect.String {
+					out = append(out, Value{Node: n, Lexeme: elemVal.Addr().Interface().(*string)})
+				} else if name != "" && !elemVal.IsNil() {
+					out = append(out, makeValue(n, elemVal))
+				}
+			}
+		}
+	}
+	return out
+}
+
+func makeValue(n ast.Node, val reflect.Value) Value {
+	if lexeme, ok := val.Interface().(*string); ok {
+		return Value{Node: n, Lexeme: lexeme}
+	}
+	return Value{Node: val.Interface().(ast.Node)}
+}
+
+// eval reports whether the filter holds for match, and returns the
+// captures of match extended with those made by the filter.
+func (o *Or) eval(match Match) (bool, []Capture) {
+	for _, and := range o.And {
+		if ok, captures := and.eval(match); ok {
+			return true, captures
+		}
+	}
+	return false, nil
+}
+
+func (a *And) eval(match Match) (bool, []Capture) {
+	for _, not := range a.Not {
+		var ok bool
+		if ok, match.Captures = not.eval(match); !ok {
+			return false, nil
+		}
+	}
+	return true, match.Captures
+}
+
+func (n *Not) eval(match Match) (bool, []Capture) {
+	switch {
+	case n.Negated != nil:
+		var ok, _ = n.Negated.eval(match)
+		return !ok, match.Captures
+	case n.Group != nil:
+		return n.Group.eval(match)
+	}
+	return n.Comparison.eval(match)
+}
+
+func (c *Comparison) eval(match Match) (bool, []Capture) {
+	var matches = []Match{match}
+	if c.Path.Test != nil && (!match.Value.isNode() || !c.Path.Test.matches(match.Value.Node)) {
+		return false, nil
+	}
+	matches = apply(c.Path.Steps, matches)
+
+	switch c.Operator {
+	case "":
+		if len(matches) > 0 {
+			return true, matches[0].Captures
+		}
+	case "=", "~":
+		for _, m := range matches {
+			var text = m.Value.Text()
+			if c.Operator == "=" && text == c.Value || c.Operator == "~" && c.regexp.MatchString(text) {
+				return true, m.Captures
+			}
+		}
+	case "!=":
+		for _, m := range matches {
+			if m.Value.Text() == c.Value {
+				return false, nil
+			}
+		}
+		if len(matches) > 0 {
+			return true, matches[0].Captures
+		}
+	}
+	return false, nil
+}
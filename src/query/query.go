@@ -0,0 +1,286 @@
+// Package query implements a small path language to search syntax trees.
+//
+// A query starts with a node type, or * for any node, and matches every
+// node of that type in the tree. The steps that follow move from the
+// matched nodes to other nodes or filter them:
+//
+//	.Field      the value of a field, as named in package ast; slices
+//	            give all their elements and .Field[2] only the third one
+//	/Type       the children of type Type
+//	//Type      the descendants of type Type
+//	>Type       the first node of type Type found by going down through
+//	            nodes that only hold a single child, starting with the
+//	            node itself; it sees through the levels of expressions
+//	[expr]      the nodes for which expr holds
+//	@name       records the current node as the capture name
+//
+// Filter expressions combine relative paths with and, or, not and
+// parentheses. A path alone holds when it gives any value. A path can
+// be compared with a string: = holds when any of its values is equal to
+// it, != when there are values and none is equal, and ~ when any value
+// matches the string as a regular expression. Lexemes compare by their
+// text, nodes by their source code as written by package printer.
+//
+// Calls to memcpy whose third argument is not a sizeof expression are
+// found with:
+//
+//	PostfixExpression[.PrimaryExpression.Identifier = "memcpy"]@call
+//	  .PostfixOperators[0][.IsCall].ArgumentExpressionList
+//	  .AssignmentExpressions[2]@size
+//	  [not >UnaryExpression[.SizeOfTypeName or .UnaryOperators = "sizeof"]]
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/participle/v2"
+	"github.com/alecthomas/participle/v2/lexer"
+	"lazarus-c/src/ast"
+)
+
+var queryLexer = lexer.MustSimple([]lexer.SimpleRule{
+	{Name: "Whitespace", Pattern: `\s+`},
+	{Name: "String", Pattern: `"(\\.|[^"\\])*"`},
+	{Name: "Keyword", Pattern: `(and|or|not)\b`},
+	{Name: "Ident", Pattern: `[A-Za-z_][A-Za-z0-9_]*`},
+	{Name: "Int", Pattern: `[0-9]+`},
+	{Name: "Punct", Pattern: `//|!=|[./\[\]()@=~>*]`},
+})
+
+var parser = participle.MustBuild[Query](
+	participle.UseLookahead(2),
+	participle.Lexer(queryLexer),
+	participle.Map(unquote, "String"),
+	participle.Elide("Whitespace"),
+)
+
+func unquote(token lexer.Token) (lexer.Token, error) {
+	var value, err = strconv.Unquote(token.Value)
+	if err != nil {
+		return token, participle.Errorf(token.Pos, "%s", err)
+	}
+	token.Value = value
+	return token, nil
+}
+
+type Query struct {
+	Pos   lexer.Position
+	Test  *Test   `parser:"@@"`
+	Steps []*Step `parser:"@@*"`
+}
+
+type Test struct {
+	Pos  lexer.Position
+	Any  bool   `parser:"@'*'"`
+	Type string `parser:"| @Ident"`
+}
+
+type Step struct {
+	Pos        lexer.Position
+	Field      *Field `parser:"'.' @@"`
+	Child      *Test  `parser:"| '/' @@"`
+	Descendant *Test  `parser:"| '//' @@"`
+	Unwrap     *Test  `parser:"| '>' @@"`
+	Filter     *Or    `parser:"| '[' @@ ']'"`
+	Capture    string `parser:"| '@' @Ident"`
+}
+
+type Field struct {
+	Pos   lexer.Position
+	Name  string `parser:"@Ident"`
+	Index *int   `parser:"( '[' @Int ']' )?"`
+}
+
+type Or struct {
+	Pos lexer.Position
+	And []*And `parser:"@@ ( 'or' @@ )*"`
+}
+
+type And struct {
+	Pos lexer.Position
+	Not []*Not `parser:"@@ ( 'and' @@ )*"`
+}
+
+type Not struct {
+	Pos        lexer.Position
+	Negated    *Not        `parser:"'not' @@"`
+	Group      *Or         `parser:"| '(' @@ ')'"`
+	Comparison *Comparison `parser:"| @@"`
+}
+
+type Comparison struct {
+	Pos      lexer.Position
+	Path     *Path  `parser:"@@"`
+	Operator string `parser:"( @( '=' | '!=' | '~' )"`
+	Value    string `parser:"@String )?"`
+	regexp   *regexp.Regexp
+}
+
+// Path is a query relative to the node being filtered. Without a leading
+// type test, it starts from that node.
+type Path struct {
+	Pos   lexer.Position
+	Test  *Test   `parser:"@@?"`
+	Steps []*Step `parser:"@@*"`
+}
+
+// Compile parses a query and checks the node types and fields it names.
+func Compile(s string) (*Query, error) {
+	var q, err = parser.ParseString("", s)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.check(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// MustCompile is like Compile but panics on errors.
+func MustCompile(s string) *Query {
+	var q, err = Compile(s)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+func (q *Query) check() error {
+	if err := q.Test.check(); err != nil {
+		return err
+	}
+	return checkSteps(q.Test, q.Steps)
+}
+
+func (t *Test) check() error {
+	if !t.Any && nodeTypes[t.Type] == nil {
+		return fmt.Errorf("%s: unknown node type %s", t.Pos, t.Type)
+	}
+	return nil
+}
+
+// checkSteps checks steps that follow the type test t, if any.
+func checkSteps(t *Test, steps []*Step) error {
+	for _, step := range steps {
+		switch {
+		case step.Field != nil:
+			if t != nil && !t.Any {
+				if _, ok := nodeTypes[t.Type].FieldByName(step.Field.Name); !ok || step.Field.Name == "Pos" || step.Field.Name == "EndPos" {
+					var err = fmt.Errorf("%s: %s has no field %s", step.Field.Pos, t.Type, step.Field.Name)
+					if owners := fieldOwners(step.Field.Name); owners != "" {
+						err = fmt.Errorf("%w, unlike %s", err, owners)
+					}
+					return err
+				}
+			} else if !fieldNames[step.Field.Name] {
+				return fmt.Errorf("%s: no node has a field %s", step.Field.Pos, step.Field.Name)
+			}
+			t = nil
+		case step.Child != nil:
+			t = step.Child
+		case step.Descendant != nil:
+			t = step.Descendant
+		case step.Unwrap != nil:
+			t = step.Unwrap
+		case step.Filter != nil:
+			if err := step.Filter.check(); err != nil {
+				return err
+			}
+			continue
+		default:
+			continue
+		}
+		if t != nil {
+			if err := t.check(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (o *Or) check() error {
+	for _, and := range o.And {
+		for _, not := range and.Not {
+			if err := not.check(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (n *Not) check() error {
+	switch {
+	case n.Negated != nil:
+		return n.Negated.check()
+	case n.Group != nil:
+		return n.Group.check()
+	}
+
+	var c = n.Comparison
+	if c.Path.Test == nil && len(c.Path.Steps) == 0 {
+		return fmt.Errorf("%s: empty path", c.Pos)
+	}
+	if c.Path.Test != nil {
+		if err := c.Path.Test.check(); err != nil {
+			return err
+		}
+	}
+	if c.Operator == "~" {
+		var err error
+		if c.regexp, err = regexp.Compile(c.Value); err != nil {
+			return fmt.Errorf("%s: %s", c.Pos, err)
+		}
+	}
+	return checkSteps(c.Path.Test, c.Path.Steps)
+}
+
+// fieldOwners lists the node types having the named field.
+func fieldOwners(name string) string {
+	var owners []string
+	for typeName, t := range nodeTypes {
+		if _, ok := t.FieldByName(name); ok {
+			owners = append(owners, typeName)
+		}
+	}
+	sort.Strings(owners)
+	return strings.Join(owners, ", ")
+}
+
+var nodeInterface = reflect.TypeOf((*ast.Node)(nil)).Elem()
+
+// nodeTypes and fieldNames hold the node types reachable from a
+// translation unit and the names of their fields.
+var nodeTypes = map[string]reflect.Type{}
+var fieldNames = map[string]bool{}
+
+func init() {
+	var visit func(t reflect.Type)
+	visit = func(t reflect.Type) {
+		if nodeTypes[t.Name()] != nil {
+			return
+		}
+		nodeTypes[t.Name()] = t
+		for idx := 0; idx < t.NumField(); idx++ {
+			var field = t.Field(idx)
+			if !field.IsExported() || field.Name == "Pos" || field.Name == "EndPos" {
+				continue
+			}
+			fieldNames[field.Name] = true
+			var fieldType = field.Type
+			if fieldType.Kind() == reflect.Slice {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Implements(nodeInterface) {
+				visit(fieldType.Elem())
+			}
+		}
+	}
+	visit(reflect.TypeOf(ast.TranslationUnit{}))
+}
@@ -0,0 +1,87 @@
+package query
+
+import (
+	"fmt"
+	"lazarus-c/src/ast"
+	"reflect"
+	"testing"
+)
+
+const source = `int memcpy(void *, void *, int);
+struct s { int a[4]; };
+void f(struct s *p, struct s *q, int n) {
+	memcpy(p, q, sizeof(struct s));
+	memcpy(p->a, q->a, n * 4);
+	memcpy(p->a, q->a, sizeof p->a);
+	if (n)
+		return;
+	for (;;)
+		break;
+}`
+
+// queries lists queries with their matches in source, as
+// "line: text", each followed by its captures as "@name line: text".
+var queries = []struct {
+	query   string
+	matches []string
+}{
+	{"JumpStatement", []string{"8: return;", "10: break;"}},
+	{"JumpStatement[.IsBreak]", []string{"10: break;"}},
+	{"JumpStatement.IsReturn", []string{"8: true"}},
+	{"StructOrUnionSpecifier.Identifier", []string{"2: s", "3: s", "3: s", "4: s"}},
+	{"FunctionDefinition//ParameterDeclaration[.Declarator.DirectDeclarators.Identifier ~ \"^[pq]$\"]", []string{"3: struct s *p", "3: struct s *q"}},
+	{"FunctionDefinition/CompoundStatement/StatementList/Statement >JumpStatement", nil},
+	{"SelectionStatement.IfBody >JumpStatement", []string{"8: return;"}},
+	{
+		`PostfixExpression[.PrimaryExpression.Identifier = "memcpy"]@call
+		  .PostfixOperators[0][.IsCall].ArgumentExpressionList
+		  .AssignmentExpressions[2]@size
+		  [not >UnaryExpression[.SizeOfTypeName or .UnaryOperators = "sizeof"]]`,
+		[]string{"5: n * 4", "@call 5: memcpy(p->a, q->a, n * 4)", "@size 5: n * 4"},
+	},
+	{`PostfixExpression[.PrimaryExpression.Identifier != "memcpy" and .PostfixOperators.IdentifierPtrAccess = "a"]`, []string{"5: p->a", "5: q->a", "6: p->a", "6: q->a", "6: p->a"}},
+	{`*[.Identifier = "n"]`, []string{"3: n", "5: n", "7: n"}},
+}
+
+func TestFind(t *testing.T) {
+	var unit, err = ast.ParseString(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range queries {
+		var q, err = Compile(test.query)
+		if err != nil {
+			t.Errorf("%s: %v", test.query, err)
+			continue
+		}
+		var got []string
+		for _, match := range q.Find(unit) {
+			got = append(got, fmt.Sprintf("%d: %s", ast.Pos(match.Value.Node).Line, match.Value.Text()))
+			for _, capture := range match.Captures {
+				got = append(got, fmt.Sprintf("@%s %d: %s", capture.Name, ast.Pos(capture.Value.Node).Line, capture.Value.Text()))
+			}
+		}
+		if !reflect.DeepEqual(got, test.matches) {
+			t.Errorf("%s: found\n\t%q\ninstead of\n\t%q", test.query, got, test.matches)
+		}
+	}
+}
+
+var illegalQueries = []struct {
+	query   string
+	message string
+}{
+	{"Function", "1:1: unknown node type Function"},
+	{"JumpStatement.IfBody", "1:15: JumpStatement has no field IfBody, unlike SelectionStatement"},
+	{"*.Bogus", "1:3: no node has a field Bogus"},
+	{"*//Bogus", "1:4: unknown node type Bogus"},
+	{`*[.Identifier ~ "("]`, "1:3: error parsing regexp: missing closing ): `(`"},
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, test := range illegalQueries {
+		if _, err := Compile(test.query); err == nil || err.Error() != test.message {
+			t.Errorf("%s: got error %v instead of %q", test.query, err, test.message)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/diag"
+	"lazarus-c/src/interp"
+	"lazarus-c/src/sema"
+	"os"
+)
+
+func runCommand(args []string) error {
+	var flags = flag.NewFlagSet("run", flag.ExitOnError)
+	var checked = flags.Bool("checked", false, "stop at undefined behavior, such as out-of-bounds accesses, uses after free and signed overflow")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: lazarus run [-checked] file [arguments]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var name, src, err = readInput(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	unit, err := ast.ParseBytes(name, src)
+	if err != nil {
+		return err
+	}
+	var diags diag.List
+	var info = (&sema.Config{Source: src}).Check(unit, &diags)
+	// Warnings are left to lazarus check, so that they do not mix with
+	// the output of the program.
+	var errors diag.List
+	for _, d := range diags {
+		if d.Severity == diag.Error {
+			errors = append(errors, d)
+		}
+	}
+	if len(errors) > 0 {
+		errors.Sort()
+		errors.Print(os.Stderr)
+		return fmt.Errorf("%s has errors", name)
+	}
+
+	var stdout = bufio.NewWriter(os.Stdout)
+	var status int
+	status, err = (&interp.Config{Stdout: stdout, Args: flags.Args(), Checked: *checked}).Run(unit, info)
+	stdout.Flush()
+	if err != nil {
+		return err
+	}
+	if status != 0 {
+		os.Exit(status)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package sema
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/cfg"
+	"lazarus-c/src/types"
+)
+
+// accessKind tells how an expression accesses a variable.
+type accessKind int
+
+const (
+	// read uses the value of the variable.
+	read accessKind = iota
+	// assign stores a value with the = operator.
+	assign
+	// update stores a value computed from the previous one with a
+	// compound assignment, which reads it first.
+	update
+	// increment stores a value with ++ or --, which read it first.
+	increment
+	// initialize stores the value of the initializer of a declaration.
+	initialize
+	// declare reaches a declaration without initializer, after which
+	// the value of an automatic variable is indeterminate.
+	declare
+)
+
+// access is a read or write of a variable by a node of a control-flow
+// graph.
+type access struct {
+	kind accessKind
+	obj  *Object
+	// node is the identifier, or the init declarator of initialize and
+	// declare accesses.
+	node ast.Node
+}
+
+// accesses calls f for the variable accesses of the node n of a
+// control-flow graph, in evaluation order.
+func (c *checker) accesses(n ast.Node, f func(access)) {
+	var w = &accessWalker{info: c.info, f: f}
+	w.node(n)
+}
+
+type accessWalker struct {
+	info *Info
+	f    func(access)
+}
+
+func (w *accessWalker) emit(kind accessKind, obj *Object, n ast.Node) {
+	w.f(access{kind, obj, n})
+}
+
+func (w *accessWalker) node(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.InitDeclarator:
+		w.children(n)
+		if obj := w.info.Defs[ast.DeclaredName(n.Declarator)]; obj != nil {
+			if n.Initializer != nil {
+				w.emit(initialize, obj, n)
+			} else {
+				w.emit(declare, obj, n)
+			}
+		}
+
+	case *ast.AssignmentExpression:
+		w.node(n.ConditionalExpression)
+		for idx := len(n.UnaryExpressions) - 1; idx >= 0; idx-- {
+			var target = n.UnaryExpressions[idx]
+			var primary = identifierOperand(target)
+			switch {
+			case primary == nil || w.info.Uses[primary] == nil:
+				w.node(target)
+			case *n.AssignmentOperators[idx].AssignmentOperator == "=":
+				w.emit(assign, w.info.Uses[primary], primary)
+			default:
+				w.emit(read, w.info.Uses[primary], primary)
+				w.emit(update, w.info.Uses[primary], primary)
+			}
+		}
+
+	case *ast.LogicalOrExpression, *ast.LogicalAndExpression, *ast.ConditionalExpression:
+		if cfg.ShortCircuit(n) {
+			// The operands are nodes of their own, evaluated before n.
+			return
+		}
+		w.children(n)
+
+	case *ast.UnaryExpression:
+		for _, op := range n.UnaryOperators {
+			if op == "sizeof" {
+				// The operand of sizeof is not evaluated.
+				return
+			}
+		}
+		if n.SizeOfTypeName != nil {
+			return
+		}
+		w.children(n)
+		if len(n.UnaryOperators) > 0 {
+			// The operand of ++ and -- is read before being updated.
+			if primary := identifierOperand(n.PostfixExpression); primary != nil && w.info.Uses[primary] != nil {
+				w.emit(increment, w.info.Uses[primary], primary)
+			}
+		}
+
+	case *ast.PostfixExpression:
+		w.children(n)
+		if len(n.PostfixOperators) == 1 && n.PostfixOperators[0].Operator != nil {
+			if primary := identifierOperand(n.PrimaryExpression); primary != nil && w.info.Uses[primary] != nil {
+				w.emit(increment, w.info.Uses[primary], primary)
+			}
+		}
+
+	case *ast.PrimaryExpression:
+		if n.Identifier == nil {
+			w.children(n)
+		} else if obj := w.info.Uses[n]; obj != nil {
+			w.emit(read, obj, n)
+		}
+
+	default:
+		w.children(n)
+	}
+}
+
+func (w *accessWalker) children(n ast.Node) {
+	for _, child := range ast.Children(n) {
+		w.node(child)
+	}
+}
+
+// identifierOperand returns the identifier that the expression n merely
+// names, possibly parenthesized, or nil.
+func identifierOperand(n ast.Node) *ast.PrimaryExpression {
+	var primary, ok = operand(n).(*ast.PrimaryExpression)
+	if !ok || primary.Identifier == nil {
+		return nil
+	}
+	return primary
+}
+
+// addressTaken returns the variables of body whose address is taken with
+// the & operator.
+func addressTaken(info *Info, body *ast.CompoundStatement) map[*Object]bool {
+	var escaped = map[*Object]bool{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		var unary, ok = n.(*ast.UnaryExpression)
+		if ok && unary.UnaryOperatorOnCast != nil && *unary.UnaryOperatorOnCast.Operator == "&" {
+			if primary := identifierOperand(unary.CastExpression); primary != nil && info.Uses[primary] != nil {
+				escaped[info.Uses[primary]] = true
+			}
+		}
+		return true
+	})
+	return escaped
+}
+
+// tracked reports whether the flow analyses follow the values of obj: a
+// variable or parameter of scalar type with automatic storage, whose
+// address is not taken and that is not volatile.
+func tracked(obj *Object, escaped map[*Object]bool) bool {
+	if obj.Kind != Variable && obj.Kind != Parameter || obj.Scope == nil || obj.Scope.Kind == FileScope {
+		return false
+	}
+	var _, quals = types.Unqualified(obj.Type)
+	return obj.Storage != Static && obj.Storage != Extern && types.IsScalar(obj.Type) && quals&types.Volatile == 0 && !escaped[obj]
+}
@@ -0,0 +1,154 @@
+package sema
+
+import "lazarus-c/src/ast"
+
+// Format describes a function with a printf or scanf format parameter,
+// as given by the format attribute.
+type Format struct {
+	// Kind is printf or scanf.
+	Kind string
+	// Format is the index, from 1, of the format parameter, and First
+	// that of the first argument to check against it, or 0 when the
+	// arguments are passed as a va_list.
+	Format, First int
+}
+
+// knownAttributes are the names of the attributes of the dialect. The
+// checker handles those applying to the objects declared, and the typer
+// those applying to the layout of structures and fields. Each leaves the
+// attributes of the other alone, warning only about unknown names.
+var knownAttributes = map[string]bool{
+	"unused":  true,
+	"format":  true,
+	"nowarn":  true,
+	"packed":  true,
+	"aligned": true,
+}
+
+// declAttributes are the attributes of a declaration that apply to the
+// object declared.
+type declAttributes struct {
+	unused   bool
+	format   *Format
+	silenced []string
+}
+
+// apply sets the attributes of a on obj.
+func (a declAttributes) apply(obj *Object) {
+	if a.unused {
+		obj.Unused = true
+	}
+	if a.format != nil {
+		obj.Format = a.format
+	}
+	for _, name := range a.silenced {
+		if obj.Silenced == nil {
+			obj.Silenced = map[string]bool{}
+		}
+		obj.Silenced[name] = true
+	}
+}
+
+// objectWarnings are the warnings about an object that the nowarn
+// attribute of its declarations silences.
+var objectWarnings = map[string]bool{
+	"dead-store":          true,
+	"maybe-uninitialized": true,
+	"uninitialized":       true,
+	"unused-function":     true,
+	"unused-label":        true,
+	"unused-parameter":    true,
+	"unused-variable":     true,
+}
+
+// attributes checks the attributes of a declaration, adding those known
+// to attrs, and returns attrs.
+func (c *checker) attributes(n *ast.Attributes, attrs declAttributes) declAttributes {
+	if n == nil {
+		return attrs
+	}
+	for _, a := range n.Attributes {
+		switch *a.Name {
+		case "unused":
+			if len(a.Arguments) > 0 {
+				c.diags.Errorf(a.Pos, "attribute unused takes no arguments")
+			}
+			attrs.unused = true
+		case "format":
+			if format := c.formatAttribute(a); format != nil {
+				attrs.format = format
+			}
+		case "nowarn":
+			attrs.silenced = append(attrs.silenced, c.nowarnAttribute(a)...)
+		default:
+			if !knownAttributes[*a.Name] {
+				c.diags.Warnf(a.Pos, "attributes", "unknown attribute %s ignored", *a.Name)
+			}
+		}
+	}
+	return attrs
+}
+
+// specifierAttributes checks the attributes among the declaration
+// specifiers specs.
+func (c *checker) specifierAttributes(specs *ast.DeclarationSpecifiers) declAttributes {
+	var attrs declAttributes
+	for ; specs != nil; specs = specs.DeclarationSpecifiers {
+		attrs = c.attributes(specs.Attributes, attrs)
+	}
+	return attrs
+}
+
+// nowarnAttribute checks the arguments of the attribute nowarn("name",
+// ...) and returns the names of the warnings it silences.
+func (c *checker) nowarnAttribute(a *ast.Attribute) []string {
+	if len(a.Arguments) == 0 {
+		c.diags.Errorf(a.Pos, "attribute nowarn takes the names of warnings")
+		return nil
+	}
+	var names []string
+	for _, arg := range a.Arguments {
+		var literal = ast.StringLiteral(arg)
+		switch {
+		case literal == nil:
+			c.diags.Errorf(arg.Pos, "attribute nowarn takes the names of warnings as strings")
+		case !objectWarnings[*literal.StringLiteral]:
+			c.diags.Warnf(arg.Pos, "attributes", "attribute nowarn cannot silence warning %s", *literal.StringLiteral)
+		default:
+			names = append(names, *literal.StringLiteral)
+		}
+	}
+	return names
+}
+
+// formatAttribute checks the arguments of the attribute format(kind,
+// format, first).
+func (c *checker) formatAttribute(a *ast.Attribute) *Format {
+	if len(a.Arguments) != 3 {
+		c.diags.Errorf(a.Pos, "attribute format takes three arguments")
+		return nil
+	}
+	var kind = identifierOperand(a.Arguments[0])
+	if kind == nil || *kind.Identifier != "printf" && *kind.Identifier != "scanf" {
+		c.diags.Errorf(a.Arguments[0].Pos, "format kind must be printf or scanf")
+		return nil
+	}
+	var format = &Format{Kind: *kind.Identifier}
+	for idx, index := range []*int{&format.Format, &format.First} {
+		var arg = a.Arguments[idx+1]
+		var value, ok = c.constantExpression(arg)
+		if !ok {
+			return nil
+		}
+		if value < 0 || value == 0 && idx == 0 {
+			c.diags.Errorf(arg.Pos, "format argument index %d is out of range", value)
+			return nil
+		}
+		*index = int(value)
+	}
+	if format.First != 0 && format.First <= format.Format {
+		c.diags.Errorf(a.Arguments[2].Pos, "format arguments must follow the format string")
+		return nil
+	}
+	return format
+}
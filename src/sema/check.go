@@ -0,0 +1,703 @@
+package sema
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/diag"
+	"lazarus-c/src/types"
+	"sort"
+)
+
+// Info holds the results of the semantic analysis of a translation unit.
+type Info struct {
+	// Scope is the file scope.
+	Scope *Scope
+	// Defs maps the nodes declaring objects, as recorded in Object.Decl,
+	// to the objects. Later declarations of an object map to the object
+	// of the first one.
+	Defs map[ast.Node]*Object
+	// Uses maps identifier primary expressions and goto statements to
+	// the objects they refer to.
+	Uses map[ast.Node]*Object
+	// Scopes maps the nodes of Scope.Node to their scopes. The parameters
+	// and outermost declarations of a function share the scope of its
+	// compound statement.
+	Scopes map[ast.Node]*Scope
+	// Types maps expression nodes, and the postfix operators within
+	// them, to their types, the type names of casts to the result of
+	// their conversion, and those of sizeof to their type. Operands with
+	// errors are left out.
+	Types map[ast.Node]TypeAndValue
+	// Lengths maps the array types of variable length to the expression
+	// of their length.
+	Lengths map[*types.Array]*ast.ConstantExpression
+}
+
+// Warnings holds the names of every warning the checker reports, by
+// which they are enabled and disabled. Those of OptIn are disabled by
+// default.
+var Warnings = map[string]bool{
+	"attributes":                     true,
+	"compare-distinct-pointer-types": true,
+	"conversion":                     true,
+	"dead-store":                     true,
+	"discarded-qualifiers":           true,
+	"division-by-zero":               true,
+	"enum-range":                     true,
+	"excess-initializers":            true,
+	"format":                         true,
+	"implicit-function-declaration":  true,
+	"implicit-int":                   true,
+	"incompatible-pointer-types":     true,
+	"int-conversion":                 true,
+	"maybe-uninitialized":            true,
+	"old-style-definition":           true,
+	"overflow":                       true,
+	"pointer-arith":                  true,
+	"pointer-integer-compare":        true,
+	"return-type":                    true,
+	"shadow":                         true,
+	"shift-count-negative":           true,
+	"shift-count-overflow":           true,
+	"sign-compare":                   true,
+	"undefined-external":             true,
+	"uninitialized":                  true,
+	"unreachable-code":               true,
+	"unused-function":                true,
+	"unused-label":                   true,
+	"unused-parameter":               true,
+	"unused-variable":                true,
+	"void-ptr-dereference":           true,
+}
+
+// Config describes how to check a translation unit.
+type Config struct {
+	// Target sets the sizes of types. X86_64 is used when nil.
+	Target *types.Target
+	// Source is the text the unit was parsed from. When set, diagnostics
+	// inside string literals take their escape sequences into account.
+	Source []byte
+}
+
+type checker struct {
+	info  *Info
+	diags *diag.List
+	typer *Typer
+	file  *Scope
+	scope *Scope
+
+	target *types.Target
+	source []byte
+	// function is the function being defined, called name.
+	function *types.Function
+	name     string
+	// loops counts the loops enclosing the current statement, and
+	// switches lists the enclosing switch statements, innermost last.
+	loops    int
+	switches []*switchLabels
+	// constantContext is set while evaluating integer constant
+	// expressions.
+	constantContext bool
+	// unevaluated is set while checking operands which are not evaluated
+	// because the constant operand before them decides the value of the
+	// enclosing &&, || or ?: expression.
+	unevaluated bool
+	// evaluated holds the initializers checked ahead of their object.
+	evaluated map[*ast.Initializer]TypeAndValue
+}
+
+// Check analyses unit for the default target, reporting problems to
+// diags.
+func Check(unit *ast.TranslationUnit, diags *diag.List) *Info {
+	return (&Config{}).Check(unit, diags)
+}
+
+// Check analyses unit like the Check function, for the target of conf.
+func (conf *Config) Check(unit *ast.TranslationUnit, diags *diag.List) *Info {
+	var c = &checker{
+		info: &Info{
+			Defs:    map[ast.Node]*Object{},
+			Uses:    map[ast.Node]*Object{},
+			Scopes:  map[ast.Node]*Scope{},
+			Types:   map[ast.Node]TypeAndValue{},
+			Lengths: map[*types.Array]*ast.ConstantExpression{},
+		},
+		diags:     diags,
+		target:    conf.Target,
+		source:    conf.Source,
+		evaluated: map[*ast.Initializer]TypeAndValue{},
+	}
+	if c.target == nil {
+		c.target = types.X86_64
+	}
+	c.typer = &Typer{
+		Tags:        c,
+		Target:      c.target,
+		Constant:    c.constantExpression,
+		Length:      c.arrayLength,
+		Variable:    c.variableArray,
+		Enumerator:  c.enumerator,
+		Prototype:   c.prototype,
+		Parameter:   c.parameter,
+		Diagnostics: diags,
+	}
+	c.file = c.openScope(FileScope, unit)
+	c.info.Scope = c.file
+
+	for _, ed := range unit.ExternalDeclarations {
+		if ed.FunctionDefinition != nil {
+			c.functionDefinition(ed.FunctionDefinition)
+		} else {
+			c.declaration(ed.Declaration)
+		}
+	}
+	c.tentativeDefinitions()
+	c.unused()
+	return c.info
+}
+
+func (c *checker) openScope(kind ScopeKind, n ast.Node) *Scope {
+	c.scope = newScope(kind, c.scope, n)
+	c.info.Scopes[n] = c.scope
+	return c.scope
+}
+
+func (c *checker) closeScope() {
+	c.scope = c.scope.Parent
+}
+
+func (c *checker) LookupTag(tag string, local bool) types.Type {
+	var obj = c.scope.Tags[tag]
+	if !local && obj == nil {
+		obj = c.scope.LookupTag(tag)
+	}
+	if obj == nil {
+		return nil
+	}
+	return obj.Type
+}
+
+func (c *checker) DeclareTag(tag string, t types.Type, n ast.Node) {
+	var obj = &Object{Kind: Tag, Name: tag, Type: t, Pos: ast.Pos(n), Decl: n}
+	c.scope.insert(obj)
+	c.info.Defs[n] = obj
+}
+
+// enumerator declares an enumeration constant. Its type is int, unless
+// its value does not fit, or the enumeration has a fixed underlying type
+// as in C23, where constants have the type of the enumeration.
+func (c *checker) enumerator(n *ast.Enumerator, constant *types.EnumConstant, enum *types.Enum) {
+	var typ types.Type = types.Typ[types.Int]
+	switch {
+	case enum.Fixed:
+		typ = enum
+	case !fits(c.target, constant.Value, typ):
+		typ = types.Typ[types.Long]
+	}
+	var obj = &Object{
+		Kind:    EnumConstant,
+		Name:    constant.Name,
+		Type:    typ,
+		Pos:     n.Pos,
+		Decl:    n,
+		Defined: true,
+		Value:   constant.Value,
+	}
+	c.info.Defs[n] = c.declare(obj)
+}
+
+// prototype opens the scope of the parameters of a function declarator.
+func (c *checker) prototype(n *ast.DeclaratorSuffix) func([]*types.Param) {
+	c.openScope(PrototypeScope, n)
+	return func([]*types.Param) {
+		c.closeScope()
+	}
+}
+
+// parameter declares a parameter in the scope of its prototype.
+func (c *checker) parameter(n *ast.ParameterDeclaration, param *types.Param) {
+	var direct = ast.DeclaredName(n.Declarator)
+	var obj = &Object{Kind: Parameter, Name: param.Name, Type: param.Type, Pos: direct.Pos, Decl: direct}
+	if previous := c.scope.Objects[obj.Name]; previous != nil {
+		c.diags.Errorf(obj.Pos, "redefinition of parameter %s", obj.Name).
+			Note(previous.Pos, "previous declaration of %s is here", obj.Name)
+		return
+	}
+	c.scope.insert(obj)
+	c.info.Defs[direct] = obj
+}
+
+// declare adds obj to the current scope, or merges it with an earlier
+// declaration of the same entity, which is then returned.
+func (c *checker) declare(obj *Object) *Object {
+	var previous = c.scope.Objects[obj.Name]
+	obj.Scope = c.scope
+	if previous == nil {
+		if obj.Linkage() && c.scope != c.file {
+			if outer := c.file.Objects[obj.Name]; outer != nil && outer.Linkage() && !types.Compatible(outer.Type, obj.Type) {
+				c.conflict(obj, outer)
+			}
+		}
+		c.shadow(obj)
+		c.scope.insert(obj)
+		return obj
+	}
+
+	switch {
+	case previous.Kind != obj.Kind && (previous.Linkage() || obj.Linkage() || previous.Kind == EnumConstant || obj.Kind == EnumConstant):
+		c.diags.Errorf(obj.Pos, "%s redeclared as a different kind of symbol", obj.Name).
+			Note(previous.Pos, "previous declaration of %s is here", obj.Name)
+	case !previous.Linkage() || !obj.Linkage():
+		c.diags.Errorf(obj.Pos, "redefinition of %s", obj.Name).
+			Note(previous.Pos, "previous definition of %s is here", obj.Name)
+	case !types.Compatible(previous.Type, obj.Type):
+		c.conflict(obj, previous)
+	case obj.Defined && previous.Defined:
+		c.diags.Errorf(obj.Pos, "redefinition of %s", obj.Name).
+			Note(previous.Pos, "previous definition of %s is here", obj.Name)
+	default:
+		if obj.Storage == Static && previous.Storage != Static {
+			c.diags.Errorf(obj.Pos, "static declaration of %s follows non-static declaration", obj.Name).
+				Note(previous.Pos, "previous declaration of %s is here", obj.Name)
+		}
+		previous.Type = types.Composite(previous.Type, obj.Type)
+		previous.Defined = previous.Defined || obj.Defined
+	}
+	return previous
+}
+
+func (c *checker) conflict(obj, previous *Object) {
+	c.diags.Errorf(obj.Pos, "conflicting types for %s: %s", obj.Name, types.Declaration(obj.Type, obj.Name)).
+		Note(previous.Pos, "previous declaration is %s", types.Declaration(previous.Type, previous.Name))
+}
+
+// shadow warns when a declaration in a block hides another one.
+func (c *checker) shadow(obj *Object) {
+	if c.scope.Kind != BlockScope || obj.Linkage() {
+		return
+	}
+	var outer = c.scope.Parent.Lookup(obj.Name)
+	if outer == nil {
+		return
+	}
+	var what = "previous local"
+	switch {
+	case outer.Kind == Parameter:
+		what = "parameter"
+	case outer.Scope == c.file:
+		what = "global declaration"
+	}
+	c.diags.Warnf(obj.Pos, "shadow", "declaration of %s shadows a %s", obj.Name, what).
+		Note(outer.Pos, "shadowed declaration is here")
+}
+
+func (c *checker) declaration(n *ast.Declaration) {
+	var base, storage = c.typer.Specifiers(n.DeclarationSpecifiers)
+	var attrs = c.specifierAttributes(n.DeclarationSpecifiers)
+	if n.InitDeclaratorList == nil {
+		return
+	}
+	for _, d := range n.InitDeclaratorList.InitDeclarators {
+		var name, typ = c.typer.Declarator(base, d.Declarator)
+		var direct = ast.DeclaredName(d.Declarator)
+		var obj = &Object{Kind: Variable, Name: name, Type: typ, Storage: storage, Pos: direct.Pos, Decl: direct}
+
+		if _, ok := typ.(*types.Function); ok {
+			obj.Kind = Function
+			if storage == Auto || storage == Register || storage == Static && c.scope != c.file {
+				c.diags.Errorf(obj.Pos, "invalid storage class for function %s", name)
+			}
+			if d.Initializer != nil {
+				c.diags.Errorf(d.Initializer.Pos, "function %s is initialized like a variable", name)
+			}
+		} else {
+			obj.Defined = storage != Extern && (c.scope != c.file || d.Initializer != nil)
+			switch {
+			case c.scope == c.file && (storage == Auto || storage == Register):
+				c.diags.Errorf(obj.Pos, "invalid storage class for file-scope variable %s", name)
+			case c.scope != c.file && storage == Extern && d.Initializer != nil:
+				c.diags.Errorf(obj.Pos, "extern variable %s has an initializer", name)
+			case types.IsVoid(typ):
+				c.diags.Errorf(obj.Pos, "variable %s declared void", name)
+			case c.scope != c.file && (storage == Static || storage == Extern) && variableLength(typ):
+				c.diags.Errorf(obj.Pos, "variable length array %s cannot have static storage duration", name)
+			case d.Initializer != nil && variableLength(typ):
+				c.diags.Errorf(d.Initializer.Pos, "variable length array %s cannot be initialized", name)
+			case obj.Defined && c.scope != c.file && !types.IsComplete(typ) && !(isUnsizedArray(typ) && d.Initializer != nil):
+				c.diags.Errorf(obj.Pos, "variable %s has incomplete type %s", name, typ)
+			}
+		}
+
+		var declared = c.declare(obj)
+		c.info.Defs[direct] = declared
+		c.attributes(d.Attributes, attrs).apply(declared)
+		if d.Initializer != nil && obj.Kind == Variable {
+			var t = c.initialize(typ, d.Initializer)
+			if isUnsizedArray(declared.Type) {
+				declared.Type = t
+			}
+		}
+	}
+}
+
+func isUnsizedArray(t types.Type) bool {
+	var array, ok = t.(*types.Array)
+	return ok && array.Kind == types.Unsized
+}
+
+// tentativeDefinitions checks that the variables defined at file scope
+// without an initializer have a complete type by the end of the file.
+func (c *checker) tentativeDefinitions() {
+	var objects []*Object
+	for _, obj := range c.file.Objects {
+		if obj.Kind == Variable && obj.Storage != Extern && !types.IsComplete(obj.Type) && !isUnsizedArray(obj.Type) {
+			objects = append(objects, obj)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Pos.Offset < objects[j].Pos.Offset })
+	for _, obj := range objects {
+		c.diags.Errorf(obj.Pos, "storage size of %s is not known", obj.Name)
+	}
+}
+
+func (c *checker) functionDefinition(n *ast.FunctionDefinition) {
+	var base types.Type = types.Typ[types.Int]
+	var storage = NoStorage
+	var attrs declAttributes
+	if n.DeclarationSpecifiers != nil {
+		base, storage = c.typer.Specifiers(n.DeclarationSpecifiers)
+		attrs = c.specifierAttributes(n.DeclarationSpecifiers)
+	} else {
+		c.diags.Warnf(n.Pos, "implicit-int", "return type defaults to int")
+	}
+	var name, typ = c.typer.Declarator(base, n.Declarator)
+	var direct = ast.DeclaredName(n.Declarator)
+	var fn, ok = typ.(*types.Function)
+	if !ok {
+		c.diags.Errorf(direct.Pos, "%s is defined like a function but has type %s", name, typ)
+		fn = &types.Function{Result: typ}
+	}
+	if storage == Auto || storage == Register {
+		c.diags.Errorf(direct.Pos, "invalid storage class for function %s", name)
+	}
+	var obj = &Object{Kind: Function, Name: name, Type: fn, Storage: storage, Pos: direct.Pos, Decl: direct, Defined: true}
+	c.info.Defs[direct] = c.declare(obj)
+	attrs.apply(c.info.Defs[direct])
+
+	c.openScope(FunctionScope, n)
+	c.labels(n.CompoundStatement)
+	c.openScope(BlockScope, n.CompoundStatement)
+	c.parameters(n, fn)
+	c.function, c.name = fn, name
+	var reported = len(*c.diags)
+	c.compoundStatement(n.CompoundStatement)
+	// The flow of a body with errors, like a misplaced break, is not
+	// worth analysing.
+	if !(*c.diags)[reported:].HasErrors() {
+		c.flow(n)
+	}
+	c.function, c.name = nil, ""
+	c.closeScope()
+	c.closeScope()
+}
+
+// parameters declares the parameters of the function defined by n in the
+// scope of its body.
+func (c *checker) parameters(n *ast.FunctionDefinition, fn *types.Function) {
+	var suffix = ast.FunctionSuffix(n.Declarator)
+	if suffix == nil {
+		return
+	}
+	if suffix.ParameterTypeList == nil {
+		c.diags.Warnf(suffix.Pos, "old-style-definition", "old-style definition of function %s", *ast.DeclaredName(n.Declarator).Identifier)
+	}
+	if suffix.IdentifierList != nil || n.DeclarationList != nil {
+		c.oldStyleParameters(n, suffix)
+		return
+	}
+	if suffix.ParameterTypeList == nil {
+		return
+	}
+
+	for idx, decl := range suffix.ParameterTypeList.ParameterList.ParameterDeclarations {
+		if idx >= len(fn.Params) {
+			break
+		}
+		if decl.Declarator == nil {
+			c.diags.Errorf(decl.Pos, "parameter name omitted")
+			continue
+		}
+		// The parameter declared in the scope of the prototype, which the
+		// lengths of the arrays of variable length of the next ones refer
+		// to, becomes that of the body.
+		var direct = ast.DeclaredName(decl.Declarator)
+		var obj = c.info.Defs[direct]
+		if obj == nil {
+			obj = &Object{Kind: Parameter, Name: fn.Params[idx].Name, Pos: direct.Pos, Decl: direct}
+		}
+		obj.Type, obj.Defined = fn.Params[idx].Type, true
+		c.attributes(decl.Attributes, c.specifierAttributes(decl.DeclarationSpecifiers)).apply(obj)
+		if c.scope.Objects[obj.Name] == nil {
+			c.shadow(obj)
+			c.scope.insert(obj)
+			c.info.Defs[direct] = obj
+		}
+	}
+}
+
+// oldStyleParameters declares the parameters of a definition listing
+// their names, with types given by the declarations that follow.
+func (c *checker) oldStyleParameters(n *ast.FunctionDefinition, suffix *ast.DeclaratorSuffix) {
+	if suffix.ParameterTypeList != nil {
+		c.diags.Errorf(n.DeclarationList.Pos, "old-style parameter declarations in prototyped function definition")
+		return
+	}
+
+	var declared = map[string]*Object{}
+	if n.DeclarationList != nil {
+		for _, decl := range n.DeclarationList.Declarations {
+			var base, storage = c.typer.Specifiers(decl.DeclarationSpecifiers)
+			var attrs = c.specifierAttributes(decl.DeclarationSpecifiers)
+			if storage != NoStorage && storage != Register {
+				c.diags.Errorf(decl.Pos, "invalid storage class for parameter")
+			}
+			if decl.InitDeclaratorList == nil {
+				continue
+			}
+			for _, d := range decl.InitDeclaratorList.InitDeclarators {
+				var name, typ = c.typer.Declarator(base, d.Declarator)
+				var direct = ast.DeclaredName(d.Declarator)
+				if d.Initializer != nil {
+					c.diags.Errorf(d.Initializer.Pos, "parameter %s is initialized", name)
+				}
+				if previous := declared[name]; previous != nil {
+					c.diags.Errorf(direct.Pos, "redefinition of parameter %s", name).
+						Note(previous.Pos, "previous declaration of %s is here", name)
+					continue
+				}
+				declared[name] = &Object{Kind: Parameter, Name: name, Type: AdjustParameter(typ), Pos: direct.Pos, Decl: direct, Defined: true}
+				c.attributes(d.Attributes, attrs).apply(declared[name])
+			}
+		}
+	}
+
+	var names []string
+	if suffix.IdentifierList != nil {
+		names = suffix.IdentifierList.Identifiers
+	}
+	var listed = map[string]bool{}
+	for _, name := range names {
+		if listed[name] {
+			c.diags.Errorf(suffix.IdentifierList.Pos, "redefinition of parameter %s", name)
+			continue
+		}
+		listed[name] = true
+		var obj = declared[name]
+		if obj == nil {
+			c.diags.Warnf(suffix.IdentifierList.Pos, "implicit-int", "type of %s defaults to int", name)
+			obj = &Object{Kind: Parameter, Name: name, Type: types.Typ[types.Int], Pos: suffix.IdentifierList.Pos, Decl: suffix.IdentifierList, Defined: true}
+		}
+		c.shadow(obj)
+		c.scope.insert(obj)
+		c.info.Defs[obj.Decl] = obj
+	}
+
+	for name, obj := range declared {
+		if !listed[name] {
+			c.diags.Errorf(obj.Pos, "declaration for parameter %s but no such parameter", name)
+		}
+	}
+}
+
+// compoundStatement checks the declarations and statements of n in the
+// current scope.
+func (c *checker) compoundStatement(n *ast.CompoundStatement) {
+	if n.DeclarationList != nil {
+		for _, decl := range n.DeclarationList.Declarations {
+			c.declaration(decl)
+		}
+	}
+	if n.StatementList != nil {
+		for _, s := range n.StatementList.Statements {
+			c.statement(s)
+		}
+	}
+}
+
+func (c *checker) statement(n *ast.Statement) {
+	switch {
+	case n.LabeledStatement != nil:
+		var s = n.LabeledStatement
+		switch {
+		case s.GotoLabel != nil:
+			c.statement(s.GotoStatement)
+		case s.CaseExpression != nil:
+			c.caseLabel(s)
+			c.statement(s.CaseStatement)
+		default:
+			c.defaultLabel(s)
+			c.statement(s.DefaultStatement)
+		}
+	case n.CompoundStatement != nil:
+		c.openScope(BlockScope, n.CompoundStatement)
+		c.compoundStatement(n.CompoundStatement)
+		c.closeScope()
+	case n.ExpressionStatement != nil:
+		c.optionalExpression(n.ExpressionStatement.Expression)
+	case n.SelectionStatement != nil:
+		var s = n.SelectionStatement
+		if s.SwitchExpression != nil {
+			var x = value(c.expression(s.SwitchExpression))
+			if x.valid() && !types.IsInteger(x.Type) {
+				c.diags.Errorf(s.SwitchExpression.Pos, "switch quantity has type %s, not an integer", x.Type)
+			}
+			var labels = &switchLabels{cases: map[int64]*ast.LabeledStatement{}}
+			if types.IsInteger(x.Type) {
+				labels.typ = types.IntegerPromotion(x.Type)
+			}
+			c.switches = append(c.switches, labels)
+			c.statement(s.SwitchBody)
+			c.switches = c.switches[:len(c.switches)-1]
+			return
+		}
+		c.test(s.IfTest)
+		c.statement(s.IfBody)
+		if s.ElseBody != nil {
+			c.statement(s.ElseBody)
+		}
+	case n.IterationStatement != nil:
+		var s = n.IterationStatement
+		switch {
+		case s.WhileTest != nil:
+			c.test(s.WhileTest)
+			c.loopBody(s.WhileBody)
+		case s.DoBody != nil:
+			c.loopBody(s.DoBody)
+			c.test(s.DoTest)
+		default:
+			c.optionalExpression(s.ForInit.Expression)
+			if s.ForTest.Expression != nil {
+				c.test(s.ForTest.Expression)
+			}
+			c.optionalExpression(s.ForUpdate)
+			c.loopBody(s.ForBody)
+		}
+	case n.JumpStatement != nil:
+		var s = n.JumpStatement
+		switch {
+		case s.GotoIdent != nil:
+			c.gotoStatement(s)
+		case s.IsBreak:
+			if c.loops == 0 && len(c.switches) == 0 {
+				c.diags.Errorf(s.Pos, "break statement not within a loop or switch")
+			}
+		case s.IsContinue:
+			if c.loops == 0 {
+				c.diags.Errorf(s.Pos, "continue statement not within a loop")
+			}
+		case s.IsReturn:
+			c.returnStatement(s)
+		}
+	}
+}
+
+func (c *checker) optionalExpression(n *ast.Expression) {
+	if n != nil {
+		c.expression(n)
+	}
+}
+
+// test checks the controlling expression of an if or a loop.
+func (c *checker) test(n *ast.Expression) {
+	c.condition(n, c.expression(n))
+}
+
+// returnStatement checks the value returned by n against the result type
+// of the function.
+func (c *checker) returnStatement(n *ast.JumpStatement) {
+	var result = c.function.Result
+	if n.ReturnExpression == nil {
+		if !types.IsVoid(result) {
+			c.diags.Warnf(n.Pos, "return-type", "non-void function %s should return a value", c.name)
+		}
+		return
+	}
+	var x = c.expression(n.ReturnExpression)
+	switch {
+	case types.IsVoid(result):
+		if x.valid() && !types.IsVoid(x.Type) {
+			c.diags.Errorf(n.ReturnExpression.Pos, "void function %s should not return a value", c.name)
+		}
+	default:
+		c.assignable(n.ReturnExpression, result, x, "returning %[2]s from a function with result type %[1]s")
+	}
+}
+
+// use resolves the identifier of n.
+func (c *checker) use(n *ast.PrimaryExpression) *Object {
+	var obj = c.scope.Lookup(*n.Identifier)
+	if obj == nil {
+		c.diags.Errorf(n.Pos, "use of undeclared identifier %s", *n.Identifier)
+		// Declare it to only report the first use in the block.
+		obj = &Object{Kind: Variable, Name: *n.Identifier, Type: types.Typ[types.Int], Pos: n.Pos, Decl: n}
+		c.scope.insert(obj)
+	}
+	c.info.Uses[n] = obj
+	return obj
+}
+
+// libraryFunctions holds the prototypes of the functions of the C library
+// that programs may call without defining them, which have no header to
+// declare them.
+var libraryFunctions = map[string]*types.Function{
+	"printf": {
+		Result:    types.Typ[types.Int],
+		Params:    []*types.Param{{Name: "format", Type: &types.Pointer{Elem: types.Qualify(types.Typ[types.Char], types.Const)}}},
+		Prototype: true,
+		Variadic:  true,
+	},
+	"malloc": {
+		Result:    &types.Pointer{Elem: types.Typ[types.Void]},
+		Params:    []*types.Param{{Name: "size", Type: types.Typ[types.UnsignedLong]}},
+		Prototype: true,
+	},
+	"free": {
+		Result:    types.Typ[types.Void],
+		Params:    []*types.Param{{Name: "ptr", Type: &types.Pointer{Elem: types.Typ[types.Void]}}},
+		Prototype: true,
+	},
+	"memcpy": {
+		Result: &types.Pointer{Elem: types.Typ[types.Void]},
+		Params: []*types.Param{
+			{Name: "dest", Type: &types.Pointer{Elem: types.Typ[types.Void]}},
+			{Name: "src", Type: &types.Pointer{Elem: types.Qualify(types.Typ[types.Void], types.Const)}},
+			{Name: "n", Type: types.Typ[types.UnsignedLong]},
+		},
+		Prototype: true,
+	},
+	"strlen": {
+		Result:    types.Typ[types.UnsignedLong],
+		Params:    []*types.Param{{Name: "s", Type: &types.Pointer{Elem: types.Qualify(types.Typ[types.Char], types.Const)}}},
+		Prototype: true,
+	},
+}
+
+// implicitFunction declares a function called without a declaration, as
+// C89 does, with the type int(), or with its prototype for the functions
+// of the library.
+func (c *checker) implicitFunction(n *ast.PrimaryExpression) {
+	var typ = &types.Function{Result: types.Typ[types.Int]}
+	var d = c.diags.Warnf(n.Pos, "implicit-function-declaration", "implicit declaration of function %s", *n.Identifier)
+	if library := libraryFunctions[*n.Identifier]; library != nil {
+		typ = library
+		d.Note(n.Pos, "the prototype of the library function %s is used", types.Declaration(library, *n.Identifier))
+	}
+	var obj = &Object{
+		Kind:    Function,
+		Name:    *n.Identifier,
+		Type:    typ,
+		Storage: Extern,
+		Pos:     n.Pos,
+		Decl:    n,
+	}
+	c.file.insert(obj)
+}
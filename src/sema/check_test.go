@@ -0,0 +1,420 @@
+package sema
+
+import (
+	"fmt"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/diag"
+	"reflect"
+	"testing"
+)
+
+// diagnostics checks src and returns its diagnostics, sorted, as
+// "line:column: message", with the name of the warnings, opt-in ones
+// included.
+func diagnostics(t *testing.T, src string) []string {
+	t.Helper()
+	var unit, err = ast.ParseString(src)
+	if err != nil {
+		t.Fatalf("parsing %q: %v", src, err)
+	}
+	var diags diag.List
+	(&Config{Source: []byte(src)}).Check(unit, &diags)
+	diags.Sort()
+	var messages []string
+	for _, d := range diags {
+		var message = fmt.Sprintf("%d:%d: %s", d.Pos.Line, d.Pos.Column, d.Message)
+		if d.Name != "" {
+			message += " [-W" + d.Name + "]"
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// checks lists programs with the diagnostics they get.
+var checks = []struct {
+	name  string
+	src   string
+	diags []string
+}{
+	{
+		name: "old-style definitions",
+		src: `int f(a, p) char *p; { return a + *p; }
+int g() { return 0; }
+int h(void) { return g(); }`,
+		diags: []string{
+			"1:6: old-style definition of function f [-Wold-style-definition]",
+			"1:7: type of a defaults to int [-Wimplicit-int]",
+			"2:6: old-style definition of function g [-Wold-style-definition]",
+		},
+	},
+	{
+		name: "bit-field width",
+		src:  "struct F { int x : 33; unsigned y : 32; char c : 9; };",
+		diags: []string{
+			"1:20: width of bit-field exceeds width of its type",
+			"1:50: width of bit-field exceeds width of its type",
+		},
+	},
+	{
+		name: "shift count in constant expressions",
+		src:  "int a[1 << 40]; int b[1 >> -1]; int c[1 << 31 >> 30]; int d[0 && 1 << 40];",
+		diags: []string{
+			"1:7: shift count 40 >= width of type int in constant expression",
+			"1:23: shift count is negative in constant expression",
+			"1:39: integer overflow in expression of type int results in -2147483648 [-Woverflow]",
+			"1:39: array has negative size",
+		},
+	},
+	{
+		name: "attributes",
+		src: `int x __attribute__((aligned(8))), y __attribute__((bogus));
+struct s { int a __attribute__((unused)), b __attribute__((packed, bogus)); } __attribute__((aligned(4)));
+static int f(void) __attribute__((unused));
+static int f(void) { return 0; }`,
+		diags: []string{
+			"1:53: unknown attribute bogus ignored [-Wattributes]",
+			"2:68: unknown attribute bogus ignored [-Wattributes]",
+		},
+	},
+	{
+		name: "nowarn attribute",
+		src: `int g(int);
+static int f(int c, int p) __attribute__((nowarn("unused-function")));
+static int f(int c, int p __attribute__((nowarn("unused-parameter")))) {
+	int x __attribute__((nowarn("maybe-uninitialized", "dead-store"))), y __attribute__((nowarn("dead-store")));
+	int z __attribute__((nowarn("shadow", "bogus"))), w;
+	if (c)
+		x = 1;
+	y = g(x);
+	z = g(1);
+	w = g(2);
+	return 0;
+}
+int v __attribute__((nowarn(1)));`,
+		diags: []string{
+			"5:30: attribute nowarn cannot silence warning shadow [-Wattributes]",
+			"5:40: attribute nowarn cannot silence warning bogus [-Wattributes]",
+			"9:2: value stored to z is never read [-Wdead-store]",
+			"10:2: value stored to w is never read [-Wdead-store]",
+			"13:29: attribute nowarn takes the names of warnings as strings",
+		},
+	},
+	{
+		name: "format arguments",
+		src: `int printf(const char *, ...);
+int scanf(const char *, ...);
+void f(char c, short s, float x) {
+	char a[2];
+	printf("%d %s %f", a, c, x);
+	printf("%s %ld", s, a);
+	scanf("%d", a);
+}`,
+		diags: []string{
+			"5:10: format specifies type int but the argument has type char * [-Wformat]",
+			"5:13: format specifies type char * but the argument has type int [-Wformat]",
+			"6:10: format specifies type char * but the argument has type int [-Wformat]",
+			"6:13: format specifies type long but the argument has type char * [-Wformat]",
+			"7:9: format specifies type int * but the argument has type char * [-Wformat]",
+		},
+	},
+	{
+		name: "uninitialized variables",
+		src: `int g(void);
+int f(int c) {
+	int x, y, z, w, v, u, *p = &u;
+	c ? (x = 1) : (x = 2);
+	c && (y = 1);
+	if (c || (z = g()))
+		;
+	if ((w = g()) > 0 && c)
+		v = w;
+	return x + y + z + w + v + u + *p;
+}
+int h(void) { int t; return t; }`,
+		diags: []string{
+			"10:13: variable y may be used uninitialized [-Wmaybe-uninitialized]",
+			"10:17: variable z may be used uninitialized [-Wmaybe-uninitialized]",
+			"10:25: variable v may be used uninitialized [-Wmaybe-uninitialized]",
+			"12:29: variable t is used uninitialized [-Wuninitialized]",
+		},
+	},
+	{
+		name: "dead stores",
+		src: `int g(int);
+int f(int c) {
+	int x = g(c), y;
+	c ? (y = 1) : (y = 2);
+	x = 3;
+	c && (x = g(x));
+	y = c ? y : 0;
+	return x;
+}`,
+		diags: []string{
+			"3:6: value stored to x is never read [-Wdead-store]",
+			"7:2: value stored to y is never read [-Wdead-store]",
+		},
+	},
+	{
+		name: "names and scopes",
+		src: `int g(int);
+int g(long);
+int x;
+struct s { int a; };
+int f(int p) {
+	int x = p;
+	struct s s;
+	{
+		int p = 1;
+		x += p + s.a;
+	}
+s:
+	goto s;
+	return y + h(x);
+}
+int f(int p);
+double x;
+union s *u;
+int k(void) { int a; char a; goto out; return a; }
+int k(void) { return 0; }`,
+		diags: []string{
+			"2:5: conflicting types for g: int g(long)",
+			"6:6: declaration of x shadows a global declaration [-Wshadow]",
+			"9:7: declaration of p shadows a parameter [-Wshadow]",
+			"14:9: use of undeclared identifier y",
+			"14:13: implicit declaration of function h [-Wimplicit-function-declaration]",
+			"17:8: conflicting types for x: double x",
+			"18:1: union s redeclared as a different kind of tag",
+			"19:27: redefinition of a",
+			"19:30: use of undeclared label out",
+			"20:5: redefinition of k",
+		},
+	},
+	{
+		name: "operands and arguments",
+		src: `struct s { int a; } v;
+int g(int, char *);
+void f(int *p, double d) {
+	int *q;
+	long n;
+	n = p - q;
+	q = p + d;
+	n = v + 1;
+	n = d % 2;
+	n = p * 2;
+	q = n;
+	g(1);
+	g(1, 2, 3);
+	g(v, "x");
+	n = v.b;
+	n = p->a;
+	n = d[1];
+	n = (*g)(1, "y");
+	n = 3();
+}`,
+		diags: []string{
+			"7:6: invalid operands to + (have int * and double)",
+			"8:6: invalid operands to + (have struct s and int)",
+			"9:6: invalid operands to % (have double and int)",
+			"10:6: invalid operands to * (have int * and int)",
+			"11:6: incompatible integer to pointer conversion assigning to int * from long [-Wint-conversion]",
+			"12:3: too few arguments to g, expected 2, have 1",
+			"13:3: too many arguments to g, expected 2, have 3",
+			"13:7: incompatible integer to pointer conversion passing int to parameter 2 of g of type char * [-Wint-conversion]",
+			"14:4: incompatible types passing struct s to parameter 1 of g of type int",
+			"15:7: no member named b in struct s",
+			"16:7: member reference base type int is not a structure or union",
+			"17:7: subscripted value of type double is not an array or pointer",
+			"19:7: called object type int is not a function or function pointer",
+		},
+	},
+	{
+		name: "enumerations",
+		src: `enum e { A, B = 5, C, D = -1, E };
+enum f { A = 1, F = C + 1 };
+enum g : unsigned char { G = 255, H };
+enum h : float { I };
+enum i { J = 4294967296, K };
+int a[C == 6 && E == 0 && F == 7 ? 1 : -1];
+int b[sizeof(enum g) == 1 && sizeof(enum i) == 8 && sizeof(enum e) == 4 ? 1 : -1];
+enum e;
+enum g : signed char { Z };`,
+		diags: []string{
+			"2:10: redefinition of A",
+			"3:35: enumerator value 256 of H is outside the range of underlying type unsigned char",
+			"4:10: invalid underlying type float of enumeration",
+			"5:14: enumerator value 4294967296 of J is outside the range of int [-Wenum-range]",
+			"5:26: enumerator value 4294967297 of K is outside the range of int [-Wenum-range]",
+			"9:1: redefinition of enum g",
+		},
+	},
+	{
+		name: "jumps and labels",
+		src: `int f(int x) {
+	break;
+	continue;
+	case 1: ;
+	default: ;
+	switch (x) {
+	case 1:
+	case 2:
+	case 1:
+		continue;
+	default:
+	default:
+		break;
+	}
+	while (x) {
+		switch (x) {
+		case 3:
+			continue;
+		}
+		break;
+	}
+	goto end;
+	goto nowhere;
+end:
+end:
+	return x;
+}
+int g(void) { goto end; return 0; }`,
+		diags: []string{
+			"2:2: break statement not within a loop or switch",
+			"3:2: continue statement not within a loop",
+			"4:2: case label not within a switch statement",
+			"5:2: default label not within a switch statement",
+			"9:7: duplicate case value 1",
+			"10:3: continue statement not within a loop",
+			"12:2: multiple default labels in one switch",
+			"23:2: use of undeclared label nowhere",
+			"25:1: redefinition of label end",
+			"28:15: use of undeclared label end",
+		},
+	},
+	{
+		name: "missing returns and unreachable code",
+		src: `int g(int);
+int a(int x) { if (x) return 1; }
+int b(int x) { for (;;) if (g(x)) return 1; }
+int c(int x) { while (1) { if (x) break; } }
+int d(int x) { switch (x) { case 1: return 1; default: return 0; } }
+int e(int x) { switch (x) { case 1: return 1; } }
+void f(int x) { return; x++; }
+int h(int x) {
+	while (x) {
+		break;
+		x--;
+	}
+	goto out;
+	x = 2;
+out:
+	return x;
+	g(x);
+}
+int main(void) { }
+int k(int x) { do { continue; } while (x); return 1; }`,
+		diags: []string{
+			"2:33: control reaches end of non-void function a [-Wreturn-type]",
+			"4:44: control reaches end of non-void function c [-Wreturn-type]",
+			"6:49: control reaches end of non-void function e [-Wreturn-type]",
+			"7:25: code will never be executed [-Wunreachable-code]",
+			"11:3: code will never be executed [-Wunreachable-code]",
+			"14:2: code will never be executed [-Wunreachable-code]",
+			"17:2: code will never be executed [-Wunreachable-code]",
+		},
+	},
+	{
+		name: "lvalues and qualifiers",
+		src: `struct s { const int c; int m; };
+const int k = 1;
+int f(void);
+void g(const char *s, char *t) {
+	const int *p = &k;
+	int *const q = 0;
+	struct s v;
+	int b[2];
+	const struct s w = { 1, 2 };
+	k = 2;
+	k++;
+	--k;
+	*p = 1;
+	q = 0;
+	*q = 1;
+	v.c = 1;
+	v.m = 1;
+	w.m = 1;
+	f() = 1;
+	1 = 2;
+	b = 0;
+	g = 0;
+	s[0] = 'x';
+	t = s;
+	p = q;
+	s++;
+}
+void put(char *);
+void h(const char *p) { char *r = p; put(p); *r = 0; }`,
+		diags: []string{
+			"10:2: cannot assign to variable k with const-qualified type const int",
+			"11:3: cannot increment variable k with const-qualified type const int",
+			"12:2: cannot decrement variable k with const-qualified type const int",
+			"13:2: cannot assign to expression with const-qualified type const int",
+			"14:2: cannot assign to variable q with const-qualified type int *const",
+			"16:2: cannot assign to expression with const-qualified type const int",
+			"18:2: cannot assign to expression with const-qualified type const int",
+			"19:2: expression is not assignable",
+			"20:2: expression is not assignable",
+			"21:2: array type int[2] is not assignable",
+			"22:2: expression is not assignable",
+			"23:2: cannot assign to expression with const-qualified type const char",
+			"24:6: assigning to char * from const char * discards the const qualifier [-Wdiscarded-qualifiers]",
+			"29:35: initializing char * with an expression of type const char * discards the const qualifier [-Wdiscarded-qualifiers]",
+			"29:42: passing const char * to parameter 1 of put of type char * discards the const qualifier [-Wdiscarded-qualifiers]",
+		},
+	},
+	{
+		name: "implicit conversions",
+		src: `int f(long l, unsigned u, int i, double d, char *p) {
+	int a = l;
+	short s = i;
+	int b = d;
+	float x = d;
+	unsigned char c = 300;
+	char ok = 100;
+	if (i < u)
+		return 1;
+	if (u == -1)
+		return 2;
+	if (i < 0 && (unsigned)i < u && u > 3)
+		return 3;
+	a = i << 40;
+	a = i >> -1;
+	a = l;
+	return a + s + b + x + c + ok + *p;
+}`,
+		diags: []string{
+			"2:6: value stored to a is never read [-Wdead-store]",
+			"2:10: implicit conversion loses integer precision: long to int [-Wconversion]",
+			"3:12: implicit conversion loses integer precision: int to short [-Wconversion]",
+			"4:10: implicit conversion turns floating-point number into integer: double to int [-Wconversion]",
+			"5:12: implicit conversion loses floating-point precision: double to float [-Wconversion]",
+			"6:20: implicit conversion loses integer precision: int to unsigned char [-Wconversion]",
+			"8:6: comparison of integers of different signs: int and unsigned int [-Wsign-compare]",
+			"10:6: comparison of integers of different signs: unsigned int and int [-Wsign-compare]",
+			"14:2: value stored to a is never read [-Wdead-store]",
+			"14:11: shift count >= width of type int [-Wshift-count-overflow]",
+			"15:2: value stored to a is never read [-Wdead-store]",
+			"15:11: shift count is negative [-Wshift-count-negative]",
+			"16:6: implicit conversion loses integer precision: long to int [-Wconversion]",
+			"17:9: implicit conversion turns floating-point number into integer: float to int [-Wconversion]",
+		},
+	},
+}
+
+func TestCheck(t *testing.T) {
+	for _, test := range checks {
+		if got := diagnostics(t, test.src); !reflect.DeepEqual(got, test.diags) {
+			t.Errorf("%s: got diagnostics\n\t%q\ninstead of\n\t%q", test.name, got, test.diags)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package sema
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/types"
+	"math/big"
+)
+
+// constantExpression evaluates the integer constant expression n. When n
+// is not one, it reports the first operand preventing it.
+func (c *checker) constantExpression(n *ast.ConstantExpression) (int64, bool) {
+	var saved = c.constantContext
+	c.constantContext = true
+	var x = c.record(n, c.conditional(n.ConditionalExpression))
+	c.constantContext = saved
+
+	switch {
+	case !x.valid():
+		return 0, false
+	case !types.IsInteger(x.Type):
+		c.diags.Errorf(n.Pos, "integer constant expression has type %s", x.Type)
+		return 0, false
+	case !x.Constant:
+		c.diags.Errorf(ast.Pos(c.nonConstant(n.ConditionalExpression)), "expression is not an integer constant expression")
+		return 0, false
+	}
+	return x.Value, true
+}
+
+// variableArray records the expression n giving the length of the array
+// of variable length array.
+func (c *checker) variableArray(n *ast.ConstantExpression, array *types.Array) {
+	c.info.Lengths[array] = n
+}
+
+// arrayLength evaluates the length n of an array which is not a member.
+// Outside of file scope, a length which is not constant makes an array
+// of variable length.
+func (c *checker) arrayLength(n *ast.ConstantExpression) (int64, bool) {
+	if c.scope == c.file {
+		return c.constantExpression(n)
+	}
+	var x = c.record(n, value(c.conditional(n.ConditionalExpression)))
+	switch {
+	case !x.valid():
+		return 0, false
+	case !types.IsInteger(x.Type):
+		c.diags.Errorf(n.Pos, "size of array has type %s", x.Type)
+		return 0, false
+	}
+	return x.Value, x.Constant
+}
+
+// nonConstant returns the innermost operand of the expression n which is
+// not constant while its own operands are. The type names of casts, which
+// record the converted operand, are passed over.
+func (c *checker) nonConstant(n ast.Node) ast.Node {
+	for _, child := range ast.Children(n) {
+		if _, ok := child.(*ast.TypeName); ok {
+			continue
+		}
+		if x, ok := c.info.Types[child]; ok && !x.Constant {
+			return c.nonConstant(child)
+		}
+	}
+	return n
+}
+
+// bigValue returns the value of the constant x.
+func (c *checker) bigValue(x TypeAndValue) *big.Int {
+	if types.IsInteger(x.Type) && !c.target.IsSigned(x.Type) {
+		return new(big.Int).SetUint64(uint64(x.Value))
+	}
+	return big.NewInt(x.Value)
+}
+
+// fold returns the constant of the integer type t with the value v
+// wrapped to the width of t. Unless n is nil, it warns when v does not
+// fit a signed t.
+func (c *checker) fold(n ast.Node, t types.Type, v *big.Int) TypeAndValue {
+	var bits = c.target.Bits(t)
+	var modulus = new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	var wrapped = new(big.Int).And(v, new(big.Int).Sub(modulus, big.NewInt(1)))
+	if !c.target.IsSigned(t) {
+		return TypeAndValue{Type: t, Constant: true, Value: int64(wrapped.Uint64())}
+	}
+	if wrapped.Bit(bits-1) == 1 {
+		wrapped.Sub(wrapped, modulus)
+	}
+	if n != nil && wrapped.Cmp(v) != 0 {
+		c.diags.Warnf(ast.Pos(n), "overflow", "integer overflow in expression of type %s results in %s", t, wrapped)
+	}
+	return TypeAndValue{Type: t, Constant: true, Value: wrapped.Int64()}
+}
+
+// convertConstant returns the value of the constant x converted to the
+// integer type t.
+func (c *checker) convertConstant(x TypeAndValue, t types.Type) *big.Int {
+	return c.bigValue(c.fold(nil, t, c.bigValue(x)))
+}
+
+// foldBinary computes x op y for constant integer operands, converted to
+// t, the type of the result. Division by zero and shifts by negative or
+// too large amounts do not give constants, unless the operation is not
+// evaluated, in which case its value does not matter.
+func (c *checker) foldBinary(n ast.Node, op string, x, y TypeAndValue, t types.Type) TypeAndValue {
+	var result = TypeAndValue{Type: t}
+	if !x.Constant || !y.Constant || !types.IsInteger(t) {
+		return result
+	}
+	var a, b = c.convertConstant(x, t), c.convertConstant(y, y.Type)
+	if op != "<<" && op != ">>" {
+		b = c.convertConstant(y, t)
+	}
+
+	var v = new(big.Int)
+	switch op {
+	case "+":
+		v.Add(a, b)
+	case "-":
+		v.Sub(a, b)
+	case "*":
+		v.Mul(a, b)
+	case "/", "%":
+		if b.Sign() == 0 {
+			if c.unevaluated {
+				return TypeAndValue{Type: t, Constant: true}
+			}
+			if c.constantContext {
+				c.diags.Errorf(ast.Pos(n), "division by zero in constant expression")
+				return invalid
+			}
+			c.diags.Warnf(ast.Pos(n), "division-by-zero", "division by zero is undefined")
+			return result
+		}
+		if op == "/" {
+			v.Quo(a, b)
+		} else {
+			v.Rem(a, b)
+		}
+	case "<<", ">>":
+		if b.Sign() < 0 || b.Cmp(big.NewInt(int64(c.target.Bits(t)))) >= 0 {
+			switch {
+			case c.unevaluated:
+				return TypeAndValue{Type: t, Constant: true}
+			case c.constantContext && b.Sign() < 0:
+				c.diags.Errorf(ast.Pos(n), "shift count is negative in constant expression")
+				return invalid
+			case c.constantContext:
+				c.diags.Errorf(ast.Pos(n), "shift count %s >= width of type %s in constant expression", b, t)
+				return invalid
+			}
+			return result
+		}
+		if op == "<<" {
+			v.Lsh(a, uint(b.Uint64()))
+		} else {
+			v.Rsh(a, uint(b.Uint64()))
+		}
+	case "&":
+		v.And(a, b)
+	case "^":
+		v.Xor(a, b)
+	case "|":
+		v.Or(a, b)
+	}
+	if c.unevaluated {
+		return c.fold(nil, t, v)
+	}
+	return c.fold(n, t, v)
+}
+
+// foldComparison computes x op y for constant arithmetic operands.
+func (c *checker) foldComparison(op string, x, y TypeAndValue) TypeAndValue {
+	var result = TypeAndValue{Type: types.Typ[types.Int]}
+	if !x.Constant || !y.Constant {
+		return result
+	}
+	var cmp int
+	if types.IsInteger(x.Type) && types.IsInteger(y.Type) {
+		var t = c.target.ArithmeticConversion(x.Type, y.Type)
+		cmp = c.convertConstant(x, t).Cmp(c.convertConstant(y, t))
+	} else {
+		cmp = c.bigValue(x).Cmp(c.bigValue(y))
+	}
+
+	var truth = map[string]bool{
+		"==": cmp == 0, "!=": cmp != 0,
+		"<": cmp < 0, "<=": cmp <= 0,
+		">": cmp > 0, ">=": cmp >= 0,
+	}[op]
+	return truthValue(truth)
+}
+
+func truthValue(b bool) TypeAndValue {
+	var x = TypeAndValue{Type: types.Typ[types.Int], Constant: true}
+	if b {
+		x.Value = 1
+	}
+	return x
+}
@@ -0,0 +1,149 @@
+package sema
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/diag"
+	"lazarus-c/src/lexer"
+	"lazarus-c/src/types"
+)
+
+// OptIn holds the names of the warnings that are only reported when
+// enabled explicitly, as they flag code that is often correct.
+var OptIn = map[string]bool{
+	"conversion":           true,
+	"sign-compare":         true,
+	"shift-count-negative": true,
+	"shift-count-overflow": true,
+}
+
+// span is the source extent of an operand. Simple operands are unary
+// expressions or tighter, which can be cast without parentheses.
+type span struct {
+	start, end lexer.Position
+	simple     bool
+}
+
+// operandSpan returns the span of the expression n.
+func operandSpan(n ast.Node) span {
+	var s = span{start: ast.Pos(n), end: ast.End(n)}
+	for {
+		switch n.(type) {
+		case *ast.CastExpression, *ast.UnaryExpression, *ast.PostfixExpression:
+			s.simple = true
+			return s
+		}
+		var children = ast.Children(n)
+		if len(children) != 1 {
+			return s
+		}
+		n = children[0]
+	}
+}
+
+// chainOperand returns the span of the left operand of the operator idx
+// of the chain of binary operators n, whose first operand is head and
+// whose other operands are tails.
+func chainOperand[T ast.Node](n, head ast.Node, tails []T, idx int) span {
+	if idx == 0 {
+		return operandSpan(head)
+	}
+	return span{start: ast.Pos(n), end: ast.End(tails[idx-1])}
+}
+
+// castFix suggests the explicit conversion of the operand s to t.
+func castFix(d *diag.Diagnostic, s span, t types.Type) {
+	var cast = "(" + underlying(t).String() + ")"
+	if s.simple {
+		d.Fix(s.start, cast)
+		return
+	}
+	d.Fix(s.start, cast+"(")
+	d.Fix(s.end, ")")
+}
+
+// conversion warns when assigning x, the operand s, to an object of the
+// arithmetic type target loses information: floating values converted to
+// integers, or values converted to narrower types. Constants that fit
+// the target are not reported.
+func (c *checker) conversion(s span, target types.Type, x TypeAndValue) {
+	var l, r = underlying(target), value(x).Type
+	var kind string
+	switch {
+	case types.IsInteger(l) && types.IsFloating(r):
+		kind = "turns floating-point number into integer"
+	case types.IsInteger(l) && types.IsInteger(r):
+		if c.target.Bits(l) >= c.target.Bits(r) {
+			return
+		}
+		if x.Constant && c.convertConstant(x, l).Cmp(c.bigValue(x)) == 0 {
+			return
+		}
+		kind = "loses integer precision"
+	case types.IsFloating(l) && types.IsFloating(r):
+		if c.target.Sizeof(l) >= c.target.Sizeof(r) {
+			return
+		}
+		kind = "loses floating-point precision"
+	default:
+		return
+	}
+	var d = c.diags.Warnf(s.start, "conversion", "implicit conversion %s: %s to %s", kind, r, l)
+	castFix(d, s, l)
+}
+
+// signCompare warns when the comparison of x and y, the operands left
+// and right, converts a signed integer to an unsigned type, unless it is
+// a constant known not to be negative.
+func (c *checker) signCompare(n ast.Node, x, y TypeAndValue, left, right span) {
+	if !x.valid() || !y.valid() {
+		return
+	}
+	x, y = value(x), value(y)
+	if !types.IsInteger(x.Type) || !types.IsInteger(y.Type) {
+		return
+	}
+	var t = c.target.ArithmeticConversion(x.Type, y.Type)
+	if c.target.IsSigned(t) {
+		return
+	}
+	var signed, s = x, left
+	if !c.target.IsSigned(types.IntegerPromotion(x.Type)) {
+		signed, s = y, right
+	}
+	if !c.target.IsSigned(types.IntegerPromotion(signed.Type)) || signed.Constant && signed.Value >= 0 {
+		return
+	}
+	var d = c.diags.Warnf(ast.Pos(n), "sign-compare", "comparison of integers of different signs: %s and %s", x.Type, y.Type)
+	castFix(d, s, t)
+}
+
+// shiftCount warns when x, the left operand s of a shift, is shifted by
+// the constant y when y is negative or not less than the width of the
+// promoted x. When a wider type holds the result, the fix converts x to
+// it. Constant expressions report such shifts as errors instead.
+func (c *checker) shiftCount(x, y TypeAndValue, s span, count ast.Node) {
+	if !x.valid() || !y.valid() || !y.Constant || c.constantContext {
+		return
+	}
+	x, y = value(x), value(y)
+	if !types.IsInteger(x.Type) || !types.IsInteger(y.Type) {
+		return
+	}
+	var t = types.IntegerPromotion(x.Type)
+	var amount = c.bigValue(y)
+	if amount.Sign() < 0 {
+		c.diags.Warnf(ast.Pos(count), "shift-count-negative", "shift count is negative")
+		return
+	}
+	if amount.IsInt64() && amount.Int64() < int64(c.target.Bits(t)) {
+		return
+	}
+	var d = c.diags.Warnf(ast.Pos(count), "shift-count-overflow", "shift count >= width of type %s", t)
+	var wider types.Type = types.Typ[types.LongLong]
+	if !c.target.IsSigned(t) {
+		wider = types.Typ[types.UnsignedLongLong]
+	}
+	if amount.IsInt64() && amount.Int64() < int64(c.target.Bits(wider)) {
+		castFix(d, s, wider)
+	}
+}
@@ -0,0 +1,50 @@
+package sema
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/diag"
+	"sort"
+	"testing"
+)
+
+// fixes lists function bodies along with the bodies obtained by inserting
+// the casts suggested by the warnings they get.
+var fixes = []struct {
+	body, fixed string
+}{
+	{"int a = l; return a;", "int a = (int)l; return a;"},
+	{"return l + 1;", "return (int)(l + 1);"},
+	{"return i < u;", "return (unsigned int)i < u;"},
+	{"return u == -1;", "return u == (unsigned int)-1;"},
+	{"return d;", "return (int)d;"},
+	{"float x = d; return x != 0;", "float x = (float)d; return x != 0;"},
+	{"return i << 40;", "return (long long)i << 40;"},
+	{"return i ? l : u;", "return (int)(i ? l : u);"},
+	{"return i < (int)u;", "return i < (int)u;"},
+}
+
+func TestFixes(t *testing.T) {
+	const head = "int f(long l, unsigned u, int i, double d) { "
+	for _, test := range fixes {
+		var src = head + test.body + " }"
+		var unit, err = ast.ParseString(src)
+		if err != nil {
+			t.Errorf("parsing %q: %v", test.body, err)
+			continue
+		}
+		var diags diag.List
+		(&Config{Source: []byte(src)}).Check(unit, &diags)
+		var edits []*diag.Fix
+		for _, d := range diags {
+			edits = append(edits, d.Fixes...)
+		}
+		sort.SliceStable(edits, func(i, j int) bool { return edits[i].Pos.Offset > edits[j].Pos.Offset })
+		var fixed = src
+		for _, fix := range edits {
+			fixed = fixed[:fix.Pos.Offset] + fix.Text + fixed[fix.Pos.Offset:]
+		}
+		if want := head + test.fixed + " }"; fixed != want {
+			t.Errorf("%s: fixed as\n\t%s\ninstead of\n\t%s", test.body, fixed[len(head):], test.fixed)
+		}
+	}
+}
@@ -0,0 +1,943 @@
+package sema
+
+import (
+	"fmt"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/types"
+	"math/big"
+	"strconv"
+)
+
+// TypeAndValue describes an expression: its type, before arrays and
+// functions are converted to pointers, whether it designates an object
+// and, for constants, its value.
+type TypeAndValue struct {
+	Type   types.Type
+	Lvalue bool
+	// Constant is set when the value of the expression is known. Value
+	// holds it, truncated to the width of Type, with the bits of unsigned
+	// types reinterpreted as int64.
+	Constant bool
+	Value    int64
+}
+
+var invalid = TypeAndValue{}
+
+func (x TypeAndValue) valid() bool {
+	return x.Type != nil
+}
+
+// value returns the operand x used for its value: arrays and functions
+// become pointers to their first element and to themselves, and the
+// qualifiers of objects are dropped.
+func value(x TypeAndValue) TypeAndValue {
+	switch t := x.Type.(type) {
+	case nil:
+		return invalid
+	case *types.Array:
+		return TypeAndValue{Type: &types.Pointer{Elem: t.Elem}}
+	case *types.Function:
+		return TypeAndValue{Type: &types.Pointer{Elem: t}}
+	}
+	return TypeAndValue{Type: underlying(x.Type), Constant: x.Constant, Value: x.Value}
+}
+
+func underlying(t types.Type) types.Type {
+	var u, _ = types.Unqualified(t)
+	return u
+}
+
+// pointee returns the type pointed to by t, or nil if t is not a pointer.
+func pointee(t types.Type) types.Type {
+	if p, ok := underlying(t).(*types.Pointer); ok {
+		return p.Elem
+	}
+	return nil
+}
+
+var (
+	sizeType    = types.Typ[types.UnsignedLong]
+	ptrdiffType = types.Typ[types.Long]
+)
+
+func (c *checker) record(n ast.Node, x TypeAndValue) TypeAndValue {
+	if x.valid() {
+		c.info.Types[n] = x
+	}
+	return x
+}
+
+func (c *checker) expression(n *ast.Expression) TypeAndValue {
+	var x TypeAndValue
+	for _, e := range n.AssignmentExpressions {
+		x = c.assignment(e)
+	}
+	if len(n.AssignmentExpressions) > 1 {
+		x = value(x)
+		x.Constant = false
+	}
+	return c.record(n, x)
+}
+
+func (c *checker) assignment(n *ast.AssignmentExpression) TypeAndValue {
+	var right = c.conditional(n.ConditionalExpression)
+	var rightNode ast.Node = n.ConditionalExpression
+	for idx := len(n.UnaryExpressions) - 1; idx >= 0; idx-- {
+		var left = c.unary(n.UnaryExpressions[idx])
+		right = c.assign(n.UnaryExpressions[idx], *n.AssignmentOperators[idx].AssignmentOperator, left, right, rightNode)
+		rightNode = n.UnaryExpressions[idx]
+	}
+	return c.record(n, right)
+}
+
+// assign checks an assignment of right to left with the operator op.
+func (c *checker) assign(n ast.Node, op string, left, right TypeAndValue, rightNode ast.Node) TypeAndValue {
+	if !left.valid() || !right.valid() {
+		return invalid
+	}
+	var l, r = value(left).Type, value(right).Type
+	var name = ""
+	if primary := identifierOperand(n); primary != nil {
+		name = *primary.Identifier
+	}
+	if !c.modifiable(n, left, "assign to", name) {
+		return invalid
+	}
+	switch op {
+	case "=":
+		c.assignable(rightNode, left.Type, right, "assigning to %[1]s from %[2]s")
+	case "+=", "-=":
+		if !(types.IsArithmetic(l) && types.IsArithmetic(r) || pointee(l) != nil && types.IsInteger(r)) {
+			c.invalidOperands(n, op, l, r)
+		}
+	case "*=", "/=":
+		if !types.IsArithmetic(l) || !types.IsArithmetic(r) {
+			c.invalidOperands(n, op, l, r)
+		}
+	default:
+		if !types.IsInteger(l) || !types.IsInteger(r) {
+			c.invalidOperands(n, op, l, r)
+		}
+	}
+	return TypeAndValue{Type: underlying(left.Type)}
+}
+
+// modifiable checks that x, the target of an assignment or increment
+// described by verb, is a modifiable lvalue: neither an array nor a
+// const object or structure with a const member. name is the identifier
+// that n is, if any.
+func (c *checker) modifiable(n ast.Node, x TypeAndValue, verb string, name string) bool {
+	var u, quals = types.Unqualified(x.Type)
+	var member *types.Field
+	if s, ok := u.(*types.Struct); ok {
+		member = constMember(s)
+	}
+	var what = "expression"
+	var obj *Object
+	if name != "" {
+		obj = c.scope.Lookup(name)
+	}
+	if obj != nil && (obj.Kind == Variable || obj.Kind == Parameter) {
+		what = fmt.Sprintf("%s %s", obj.Kind, name)
+	}
+
+	switch {
+	case !x.Lvalue:
+		c.diags.Errorf(ast.Pos(n), "expression is not assignable")
+	case isArray(u):
+		c.diags.Errorf(ast.Pos(n), "array type %s is not assignable", x.Type)
+	case quals&types.Const != 0:
+		var d = c.diags.Errorf(ast.Pos(n), "cannot %s %s with const-qualified type %s", verb, what, x.Type)
+		if obj != nil && what != "expression" {
+			d.Note(obj.Pos, "%s declared const here", name)
+		}
+	case member != nil:
+		c.diags.Errorf(ast.Pos(n), "cannot %s %s with const-qualified member %s", verb, what, member.Name)
+	default:
+		return true
+	}
+	return false
+}
+
+// constMember returns the first const-qualified member of s, looking into
+// the structures and unions it holds, or nil.
+func constMember(s *types.Struct) *types.Field {
+	for _, field := range s.Fields {
+		var u, quals = types.Unqualified(field.Type)
+		if quals&types.Const != 0 {
+			return field
+		}
+		if inner, ok := u.(*types.Struct); ok && inner != s {
+			if member := constMember(inner); member != nil {
+				return member
+			}
+		}
+	}
+	return nil
+}
+
+func isArray(t types.Type) bool {
+	var _, ok = t.(*types.Array)
+	return ok
+}
+
+func (c *checker) invalidOperands(n ast.Node, op string, left, right types.Type) {
+	c.diags.Errorf(ast.Pos(n), "invalid operands to %s (have %s and %s)", op, left, right)
+}
+
+// assignable checks that x, the value of the expression n, can be
+// assigned to an object of type target. what is the format of the
+// description of the assignment in messages, given the target and value
+// types, as in "assigning to %[1]s from %[2]s".
+func (c *checker) assignable(n ast.Node, target types.Type, x TypeAndValue, what string) {
+	if !x.valid() {
+		return
+	}
+	var pos = ast.Pos(n)
+	var l, r = underlying(target), value(x).Type
+	what = fmt.Sprintf(what, target, r)
+	switch {
+	case types.IsArithmetic(l) && types.IsArithmetic(r):
+		c.conversion(operandSpan(n), l, x)
+		return
+	case pointee(l) != nil:
+		if pointee(r) != nil {
+			var lp, rp = underlying(pointee(l)), underlying(pointee(r))
+			var _, lq = types.Unqualified(pointee(l))
+			var _, rq = types.Unqualified(pointee(r))
+			if !types.IsVoid(lp) && !types.IsVoid(rp) && !types.Compatible(lp, rp) {
+				c.diags.Warnf(pos, "incompatible-pointer-types", "incompatible pointer types %s", what)
+			} else if dropped := rq &^ lq; dropped != 0 {
+				c.diags.Warnf(pos, "discarded-qualifiers", "%s discards the %s qualifier", what, dropped)
+			}
+			return
+		}
+		if c.nullPointer(x) {
+			return
+		}
+		if types.IsInteger(r) {
+			c.diags.Warnf(pos, "int-conversion", "incompatible integer to pointer conversion %s", what)
+			return
+		}
+	case types.IsInteger(l) && pointee(r) != nil:
+		c.diags.Warnf(pos, "int-conversion", "incompatible pointer to integer conversion %s", what)
+		return
+	case types.Compatible(l, r):
+		if _, ok := l.(*types.Struct); ok {
+			return
+		}
+	}
+	c.diags.Errorf(pos, "incompatible types %s", what)
+}
+
+// nullPointer reports whether x is a null pointer constant: an integer
+// constant expression of value 0, possibly cast to void *.
+func (c *checker) nullPointer(x TypeAndValue) bool {
+	var t = value(x).Type
+	if !types.IsInteger(t) && !(pointee(t) != nil && types.IsVoid(pointee(t))) {
+		return false
+	}
+	return x.Constant && x.Value == 0
+}
+
+// condition checks an expression used as a truth value.
+func (c *checker) condition(n ast.Node, x TypeAndValue) {
+	if x.valid() && !types.IsScalar(value(x).Type) {
+		c.diags.Errorf(ast.Pos(n), "used type %s where a scalar is required", x.Type)
+	}
+}
+
+func (c *checker) conditional(n *ast.ConditionalExpression) TypeAndValue {
+	var x = c.logicalOr(n.LogicalOrExpression)
+	if n.TernaryTrueExpression == nil {
+		return c.record(n, x)
+	}
+	c.condition(n.LogicalOrExpression, x)
+	var saved = c.unevaluated
+	c.unevaluated = saved || x.Constant && x.Value == 0
+	var t = c.expression(n.TernaryTrueExpression)
+	c.unevaluated = saved || x.Constant && x.Value != 0
+	var f = c.conditional(n.TernaryFalseExpression)
+	c.unevaluated = saved
+	if !t.valid() || !f.valid() {
+		return invalid
+	}
+
+	var l, r = value(t).Type, value(f).Type
+	var result types.Type
+	switch {
+	case types.IsArithmetic(l) && types.IsArithmetic(r):
+		result = c.target.ArithmeticConversion(l, r)
+	case types.IsVoid(l) && types.IsVoid(r):
+		result = l
+	case pointee(l) != nil && pointee(r) != nil:
+		var lp, rp = pointee(l), pointee(r)
+		var lu, lq = types.Unqualified(lp)
+		var ru, rq = types.Unqualified(rp)
+		switch {
+		case types.IsVoid(lu) || types.IsVoid(ru):
+			result = &types.Pointer{Elem: types.Qualify(types.Typ[types.Void], lq|rq)}
+		case types.Compatible(lu, ru):
+			result = &types.Pointer{Elem: types.Qualify(types.Composite(lu, ru), lq|rq)}
+		default:
+			c.diags.Warnf(n.Pos, "incompatible-pointer-types", "pointer type mismatch in conditional expression (%s and %s)", l, r)
+			result = &types.Pointer{Elem: types.Typ[types.Void]}
+		}
+	case pointee(l) != nil && c.nullPointer(f):
+		result = l
+	case pointee(r) != nil && c.nullPointer(t):
+		result = r
+	case pointee(l) != nil && types.IsInteger(r) || types.IsInteger(l) && pointee(r) != nil:
+		c.diags.Warnf(n.Pos, "int-conversion", "pointer/integer type mismatch in conditional expression (%s and %s)", l, r)
+		result = l
+		if pointee(r) != nil {
+			result = r
+		}
+	case types.Compatible(l, r):
+		result = l
+	default:
+		c.diags.Errorf(n.Pos, "type mismatch in conditional expression (%s and %s)", l, r)
+		return invalid
+	}
+	if x.Constant && t.Constant && f.Constant && types.IsInteger(result) {
+		var chosen = f
+		if x.Value != 0 {
+			chosen = t
+		}
+		return c.record(n, c.fold(nil, result, c.bigValue(chosen)))
+	}
+	return c.record(n, TypeAndValue{Type: result})
+}
+
+// logical checks the operands of op, && or ||.
+func (c *checker) logical(n ast.Node, op string, operands []TypeAndValue, nodes []ast.Node) TypeAndValue {
+	if len(operands) == 1 {
+		return c.record(n, operands[0])
+	}
+	var constant, truth = true, op == "&&"
+	for idx, x := range operands {
+		c.condition(nodes[idx], x)
+		constant = constant && x.Constant
+		if op == "&&" {
+			truth = truth && x.Value != 0
+		} else {
+			truth = truth || x.Value != 0
+		}
+	}
+	if constant {
+		return c.record(n, truthValue(truth))
+	}
+	return c.record(n, TypeAndValue{Type: types.Typ[types.Int]})
+}
+
+// shortCircuits reports whether the operand x decides the value of the
+// && or || expression op, leaving the operands after it unevaluated.
+func shortCircuits(op string, x TypeAndValue) bool {
+	return x.Constant && (x.Value == 0) == (op == "&&")
+}
+
+func (c *checker) logicalOr(n *ast.LogicalOrExpression) TypeAndValue {
+	var operands []TypeAndValue
+	var nodes []ast.Node
+	var saved = c.unevaluated
+	for _, e := range n.LogicalAndExpressions {
+		operands = append(operands, c.logicalAnd(e))
+		nodes = append(nodes, e)
+		c.unevaluated = c.unevaluated || shortCircuits("||", operands[len(operands)-1])
+	}
+	c.unevaluated = saved
+	return c.logical(n, "||", operands, nodes)
+}
+
+func (c *checker) logicalAnd(n *ast.LogicalAndExpression) TypeAndValue {
+	var operands []TypeAndValue
+	var nodes []ast.Node
+	var saved = c.unevaluated
+	for _, e := range n.InclusiveOrExpressions {
+		operands = append(operands, c.inclusiveOr(e))
+		nodes = append(nodes, e)
+		c.unevaluated = c.unevaluated || shortCircuits("&&", operands[len(operands)-1])
+	}
+	c.unevaluated = saved
+	return c.logical(n, "&&", operands, nodes)
+}
+
+// bitwise checks the operands of &, ^ and |.
+func (c *checker) bitwise(n ast.Node, op string, operands []TypeAndValue) TypeAndValue {
+	var x = operands[0]
+	for _, y := range operands[1:] {
+		x = c.binary(n, op, x, y)
+	}
+	return c.record(n, x)
+}
+
+func (c *checker) inclusiveOr(n *ast.InclusiveOrExpression) TypeAndValue {
+	var operands []TypeAndValue
+	for _, e := range n.ExclusiveOrExpressions {
+		operands = append(operands, c.exclusiveOr(e))
+	}
+	return c.bitwise(n, "|", operands)
+}
+
+func (c *checker) exclusiveOr(n *ast.ExclusiveOrExpression) TypeAndValue {
+	var operands []TypeAndValue
+	for _, e := range n.AndExpressions {
+		operands = append(operands, c.and(e))
+	}
+	return c.bitwise(n, "^", operands)
+}
+
+func (c *checker) and(n *ast.AndExpression) TypeAndValue {
+	var operands []TypeAndValue
+	for _, e := range n.EqualityExpressions {
+		operands = append(operands, c.equality(e))
+	}
+	return c.bitwise(n, "&", operands)
+}
+
+func (c *checker) equality(n *ast.EqualityExpression) TypeAndValue {
+	var x = c.relational(n.HeadRelationalExpression)
+	for idx, e := range n.TailRelationalExpressions {
+		var y = c.relational(e)
+		c.signCompare(n, x, y, chainOperand(n, n.HeadRelationalExpression, n.TailRelationalExpressions, idx), operandSpan(e))
+		x = c.comparison(n, n.Operators[idx], x, y)
+	}
+	return c.record(n, x)
+}
+
+func (c *checker) relational(n *ast.RelationalExpression) TypeAndValue {
+	var x = c.shift(n.HeadShiftExpression)
+	for idx, e := range n.TailShiftExpressions {
+		var y = c.shift(e)
+		c.signCompare(n, x, y, chainOperand(n, n.HeadShiftExpression, n.TailShiftExpressions, idx), operandSpan(e))
+		x = c.comparison(n, n.Operators[idx], x, y)
+	}
+	return c.record(n, x)
+}
+
+// comparison checks the operands of an equality or relational operator.
+func (c *checker) comparison(n ast.Node, op string, x, y TypeAndValue) TypeAndValue {
+	if !x.valid() || !y.valid() {
+		return invalid
+	}
+	var l, r = value(x).Type, value(y).Type
+	var result = TypeAndValue{Type: types.Typ[types.Int]}
+	var equality = op == "==" || op == "!="
+	switch {
+	case types.IsArithmetic(l) && types.IsArithmetic(r):
+		return c.foldComparison(op, value(x), value(y))
+	case pointee(l) != nil && pointee(r) != nil:
+		var lp, rp = underlying(pointee(l)), underlying(pointee(r))
+		if equality && (types.IsVoid(lp) || types.IsVoid(rp)) {
+			break
+		}
+		if !types.Compatible(lp, rp) {
+			c.diags.Warnf(ast.Pos(n), "compare-distinct-pointer-types", "comparison of distinct pointer types (%s and %s)", l, r)
+		}
+	case equality && pointee(l) != nil && c.nullPointer(y),
+		equality && pointee(r) != nil && c.nullPointer(x):
+	case pointee(l) != nil && types.IsInteger(r) || types.IsInteger(l) && pointee(r) != nil:
+		c.diags.Warnf(ast.Pos(n), "pointer-integer-compare", "comparison between pointer and integer (%s and %s)", l, r)
+	default:
+		c.invalidOperands(n, op, l, r)
+		return invalid
+	}
+	return result
+}
+
+func (c *checker) shift(n *ast.ShiftExpression) TypeAndValue {
+	var x = c.additive(n.HeadAdditiveExpression)
+	for idx, e := range n.TailAdditiveExpressions {
+		var y = c.additive(e)
+		c.shiftCount(x, y, chainOperand(n, n.HeadAdditiveExpression, n.TailAdditiveExpressions, idx), e)
+		x = c.binary(n, n.Operators[idx], x, y)
+	}
+	return c.record(n, x)
+}
+
+func (c *checker) additive(n *ast.AdditiveExpression) TypeAndValue {
+	var x = c.multiplicative(n.HeadMultiplicativeExpression)
+	for idx, e := range n.TailMultiplicativeExpression {
+		x = c.binary(n, n.Operators[idx], x, c.multiplicative(e))
+	}
+	return c.record(n, x)
+}
+
+func (c *checker) multiplicative(n *ast.MultiplicativeExpression) TypeAndValue {
+	var x = c.cast(n.HeadCastExpression)
+	for idx, e := range n.TailCastExpression {
+		x = c.binary(n, n.Operators[idx], x, c.cast(e))
+	}
+	return c.record(n, x)
+}
+
+// binary checks the operands of an arithmetic, shift or bitwise operator
+// and returns the type of the result.
+func (c *checker) binary(n ast.Node, op string, x, y TypeAndValue) TypeAndValue {
+	if !x.valid() || !y.valid() {
+		return invalid
+	}
+	var l, r = value(x).Type, value(y).Type
+	switch op {
+	case "+", "-":
+		if types.IsArithmetic(l) && types.IsArithmetic(r) {
+			break
+		}
+		if pointee(l) != nil && types.IsInteger(r) {
+			c.pointerArithmetic(n, l)
+			return TypeAndValue{Type: l}
+		}
+		if op == "+" && types.IsInteger(l) && pointee(r) != nil {
+			c.pointerArithmetic(n, r)
+			return TypeAndValue{Type: r}
+		}
+		if op == "-" && pointee(l) != nil && pointee(r) != nil {
+			if !types.Compatible(underlying(pointee(l)), underlying(pointee(r))) {
+				c.invalidOperands(n, op, l, r)
+				return invalid
+			}
+			c.pointerArithmetic(n, l)
+			return TypeAndValue{Type: ptrdiffType}
+		}
+		c.invalidOperands(n, op, l, r)
+		return invalid
+	case "*", "/":
+		if !types.IsArithmetic(l) || !types.IsArithmetic(r) {
+			c.invalidOperands(n, op, l, r)
+			return invalid
+		}
+	case "<<", ">>":
+		if !types.IsInteger(l) || !types.IsInteger(r) {
+			c.invalidOperands(n, op, l, r)
+			return invalid
+		}
+		return c.foldBinary(n, op, value(x), value(y), types.IntegerPromotion(l))
+	default:
+		if !types.IsInteger(l) || !types.IsInteger(r) {
+			c.invalidOperands(n, op, l, r)
+			return invalid
+		}
+	}
+	return c.foldBinary(n, op, value(x), value(y), c.target.ArithmeticConversion(l, r))
+}
+
+// pointerArithmetic checks that the pointer type t points to objects of
+// known size.
+func (c *checker) pointerArithmetic(n ast.Node, t types.Type) {
+	var elem = pointee(t)
+	switch {
+	case types.IsVoid(elem):
+		c.diags.Warnf(ast.Pos(n), "pointer-arith", "arithmetic on a pointer to void")
+	case !types.IsComplete(elem):
+		c.diags.Errorf(ast.Pos(n), "arithmetic on a pointer to an incomplete type %s", elem)
+	}
+}
+
+func (c *checker) cast(n *ast.CastExpression) TypeAndValue {
+	var x = c.unary(n.UnaryExpression)
+	if f, ok := floatLiteral(n.UnaryExpression); ok && len(n.TypeNames) > 0 {
+		x.Constant, x.Value = true, int64(f)
+	}
+	for idx := len(n.TypeNames) - 1; idx >= 0; idx-- {
+		var target = c.typer.TypeName(n.TypeNames[idx])
+		x = c.record(n.TypeNames[idx], c.convert(n.TypeNames[idx], target, x))
+	}
+	return c.record(n, x)
+}
+
+// floatLiteral returns the value of the floating constant that makes up
+// the expression n, if any. Floating constants cast to integer types are
+// integer constant expressions.
+func floatLiteral(n ast.Node) (float64, bool) {
+	for {
+		if primary, ok := n.(*ast.PrimaryExpression); ok && primary.Float != nil {
+			var f, err = strconv.ParseFloat(*primary.Float, 64)
+			return f, err == nil
+		}
+		var children = ast.Children(n)
+		if len(children) != 1 {
+			return 0, false
+		}
+		n = children[0]
+	}
+}
+
+// convert checks an explicit conversion of x to target.
+func (c *checker) convert(n ast.Node, target types.Type, x TypeAndValue) TypeAndValue {
+	if !x.valid() {
+		return invalid
+	}
+	var t, v = underlying(target), value(x).Type
+	switch {
+	case types.IsVoid(t):
+	case !types.IsScalar(t):
+		c.diags.Errorf(ast.Pos(n), "cast to non-scalar type %s", target)
+		return invalid
+	case !types.IsScalar(v):
+		c.diags.Errorf(ast.Pos(n), "cannot cast %s to %s", v, target)
+		return invalid
+	case pointee(t) != nil && types.IsFloating(v) || types.IsFloating(t) && pointee(v) != nil:
+		c.diags.Errorf(ast.Pos(n), "cannot cast %s to %s", v, target)
+		return invalid
+	}
+	switch {
+	case !x.Constant:
+	case types.IsInteger(t):
+		return c.fold(nil, t, c.bigValue(value(x)))
+	case pointee(t) != nil && types.IsInteger(v):
+		return TypeAndValue{Type: t, Constant: true, Value: x.Value}
+	}
+	return TypeAndValue{Type: t}
+}
+
+func (c *checker) unary(n *ast.UnaryExpression) TypeAndValue {
+	var x TypeAndValue
+	switch {
+	case n.SizeOfTypeName != nil:
+		var t = c.typer.TypeName(n.SizeOfTypeName)
+		c.info.Types[n.SizeOfTypeName] = TypeAndValue{Type: t}
+		return c.record(n, c.sizeOf(n, t))
+	case n.PostfixExpression != nil:
+		x = c.postfix(n.PostfixExpression)
+	default:
+		x = c.unaryOperator(n, *n.UnaryOperatorOnCast.Operator, c.cast(n.CastExpression))
+	}
+
+	for idx := len(n.UnaryOperators) - 1; idx >= 0 && x.valid(); idx-- {
+		if n.UnaryOperators[idx] == "sizeof" {
+			x = c.sizeOf(n, x.Type)
+		} else {
+			var name = ""
+			if n.PostfixExpression != nil && idx == len(n.UnaryOperators)-1 {
+				if primary := identifierOperand(n.PostfixExpression); primary != nil {
+					name = *primary.Identifier
+				}
+			}
+			x = c.increment(n, n.UnaryOperators[idx], x, name)
+		}
+	}
+	return c.record(n, x)
+}
+
+// sizeOf returns the size of objects of type t, a constant unless t is
+// an array of variable length.
+func (c *checker) sizeOf(n ast.Node, t types.Type) TypeAndValue {
+	var x = TypeAndValue{Type: sizeType}
+	if _, ok := underlying(t).(*types.Function); ok {
+		c.diags.Errorf(ast.Pos(n), "invalid application of sizeof to a function type")
+	} else if !types.IsComplete(t) {
+		c.diags.Errorf(ast.Pos(n), "invalid application of sizeof to an incomplete type %s", t)
+	} else if !variableLength(t) {
+		x.Constant, x.Value = true, c.target.Sizeof(t)
+	}
+	return x
+}
+
+// variableLength reports whether t is an array of variable length, or of
+// such arrays.
+func variableLength(t types.Type) bool {
+	for {
+		var array, ok = underlying(t).(*types.Array)
+		if !ok {
+			return false
+		}
+		if array.Kind == types.VariableLength {
+			return true
+		}
+		t = array.Elem
+	}
+}
+
+// increment checks the operand of a prefix or postfix ++ or --. name is
+// the identifier that the operand is, if any.
+func (c *checker) increment(n ast.Node, op string, x TypeAndValue, name string) TypeAndValue {
+	var t = value(x).Type
+	if !c.modifiable(n, x, map[string]string{"++": "increment", "--": "decrement"}[op], name) {
+		return invalid
+	}
+	if !types.IsScalar(t) {
+		c.diags.Errorf(ast.Pos(n), "cannot %s value of type %s", map[string]string{"++": "increment", "--": "decrement"}[op], x.Type)
+		return invalid
+	}
+	if pointee(t) != nil {
+		c.pointerArithmetic(n, t)
+	}
+	return TypeAndValue{Type: underlying(x.Type)}
+}
+
+func (c *checker) unaryOperator(n ast.Node, op string, x TypeAndValue) TypeAndValue {
+	if !x.valid() {
+		return invalid
+	}
+	var t = value(x).Type
+	switch op {
+	case "&":
+		if _, ok := x.Type.(*types.Function); !ok && !x.Lvalue {
+			c.diags.Errorf(ast.Pos(n), "cannot take the address of an rvalue of type %s", x.Type)
+			return invalid
+		}
+		return TypeAndValue{Type: &types.Pointer{Elem: x.Type}}
+	case "*":
+		var elem = pointee(t)
+		if elem == nil {
+			c.diags.Errorf(ast.Pos(n), "indirection requires pointer operand (%s invalid)", t)
+			return invalid
+		}
+		if types.IsVoid(elem) {
+			c.diags.Warnf(ast.Pos(n), "void-ptr-dereference", "dereferencing a %s pointer", t)
+		}
+		_, isFunction := underlying(elem).(*types.Function)
+		return TypeAndValue{Type: elem, Lvalue: !isFunction}
+	case "+", "-":
+		if !types.IsArithmetic(t) {
+			break
+		}
+		var promoted = types.IntegerPromotion(t)
+		if !x.Constant || !types.IsInteger(t) {
+			return TypeAndValue{Type: promoted}
+		}
+		var v = c.convertConstant(x, promoted)
+		if op == "-" {
+			return c.fold(n, promoted, v.Neg(v))
+		}
+		return c.fold(n, promoted, v)
+	case "~":
+		if !types.IsInteger(t) {
+			break
+		}
+		var promoted = types.IntegerPromotion(t)
+		if !x.Constant {
+			return TypeAndValue{Type: promoted}
+		}
+		var v = c.convertConstant(x, promoted)
+		return c.fold(nil, promoted, v.Not(v))
+	case "!":
+		if !types.IsScalar(t) {
+			break
+		}
+		if x.Constant {
+			return truthValue(x.Value == 0)
+		}
+		return TypeAndValue{Type: types.Typ[types.Int]}
+	}
+	c.diags.Errorf(ast.Pos(n), "invalid argument type %s to unary %s", t, op)
+	return invalid
+}
+
+func (c *checker) postfix(n *ast.PostfixExpression) TypeAndValue {
+	var primary = n.PrimaryExpression
+	var name = ""
+	if primary.Identifier != nil {
+		name = *primary.Identifier
+		if len(n.PostfixOperators) > 0 && n.PostfixOperators[0].IsCall && c.scope.Lookup(name) == nil {
+			c.implicitFunction(primary)
+		}
+	}
+
+	var x = c.primary(primary)
+	for idx, op := range n.PostfixOperators {
+		if idx > 0 {
+			name = ""
+		}
+		x = c.record(op, c.postfixOperator(op, x, name))
+	}
+	return c.record(n, x)
+}
+
+// postfixOperator checks op applied to x. name is the identifier x is,
+// if any, to name the function in calls.
+func (c *checker) postfixOperator(op *ast.PostfixOperator, x TypeAndValue, name string) TypeAndValue {
+	switch {
+	case op.ArrayAccessExpression != nil:
+		var index = c.expression(op.ArrayAccessExpression)
+		if !x.valid() || !index.valid() {
+			return invalid
+		}
+		var base, i = value(x).Type, value(index).Type
+		if pointee(i) != nil && types.IsInteger(base) {
+			base, i = i, base
+		}
+		if pointee(base) == nil {
+			c.diags.Errorf(op.Pos, "subscripted value of type %s is not an array or pointer", base)
+			return invalid
+		}
+		if !types.IsInteger(i) {
+			c.diags.Errorf(op.Pos, "array subscript of type %s is not an integer", i)
+			return invalid
+		}
+		if !types.IsComplete(pointee(base)) {
+			c.diags.Errorf(op.Pos, "subscript of pointer to incomplete type %s", pointee(base))
+			return invalid
+		}
+		return TypeAndValue{Type: pointee(base), Lvalue: true}
+	case op.IsCall:
+		return c.call(op, x, name)
+	case op.IdentifierAccess != nil:
+		if !x.valid() {
+			return invalid
+		}
+		var result = c.member(op, x.Type, *op.IdentifierAccess, ".")
+		result.Lvalue = result.valid() && x.Lvalue
+		return result
+	case op.IdentifierPtrAccess != nil:
+		if !x.valid() {
+			return invalid
+		}
+		var elem = pointee(value(x).Type)
+		if elem == nil {
+			c.diags.Errorf(op.Pos, "member reference type %s is not a pointer", x.Type)
+			return invalid
+		}
+		var result = c.member(op, elem, *op.IdentifierPtrAccess, "->")
+		result.Lvalue = result.valid()
+		return result
+	default:
+		if !x.valid() {
+			return invalid
+		}
+		return c.increment(op, *op.Operator, x, name)
+	}
+}
+
+// member returns the field called name of the structure or union of type
+// t, which has the qualifiers of t.
+func (c *checker) member(op *ast.PostfixOperator, t types.Type, name string, access string) TypeAndValue {
+	var u, quals = types.Unqualified(t)
+	var s, ok = u.(*types.Struct)
+	if !ok {
+		c.diags.Errorf(op.Pos, "member reference base type %s is not a structure or union", t)
+		return invalid
+	}
+	if !s.Complete {
+		c.diags.Errorf(op.Pos, "member access into incomplete type %s", t)
+		return invalid
+	}
+	var field = s.Field(name)
+	if field == nil {
+		c.diags.Errorf(op.Pos, "no member named %s in %s", name, t)
+		return invalid
+	}
+	return TypeAndValue{Type: types.Qualify(field.Type, quals)}
+}
+
+// call checks a call of the function x with the arguments of op.
+func (c *checker) call(op *ast.PostfixOperator, x TypeAndValue, name string) TypeAndValue {
+	var args []*ast.AssignmentExpression
+	if op.ArgumentExpressionList != nil {
+		args = op.ArgumentExpressionList.AssignmentExpressions
+	}
+	var operands []TypeAndValue
+	for _, arg := range args {
+		operands = append(operands, c.assignment(arg))
+	}
+	if !x.valid() {
+		return invalid
+	}
+
+	var fn, ok = underlying(pointee(value(x).Type)).(*types.Function)
+	if !ok {
+		c.diags.Errorf(op.Pos, "called object type %s is not a function or function pointer", x.Type)
+		return invalid
+	}
+	var format *Format
+	if name != "" {
+		format = c.functionFormat(name)
+	} else {
+		name = "function"
+	}
+	if fn.Prototype {
+		switch {
+		case len(args) < len(fn.Params):
+			c.diags.Errorf(op.Pos, "too few arguments to %s, expected %d, have %d", name, len(fn.Params), len(args))
+		case len(args) > len(fn.Params) && !fn.Variadic:
+			c.diags.Errorf(op.Pos, "too many arguments to %s, expected %d, have %d", name, len(fn.Params), len(args))
+		}
+		for idx, param := range fn.Params {
+			if idx < len(args) {
+				var what = fmt.Sprintf("passing %%[2]s to parameter %d of %s of type %%[1]s", idx+1, name)
+				c.assignable(args[idx], param.Type, operands[idx], what)
+			}
+		}
+	}
+	if format != nil {
+		c.format(format, args, operands)
+	}
+
+	var result = underlying(fn.Result)
+	if !types.IsVoid(result) && !types.IsComplete(result) {
+		c.diags.Errorf(op.Pos, "calling %s with incomplete return type %s", name, result)
+		return invalid
+	}
+	return TypeAndValue{Type: result}
+}
+
+func (c *checker) primary(n *ast.PrimaryExpression) TypeAndValue {
+	var x TypeAndValue
+	switch {
+	case n.Identifier != nil:
+		var obj = c.use(n)
+		switch obj.Kind {
+		case Variable, Parameter:
+			x = TypeAndValue{Type: obj.Type, Lvalue: true}
+		case EnumConstant:
+			x = TypeAndValue{Type: obj.Type, Constant: true, Value: obj.Value}
+		default:
+			x = TypeAndValue{Type: obj.Type}
+		}
+	case n.Int != nil:
+		x = c.integerLiteral(n)
+	case n.Float != nil:
+		x = TypeAndValue{Type: types.Typ[types.Double]}
+	case n.Char != nil:
+		x = c.characterLiteral(*n.Char)
+	case n.StringLiteral != nil:
+		var length = int64(len(*n.StringLiteral) + 1)
+		x = TypeAndValue{Type: &types.Array{Elem: types.Typ[types.Char], Kind: types.Sized, Len: length}, Lvalue: true}
+	default:
+		x = c.expression(n.Expression)
+	}
+	return c.record(n, x)
+}
+
+// integerLiteral returns the integer constant n. Its type is the first
+// of int, long and unsigned long able to represent it, unsigned int
+// coming after int for octal and hexadecimal constants.
+func (c *checker) integerLiteral(n *ast.PrimaryExpression) TypeAndValue {
+	var text = *n.Int
+	var value, err = strconv.ParseUint(text, 0, 64)
+	if err != nil {
+		c.diags.Errorf(n.Pos, "integer constant %s is too large", text)
+		return TypeAndValue{Type: types.Typ[types.UnsignedLong]}
+	}
+	var candidates = []types.Kind{types.Int, types.Long, types.UnsignedLong}
+	if len(text) > 1 && text[0] == '0' {
+		candidates = []types.Kind{types.Int, types.UnsignedInt, types.Long, types.UnsignedLong}
+	}
+	for _, kind := range candidates {
+		var t = types.Typ[kind]
+		var bits = c.target.Bits(t)
+		if c.target.IsSigned(t) {
+			bits--
+		}
+		if bits >= 64 || value < 1<<bits {
+			return TypeAndValue{Type: t, Constant: true, Value: int64(value)}
+		}
+	}
+	return TypeAndValue{Type: types.Typ[types.UnsignedLong], Constant: true, Value: int64(value)}
+}
+
+// characterLiteral returns the character constant made of the bytes of
+// text, which has type int. Like other compilers, the bytes of
+// multi-character constants are combined, the first being the most
+// significant.
+func (c *checker) characterLiteral(text string) TypeAndValue {
+	var char = types.Typ[types.Char]
+	if len(text) == 1 {
+		return c.fold(nil, types.Typ[types.Int], c.bigValue(c.fold(nil, char, big.NewInt(int64(text[0])))))
+	}
+	var value int64
+	for idx := 0; idx < len(text); idx++ {
+		value = value<<8 | int64(text[idx])
+	}
+	return c.fold(nil, types.Typ[types.Int], big.NewInt(value))
+}
@@ -0,0 +1,104 @@
+package sema
+
+import (
+	"fmt"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/diag"
+	"testing"
+)
+
+const exprDecls = `struct s { int a; char c[4]; } v, *ps;
+char c; short sh; int i; unsigned u; long l; unsigned long ul;
+float f; double d; int *p; const char *cp; void *vp; int a[3];
+int fn(int);
+`
+
+// exprs lists expressions with their type, followed by "lvalue" for
+// lvalues or by the value of constants.
+var exprs = []struct {
+	expr, typ string
+}{
+	// Integer promotions and usual arithmetic conversions.
+	{"c", "char lvalue"},
+	{"c + sh", "int"},
+	{"-c", "int"},
+	{"u + i", "unsigned int"},
+	{"l + u", "long"},
+	{"ul - l", "unsigned long"},
+	{"f * i", "float"},
+	{"d / f", "double"},
+	{"sh << l", "int"},
+	{"i < d", "int"},
+	{"i ? c : sh", "int"},
+
+	// Pointers, arrays and functions.
+	{"p + 1", "int *"},
+	{"1 + p", "int *"},
+	{"p - p", "long"},
+	{"*p", "int lvalue"},
+	{"&i", "int *"},
+	{"&a", "int (*)[3]"},
+	{"a[1]", "int lvalue"},
+	{"1[a]", "int lvalue"},
+	{"*a", "int lvalue"},
+	{"&fn", "int (*)(int)"},
+	{"fn(c)", "int"},
+	{"(*fn)(1)", "int"},
+	{"v.c", "char[4] lvalue"},
+	{"ps->c[2]", "char lvalue"},
+	{"i ? p : 0", "int *"},
+	{"i ? vp : cp", "const void *"},
+	{"p == 0", "int"},
+
+	// Assignments are not lvalues and take the type of their target.
+	{"i = l", "int"},
+	{"c += 1", "char"},
+	{"p++", "int *"},
+	{"(long)i", "long"},
+
+	// Constants.
+	{"'a'", "int 97"},
+	{"1 << 3", "int 8"},
+	{"(char)300", "char 44"},
+	{"(unsigned)-1", "unsigned int 4294967295"},
+	{"sizeof a", "unsigned long 12"},
+	{"sizeof(struct s)", "unsigned long 8"},
+	{"10 / 3 * 3 + 10 % 3", "int 10"},
+	{"2 > 1 && 0 || !0", "int 1"},
+	{"1 ? 2 : 3", "int 2"},
+	{"1.5", "double"},
+}
+
+func TestExprTypes(t *testing.T) {
+	for _, test := range exprs {
+		var src = exprDecls + "void t(void) { " + test.expr + "; }"
+		var unit, err = ast.ParseString(src)
+		if err != nil {
+			t.Errorf("parsing %q: %v", test.expr, err)
+			continue
+		}
+		var diags diag.List
+		var info = Check(unit, &diags)
+		if diags.HasErrors() {
+			t.Errorf("%s: %v", test.expr, diags)
+			continue
+		}
+		var body = unit.ExternalDeclarations[len(unit.ExternalDeclarations)-1].FunctionDefinition.CompoundStatement
+		var expr = body.StatementList.Statements[0].ExpressionStatement.Expression
+		var x, ok = info.Types[expr]
+		if !ok {
+			t.Errorf("%s: has no type", test.expr)
+			continue
+		}
+		var got = x.Type.String()
+		switch {
+		case x.Lvalue:
+			got += " lvalue"
+		case x.Constant:
+			got += fmt.Sprintf(" %d", x.Value)
+		}
+		if got != test.typ {
+			t.Errorf("%s: has type %s instead of %s", test.expr, got, test.typ)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package sema
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/types"
+)
+
+// switchLabels records the labels of a switch statement: the values of
+// its cases, converted to the promoted type of the controlling
+// expression, and its default label.
+type switchLabels struct {
+	typ   types.Type
+	cases map[int64]*ast.LabeledStatement
+	def   *ast.LabeledStatement
+}
+
+// labels declares the labels of a function body in the current scope.
+func (c *checker) labels(body *ast.CompoundStatement) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		var label, ok = n.(*ast.LabeledStatement)
+		if !ok || label.GotoLabel == nil {
+			return true
+		}
+		var name = *label.GotoLabel
+		if previous := c.scope.Objects[name]; previous != nil {
+			c.diags.Errorf(label.Pos, "redefinition of label %s", name).
+				Note(previous.Pos, "previous definition of %s is here", name)
+			return true
+		}
+		var obj = &Object{Kind: Label, Name: name, Pos: label.Pos, Decl: label, Defined: true}
+		c.attributes(label.Attributes, declAttributes{}).apply(obj)
+		c.scope.insert(obj)
+		c.info.Defs[label] = obj
+		return true
+	})
+}
+
+// gotoStatement resolves the label of the goto statement n.
+func (c *checker) gotoStatement(n *ast.JumpStatement) {
+	if label := c.scope.LookupLabel(*n.GotoIdent); label != nil {
+		c.info.Uses[n] = label
+	} else {
+		c.diags.Errorf(n.Pos, "use of undeclared label %s", *n.GotoIdent)
+	}
+}
+
+func (c *checker) loopBody(n *ast.Statement) {
+	c.loops++
+	c.statement(n)
+	c.loops--
+}
+
+// caseLabel checks that the case label n is in a switch statement and
+// that no other case of the switch has the same value.
+func (c *checker) caseLabel(n *ast.LabeledStatement) {
+	var value, ok = c.constantExpression(n.CaseExpression)
+	if len(c.switches) == 0 {
+		c.diags.Errorf(n.Pos, "case label not within a switch statement")
+		return
+	}
+	var labels = c.switches[len(c.switches)-1]
+	if !ok || labels.typ == nil {
+		return
+	}
+	var x = c.info.Types[n.CaseExpression]
+	value = c.fold(nil, labels.typ, c.convertConstant(x, labels.typ)).Value
+	if previous := labels.cases[value]; previous != nil {
+		c.diags.Errorf(n.CaseExpression.Pos, "duplicate case value %s", c.bigValue(TypeAndValue{Type: labels.typ, Value: value})).
+			Note(previous.Pos, "previous case is here")
+		return
+	}
+	labels.cases[value] = n
+}
+
+// defaultLabel checks that the default label n is the only one of a
+// switch statement.
+func (c *checker) defaultLabel(n *ast.LabeledStatement) {
+	if len(c.switches) == 0 {
+		c.diags.Errorf(n.Pos, "default label not within a switch statement")
+		return
+	}
+	var labels = c.switches[len(c.switches)-1]
+	if labels.def != nil {
+		c.diags.Errorf(n.Pos, "multiple default labels in one switch").
+			Note(labels.def.Pos, "previous default label is here")
+		return
+	}
+	labels.def = n
+}
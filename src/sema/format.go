@@ -0,0 +1,313 @@
+package sema
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/lexer"
+	"lazarus-c/src/types"
+	"strings"
+)
+
+// knownFormats describes the format parameters of the library functions
+// of stdio.h.
+var knownFormats = map[string]*Format{
+	"printf":    {"printf", 1, 2},
+	"fprintf":   {"printf", 2, 3},
+	"sprintf":   {"printf", 2, 3},
+	"snprintf":  {"printf", 3, 4},
+	"vprintf":   {"printf", 1, 0},
+	"vfprintf":  {"printf", 2, 0},
+	"vsprintf":  {"printf", 2, 0},
+	"vsnprintf": {"printf", 3, 0},
+	"scanf":     {"scanf", 1, 2},
+	"fscanf":    {"scanf", 2, 3},
+	"sscanf":    {"scanf", 2, 3},
+	"vscanf":    {"scanf", 1, 0},
+	"vfscanf":   {"scanf", 2, 0},
+	"vsscanf":   {"scanf", 2, 0},
+}
+
+// functionFormat returns the format parameter of the function called
+// name: the one of its format attribute, or the one of the library
+// function of that name declared at file scope.
+func (c *checker) functionFormat(name string) *Format {
+	var obj = c.scope.Lookup(name)
+	if obj == nil || obj.Kind != Function {
+		return nil
+	}
+	if obj.Format != nil {
+		return obj.Format
+	}
+	if obj.Scope.Kind == FileScope {
+		return knownFormats[name]
+	}
+	return nil
+}
+
+// conversion is one conversion specification of a format string, from
+// its % at start to its conversion character at end.
+type conversion struct {
+	start, end int
+	// stars counts the widths and precisions given by arguments.
+	stars      int
+	suppressed bool
+	length     string
+	verb       byte
+}
+
+// format checks the arguments of a call against the format string literal
+// they follow, when there is one.
+func (c *checker) format(f *Format, args []*ast.AssignmentExpression, operands []TypeAndValue) {
+	if f.Format > len(args) {
+		return
+	}
+	var literal, ok = operand(args[f.Format-1]).(*ast.PrimaryExpression)
+	if !ok || literal.StringLiteral == nil {
+		return
+	}
+
+	// The arguments are only checked until one is missing or a
+	// conversion is invalid, when they cannot be matched anymore.
+	var next = f.First - 1
+	var checking = f.First != 0
+	var argument = func(conv conversion, expected types.Type, promote bool) {
+		if !checking {
+			return
+		}
+		if next >= len(args) {
+			c.diags.Warnf(c.literalPos(literal, conv.start), "format", "more '%%' conversions than data arguments")
+			checking = false
+			return
+		}
+		var arg, x = args[next], operands[next]
+		next++
+		if !x.valid() {
+			return
+		}
+		var actual = value(x).Type
+		if promote {
+			actual = types.DefaultPromotion(actual)
+		}
+		if !formatMatch(expected, actual, promote) {
+			c.diags.Warnf(c.literalPos(literal, conv.start), "format", "format specifies type %s but the argument has type %s", expected, actual).
+				Note(arg.Pos, "argument is here")
+		}
+	}
+
+	for _, conv := range c.conversions(literal, f.Kind) {
+		for i := 0; i < conv.stars; i++ {
+			argument(conv, types.Typ[types.Int], true)
+		}
+		if conv.verb == '%' || conv.suppressed {
+			continue
+		}
+		var expected = c.formatType(literal, conv, f.Kind)
+		if expected == nil {
+			checking = false
+			continue
+		}
+		argument(conv, expected, f.Kind == "printf")
+	}
+	if checking && next < len(args) {
+		c.diags.Warnf(args[next].Pos, "format", "data argument not used by format string")
+	}
+}
+
+// conversions parses the conversion specifications of the format string
+// literal, reporting the incomplete ones.
+func (c *checker) conversions(literal *ast.PrimaryExpression, kind string) []conversion {
+	var s = *literal.StringLiteral
+	var convs []conversion
+	for idx := 0; idx < len(s); idx++ {
+		if s[idx] != '%' {
+			continue
+		}
+		var conv = conversion{start: idx}
+		idx++
+		if kind == "printf" {
+			for idx < len(s) && strings.IndexByte("-+ #0", s[idx]) >= 0 {
+				idx++
+			}
+		} else if idx < len(s) && s[idx] == '*' {
+			conv.suppressed = true
+			idx++
+		}
+		idx = c.formatWidth(s, idx, kind, &conv)
+		if kind == "printf" && idx < len(s) && s[idx] == '.' {
+			idx = c.formatWidth(s, idx+1, kind, &conv)
+		}
+		for _, length := range []string{"hh", "ll", "h", "l", "L", "j", "z", "t"} {
+			if strings.HasPrefix(s[idx:], length) {
+				conv.length = length
+				idx += len(length)
+				break
+			}
+		}
+		if idx >= len(s) {
+			c.diags.Warnf(c.literalPos(literal, conv.start), "format", "incomplete format specifier")
+			break
+		}
+		conv.verb, conv.end = s[idx], idx
+		if conv.verb == '[' && kind == "scanf" {
+			// The scanset ends at the first ], which may be the first
+			// character of the set.
+			idx++
+			if idx < len(s) && s[idx] == '^' {
+				idx++
+			}
+			if idx < len(s) && s[idx] == ']' {
+				idx++
+			}
+			for idx < len(s) && s[idx] != ']' {
+				idx++
+			}
+			if idx >= len(s) {
+				c.diags.Warnf(c.literalPos(literal, conv.start), "format", "no closing ] for %%[ in format string")
+				break
+			}
+		}
+		convs = append(convs, conv)
+	}
+	return convs
+}
+
+// formatWidth skips the width or precision starting at s[idx], which
+// printf formats may give as an argument with *.
+func (c *checker) formatWidth(s string, idx int, kind string, conv *conversion) int {
+	if kind == "printf" && idx < len(s) && s[idx] == '*' {
+		conv.stars++
+		return idx + 1
+	}
+	for idx < len(s) && s[idx] >= '0' && s[idx] <= '9' {
+		idx++
+	}
+	return idx
+}
+
+// Integer types by length modifier, signed and unsigned. The size_t,
+// ptrdiff_t and intmax_t of the j, z and t modifiers are long on the
+// supported targets.
+var formatIntegers = map[string][2]types.Kind{
+	"":   {types.Int, types.UnsignedInt},
+	"hh": {types.SignedChar, types.UnsignedChar},
+	"h":  {types.Short, types.UnsignedShort},
+	"l":  {types.Long, types.UnsignedLong},
+	"ll": {types.LongLong, types.UnsignedLongLong},
+	"j":  {types.Long, types.UnsignedLong},
+	"z":  {types.Long, types.UnsignedLong},
+	"t":  {types.Long, types.UnsignedLong},
+}
+
+// formatType returns the type of the argument that the conversion conv
+// expects, or nil after reporting an invalid conversion.
+func (c *checker) formatType(literal *ast.PrimaryExpression, conv conversion, kind string) types.Type {
+	var scan = kind == "scanf"
+	var pointer = func(t types.Type) types.Type {
+		if scan {
+			return &types.Pointer{Elem: t}
+		}
+		return t
+	}
+	var integers, integer = formatIntegers[conv.length]
+
+	switch conv.verb {
+	case 'd', 'i':
+		if integer {
+			return pointer(types.Typ[integers[0]])
+		}
+	case 'o', 'u', 'x', 'X':
+		if integer {
+			return pointer(types.Typ[integers[1]])
+		}
+	case 'n':
+		if integer {
+			return &types.Pointer{Elem: types.Typ[integers[0]]}
+		}
+	case 'c', 's', '[':
+		if conv.length == "" && (conv.verb != '[' || scan) {
+			if conv.verb == 'c' && !scan {
+				return types.Typ[types.Int]
+			}
+			return &types.Pointer{Elem: types.Typ[types.Char]}
+		}
+	case 'p':
+		if conv.length == "" {
+			return pointer(&types.Pointer{Elem: types.Typ[types.Void]})
+		}
+	case 'f', 'F', 'e', 'E', 'g', 'G', 'a', 'A':
+		switch {
+		case conv.length == "L":
+			return pointer(types.Typ[types.LongDouble])
+		case scan && conv.length == "l", !scan && (conv.length == "" || conv.length == "l"):
+			return pointer(types.Typ[types.Double])
+		case scan && conv.length == "":
+			return pointer(types.Typ[types.Float])
+		}
+	default:
+		c.diags.Warnf(c.literalPos(literal, conv.end), "format", "invalid conversion specifier '%c'", conv.verb)
+		return nil
+	}
+	c.diags.Warnf(c.literalPos(literal, conv.start), "format", "length modifier '%s' is invalid with conversion specifier '%c'", conv.length, conv.verb)
+	return nil
+}
+
+// formatMatch reports whether an argument of type actual fits a
+// conversion expecting the type expected. Integers of the same size and
+// signedness match, as do arguments promoted like those of printf when
+// promote is set, and any pointer matches void *.
+func formatMatch(expected, actual types.Type, promote bool) bool {
+	expected, actual = underlying(expected), underlying(actual)
+	switch {
+	case types.IsInteger(expected):
+		if promote {
+			expected = types.IntegerPromotion(expected)
+		}
+		return types.IsInteger(actual) && types.Unsigned(expected) == types.Unsigned(actual)
+	case types.IsFloating(expected):
+		return types.Identical(expected, actual)
+	case pointee(expected) != nil:
+		var elem = underlying(pointee(expected))
+		if pointee(actual) == nil {
+			return false
+		}
+		return types.IsVoid(elem) || formatMatch(elem, pointee(actual), false)
+	}
+	return false
+}
+
+// literalPos returns the position of the byte at index in the value of
+// the string literal n. The escape sequences of the literal are those of
+// the source when it is known, or those written by lexer.Quote otherwise.
+func (c *checker) literalPos(n *ast.PrimaryExpression, index int) lexer.Position {
+	var raw = lexer.Quote(*n.StringLiteral, '"')
+	if end := ast.End(n).Offset; c.source != nil && end <= len(c.source) {
+		raw = string(c.source[n.Pos.Offset:end])
+	}
+	var pos = n.Pos
+	var offset = 1
+	for ; index > 0 && offset < len(raw)-1; index-- {
+		offset += escapeLength(raw[offset:])
+	}
+	pos.Offset += offset
+	pos.Column += offset
+	return pos
+}
+
+// escapeLength returns the length of the character or escape sequence
+// starting s, the body of a string literal.
+func escapeLength(s string) int {
+	if s[0] != '\\' || len(s) < 2 {
+		return 1
+	}
+	var length = 2
+	switch {
+	case s[1] == 'x':
+		for length < len(s) && strings.IndexByte("0123456789abcdefABCDEF", s[length]) >= 0 {
+			length++
+		}
+	case s[1] >= '0' && s[1] <= '7':
+		for length < 4 && length < len(s) && s[length] >= '0' && s[length] <= '7' {
+			length++
+		}
+	}
+	return length
+}
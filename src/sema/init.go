@@ -0,0 +1,130 @@
+package sema
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/types"
+)
+
+// initialize checks the initializer n of an object of type t. The result
+// is t, with the length of an unsized array set from n.
+func (c *checker) initialize(t types.Type, n *ast.Initializer) types.Type {
+	if n.InitializerList == nil {
+		return c.initializeValue(t, n)
+	}
+
+	var inits = n.InitializerList.Initializers
+	var idx = 0
+	if types.IsScalar(t) {
+		c.initialize(t, inits[0])
+		idx++
+	} else {
+		t = c.aggregate(t, inits, &idx)
+	}
+	if idx < len(inits) {
+		c.diags.Warnf(inits[idx].Pos, "excess-initializers", "excess elements in initializer of type %s", t)
+		for _, init := range inits[idx:] {
+			c.initialize(nil, init)
+		}
+	}
+	return t
+}
+
+// initializeValue checks an initializer that is an expression. A nil t
+// only checks the expression.
+func (c *checker) initializeValue(t types.Type, n *ast.Initializer) types.Type {
+	var x, ok = c.evaluated[n]
+	if ok {
+		delete(c.evaluated, n)
+	} else {
+		x = c.assignment(n.AssignmentExpression)
+	}
+	if t == nil {
+		return t
+	}
+	array, ok := t.(*types.Array)
+	if !ok {
+		c.assignable(n, t, x, "initializing %[1]s with an expression of type %[2]s")
+		return t
+	}
+
+	var literal = ast.StringLiteral(n.AssignmentExpression)
+	if literal == nil || !types.IsInteger(array.Elem) || c.target.Bits(array.Elem) != 8 {
+		c.diags.Errorf(n.Pos, "array of type %s must be initialized with a brace-enclosed list", t)
+		return t
+	}
+	var length = int64(len(*literal.StringLiteral))
+	switch {
+	case array.Kind == types.Unsized:
+		return &types.Array{Elem: array.Elem, Kind: types.Sized, Len: length + 1}
+	case array.Kind == types.Sized && length > array.Len:
+		c.diags.Warnf(n.Pos, "excess-initializers", "initializer string for array of type %s is too long", t)
+	}
+	return t
+}
+
+// aggregate checks the initializers of the elements or members of the
+// array, structure or union of type t, starting at inits[*idx]. The
+// braces around the initializers of nested aggregates may be left out,
+// in which case they take as many initializers as they need.
+func (c *checker) aggregate(t types.Type, inits []*ast.Initializer, idx *int) types.Type {
+	var element = func(elem types.Type) {
+		var init = inits[*idx]
+		_, isArray := elem.(*types.Array)
+		switch {
+		case init.InitializerList != nil || types.IsScalar(elem):
+			c.initialize(elem, init)
+			*idx++
+		case isArray && ast.StringLiteral(init.AssignmentExpression) != nil:
+			c.initialize(elem, init)
+			*idx++
+		case !isArray && c.initializesWhole(elem, init):
+			*idx++
+		default:
+			c.aggregate(elem, inits, idx)
+		}
+	}
+
+	switch u := underlying(t).(type) {
+	case *types.Array:
+		var count int64
+		for ; (u.Kind != types.Sized || count < u.Len) && *idx < len(inits); count++ {
+			element(u.Elem)
+		}
+		if u.Kind == types.Unsized {
+			return &types.Array{Elem: u.Elem, Kind: types.Sized, Len: count}
+		}
+	case *types.Struct:
+		if !u.Complete {
+			c.diags.Errorf(inits[*idx].Pos, "initializing incomplete type %s", t)
+			return t
+		}
+		for _, field := range u.Fields {
+			if *idx == len(inits) {
+				break
+			}
+			if field.BitField && field.Name == "" {
+				continue
+			}
+			element(field.Type)
+			if u.Union {
+				break
+			}
+		}
+	default:
+		c.diags.Errorf(inits[*idx].Pos, "invalid initializer for type %s", t)
+		*idx = len(inits)
+	}
+	return t
+}
+
+// initializesWhole checks whether the expression init initializes a
+// structure or union of type t as a whole, rather than its first member.
+// Otherwise the type of init is kept for when its member is reached.
+func (c *checker) initializesWhole(t types.Type, init *ast.Initializer) bool {
+	var x = c.assignment(init.AssignmentExpression)
+	if x.valid() && types.Compatible(underlying(x.Type), underlying(t)) {
+		return true
+	}
+	c.evaluated[init] = x
+	return false
+}
@@ -0,0 +1,81 @@
+package sema
+
+import (
+	"fmt"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/diag"
+	"lazarus-c/src/types"
+	"strings"
+	"testing"
+)
+
+// layouts lists structure and union definitions with their layout on
+// x86-64, as "size, align: field offset, ...", bit-fields at byte.bit.
+var layouts = []struct {
+	src    string
+	layout string
+}{
+	{"struct t { char c; int i; short s; };", "12, 4: c 0, i 4, s 8"},
+	{"struct t { char c; double d; char e[3]; };", "24, 8: c 0, d 8, e 16"},
+	{"struct t { int *p; char c; };", "16, 8: p 0, c 8"},
+	{"union t { char c[5]; int i; };", "8, 4: c 0, i 0"},
+	{"struct t { char a; struct { char b; long l; } in; char c; };", "32, 8: a 0, in 8, c 24"},
+
+	// Bit-fields share the unit of their type, and move to the next
+	// one rather than straddle two. Zero widths close the unit, and
+	// unnamed ones do not align the structure.
+	{"struct t { int a : 3, b : 5; char c; };", "4, 4: a 0.0, b 0.3, c 1"},
+	{"struct t { int a : 30, b : 4; };", "8, 4: a 0.0, b 4.0"},
+	{"struct t { char a : 4; int : 0; char b; };", "5, 1: a 0.0, b 4"},
+	{"struct t { char a; int : 4; };", "2, 1: a 0"},
+
+	// Flexible array members take no room but align the structure.
+	{"struct t { char c; double d[]; };", "8, 8: c 0, d 8"},
+
+	// Attributes.
+	{"struct t { char c; int i; } __attribute__((packed));", "5, 1: c 0, i 1"},
+	{"struct t { char c; int i __attribute__((packed)); short s; };", "8, 2: c 0, i 1, s 6"},
+	{"struct t { char c __attribute__((aligned(8))); };", "8, 8: c 0"},
+	{"struct t { char c; } __attribute__((aligned(16)));", "16, 16: c 0"},
+	{"struct t { char a; int b : 4, c : 6; } __attribute__((packed));", "3, 1: a 0, b 1.0, c 1.4"},
+
+	// Enumerations take the size of their underlying type.
+	{"enum e : unsigned char { A }; struct t { enum e a; enum e b; int i; };", "8, 4: a 0, b 1, i 4"},
+	{"enum e { A = 4294967296 }; struct t { char c; enum e e; };", "16, 8: c 0, e 8"},
+}
+
+func TestLayout(t *testing.T) {
+	for _, test := range layouts {
+		var unit, err = ast.ParseString(test.src)
+		if err != nil {
+			t.Errorf("parsing %q: %v", test.src, err)
+			continue
+		}
+		var diags diag.List
+		var info = (&Config{Target: types.X86_64}).Check(unit, &diags)
+		if diags.HasErrors() {
+			t.Errorf("%s: %v", test.src, diags)
+			continue
+		}
+		var s = info.Scope.LookupTag("t").Type.(*types.Struct)
+		var layout = types.X86_64.Layout(s)
+		var fields []string
+		for _, field := range layout.Fields {
+			if field.Name == "" {
+				continue
+			}
+			var offset = fmt.Sprint(field.Offset)
+			if field.BitField {
+				offset += fmt.Sprintf(".%d", field.Bit)
+			}
+			fields = append(fields, field.Name+" "+offset)
+		}
+		var got = fmt.Sprintf("%d, %d: %s", layout.Size, layout.Align, strings.Join(fields, ", "))
+		if got != test.layout {
+			t.Errorf("%s: laid out as\n\t%s\ninstead of\n\t%s", test.src, got, test.layout)
+		}
+		if size := types.X86_64.Sizeof(s); size != layout.Size {
+			t.Errorf("%s: has size %d instead of %d", test.src, size, layout.Size)
+		}
+	}
+}
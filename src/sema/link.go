@@ -0,0 +1,184 @@
+package sema
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/diag"
+	"lazarus-c/src/lexer"
+	"lazarus-c/src/types"
+	"sort"
+)
+
+// Unit is a translation unit and the information its checking recorded.
+type Unit struct {
+	Syntax *ast.TranslationUnit
+	Info   *Info
+}
+
+// external is what one translation unit declares of an identifier with
+// external linkage.
+type external struct {
+	obj *Object
+	// decl is the position of the first declaration, def the one of the
+	// definition with an initializer or a body, if any.
+	decl, def *lexer.Position
+	// tentative is set when the unit defines the variable without an
+	// initializer, extern when a declaration has the extern storage
+	// class or declares a function without storage class, which is
+	// extern all the same.
+	tentative, extern bool
+}
+
+// pos returns the position best describing e: its definition, or else its
+// first declaration.
+func (e *external) pos() lexer.Position {
+	if e.def != nil {
+		return *e.def
+	}
+	return *e.decl
+}
+
+// Link checks the consistency of units compiled into one program. An
+// identifier with external linkage must have compatible types in every
+// unit and at most one definition, and an identifier declared extern must
+// be defined in some unit, unless it is a function of the library.
+func Link(units []*Unit, diags *diag.List) {
+	var names []string
+	var declared = map[string][]*external{}
+	for _, unit := range units {
+		var externals = unit.externals()
+		for name, e := range externals {
+			if declared[name] == nil {
+				names = append(names, name)
+			}
+			declared[name] = append(declared[name], e)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var externals = declared[name]
+		var reference = definition(externals)
+
+		for _, e := range externals {
+			if e == reference {
+				continue
+			}
+			if e.obj.Kind != reference.obj.Kind || !types.CompatibleAcross(e.obj.Type, reference.obj.Type) {
+				diags.Errorf(e.pos(), "conflicting types for %s across translation units: %s", name, types.Declaration(e.obj.Type, name)).
+					Note(reference.pos(), "other declaration is %s", types.Declaration(reference.obj.Type, name))
+			}
+		}
+
+		var first *external
+		var defined = false
+		for _, e := range externals {
+			defined = defined || e.def != nil || e.tentative
+			if e.def == nil {
+				continue
+			}
+			if first != nil {
+				diags.Errorf(*e.def, "multiple definitions of %s", name).
+					Note(*first.def, "previous definition of %s is here", name)
+				continue
+			}
+			first = e
+		}
+		if defined || reference.obj.Kind == Function && libraryFunctions[name] != nil {
+			continue
+		}
+		for _, e := range externals {
+			switch {
+			case !e.extern:
+				continue
+			case e.obj.Kind == Function:
+				diags.Warnf(*e.decl, "undefined-external", "function %s is declared but never defined", name)
+			default:
+				diags.Warnf(*e.decl, "undefined-external", "%s is declared extern but never defined", name)
+			}
+			break
+		}
+	}
+}
+
+// definition returns the first of externals defining the identifier, or
+// else the first defining it tentatively, or else the first.
+func definition(externals []*external) *external {
+	var reference = externals[0]
+	for _, e := range externals {
+		if e.def != nil {
+			return e
+		}
+		if e.tentative && !reference.tentative {
+			reference = e
+		}
+	}
+	return reference
+}
+
+// externals returns what u declares of the identifiers with external
+// linkage, by name.
+func (u *Unit) externals() map[string]*external {
+	var externals = map[string]*external{}
+	var add = func(direct *ast.DirectDeclarator, specs *ast.DeclarationSpecifiers) *external {
+		var obj = u.Info.Defs[direct]
+		if obj == nil || !obj.Linkage() {
+			return nil
+		}
+		// A block-scope declaration of a static file-scope identifier
+		// has internal linkage too.
+		if outer := u.Info.Scope.Objects[obj.Name]; outer != nil && outer.Storage == Static {
+			return nil
+		}
+		var e = externals[obj.Name]
+		if e == nil {
+			e = &external{obj: obj, decl: &direct.Pos}
+			externals[obj.Name] = e
+		}
+		switch storageClass(specs) {
+		case Extern:
+			e.extern = true
+		case NoStorage:
+			e.extern = e.extern || obj.Kind == Function
+		}
+		return e
+	}
+
+	ast.Inspect(u.Syntax, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionDefinition:
+			var direct = ast.DeclaredName(n.Declarator)
+			if e := add(direct, n.DeclarationSpecifiers); e != nil {
+				e.def = &direct.Pos
+			}
+		case *ast.Declaration:
+			if n.InitDeclaratorList == nil {
+				return true
+			}
+			for _, d := range n.InitDeclaratorList.InitDeclarators {
+				var direct = ast.DeclaredName(d.Declarator)
+				var e = add(direct, n.DeclarationSpecifiers)
+				if e == nil || e.obj.Kind != Variable || u.Info.Defs[direct].Scope != u.Info.Scope {
+					continue
+				}
+				switch {
+				case d.Initializer != nil:
+					e.def = &direct.Pos
+				case storageClass(n.DeclarationSpecifiers) != Extern:
+					e.tentative = true
+				}
+			}
+		}
+		return true
+	})
+	return externals
+}
+
+// storageClass returns the storage class given by specs.
+func storageClass(specs *ast.DeclarationSpecifiers) Storage {
+	for ; specs != nil; specs = specs.DeclarationSpecifiers {
+		if specs.StorageClassSpecifier != nil {
+			return storageClasses[*specs.StorageClassSpecifier]
+		}
+	}
+	return NoStorage
+}
@@ -0,0 +1,91 @@
+package sema
+
+import (
+	"fmt"
+	"lazarus-c/src/ast"
+	"lazarus-c/src/diag"
+	"reflect"
+	"testing"
+)
+
+// links lists programs made of several units with the diagnostics of
+// linking them, as "unit:line:column: message".
+var links = []struct {
+	name  string
+	units []string
+	diags []string
+}{
+	{
+		"consistent",
+		[]string{
+			"int n; int f(int); int main(void) { return f(n); }",
+			"int n = 1; static int g(void) { return 0; } int f(int x) { return x + g(); }",
+			"int n; static int g(void) { return 1; }",
+		},
+		nil,
+	},
+	{
+		"conflicting types",
+		[]string{
+			"extern long n; int f(int);",
+			"int n; int f(int x) { return x; }",
+		},
+		[]string{
+			"0:1:13: conflicting types for n across translation units: long n",
+		},
+	},
+	{
+		"multiple definitions",
+		[]string{
+			"int n = 1; int f(void) { return 0; }",
+			"int n = 2; int f(void) { return 1; }",
+		},
+		[]string{
+			"1:1:5: multiple definitions of n",
+			"1:1:16: multiple definitions of f",
+		},
+	},
+	{
+		"undefined externals",
+		[]string{
+			"extern int n; int g(void); int f(void) { return g() + n; }",
+			"int printf(const char *, ...); extern void *malloc(unsigned long); void h(void) { int k(int); printf(\"%d\", k(f())); }",
+		},
+		[]string{
+			"0:1:12: n is declared extern but never defined",
+			"0:1:19: function g is declared but never defined",
+			"1:1:87: function k is declared but never defined",
+		},
+	},
+}
+
+func TestLink(t *testing.T) {
+	for _, test := range links {
+		var units []*Unit
+		var files = map[string]int{}
+		for idx, src := range test.units {
+			var name = fmt.Sprint(idx)
+			var unit, err = ast.ParseBytes(name, []byte(src))
+			if err != nil {
+				t.Fatalf("%s: parsing unit %d: %v", test.name, idx, err)
+			}
+			var diags diag.List
+			var info = (&Config{Source: []byte(src)}).Check(unit, &diags)
+			if diags.HasErrors() {
+				t.Fatalf("%s: checking unit %d: %v", test.name, idx, diags)
+			}
+			units = append(units, &Unit{Syntax: unit, Info: info})
+			files[name] = idx
+		}
+		var diags diag.List
+		Link(units, &diags)
+		diags.Sort()
+		var got []string
+		for _, d := range diags {
+			got = append(got, fmt.Sprintf("%d:%d:%d: %s", files[d.Pos.Filename], d.Pos.Line, d.Pos.Column, d.Message))
+		}
+		if !reflect.DeepEqual(got, test.diags) {
+			t.Errorf("%s: got diagnostics\n\t%q\ninstead of\n\t%q", test.name, got, test.diags)
+		}
+	}
+}
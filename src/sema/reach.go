@@ -0,0 +1,141 @@
+package sema
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/cfg"
+	"lazarus-c/src/types"
+	"sort"
+)
+
+// noReturnFunctions are the library functions that never return.
+var noReturnFunctions = map[string]bool{
+	"abort":   true,
+	"exit":    true,
+	"_Exit":   true,
+	"longjmp": true,
+}
+
+// FlowConfig returns the configuration building control-flow graphs that
+// know the constant conditions and the calls to exit, abort and longjmp
+// recorded in info.
+func FlowConfig(info *Info) *cfg.Config {
+	return &cfg.Config{
+		Constant: func(n ast.Node) (int64, bool) {
+			var x, ok = info.Types[n]
+			if !ok || !x.Constant || !types.IsInteger(x.Type) {
+				return 0, false
+			}
+			return x.Value, true
+		},
+		NoReturn: func(n *ast.Expression) bool {
+			var call, ok = operand(n).(*ast.PostfixExpression)
+			if !ok || len(call.PostfixOperators) != 1 || !call.PostfixOperators[0].IsCall {
+				return false
+			}
+			var callee = info.Uses[call.PrimaryExpression]
+			return callee != nil && callee.Kind == Function && callee.Scope.Kind == FileScope && noReturnFunctions[callee.Name]
+		},
+	}
+}
+
+// operand returns the innermost node of n holding all of it, looking
+// through parentheses.
+func operand(n ast.Node) ast.Node {
+	for {
+		var children = ast.Children(n)
+		if len(children) != 1 {
+			return n
+		}
+		if _, ok := children[0].(*ast.TypeName); ok {
+			return n
+		}
+		n = children[0]
+	}
+}
+
+// flow runs the analyses of the control-flow graph of the function
+// definition n.
+func (c *checker) flow(n *ast.FunctionDefinition) {
+	var g = FlowConfig(c.info).New(n)
+	c.reachability(n, g)
+	c.initialization(n.CompoundStatement, g)
+	c.deadStores(n.CompoundStatement, g)
+}
+
+// reachability warns about the code of the function definition n that
+// can never be executed, and about the end of its body when a function
+// returning a value can reach it.
+func (c *checker) reachability(n *ast.FunctionDefinition, g *cfg.CFG) {
+	if g.End.Live && !types.IsVoid(c.function.Result) && c.name != "main" {
+		var brace = n.CompoundStatement.EndPos
+		brace.Offset--
+		brace.Column--
+		c.diags.Warnf(brace, "return-type", "control reaches end of non-void function %s", c.name)
+	}
+
+	// The blocks that a constant condition skips, like the body of
+	// if (0) or the code following while (1), are left out on purpose.
+	var covered = map[*cfg.Block]bool{}
+	var cover func(block *cfg.Block)
+	cover = func(block *cfg.Block) {
+		if block.Live || covered[block] {
+			return
+		}
+		covered[block] = true
+		for _, succ := range block.Succs {
+			cover(succ)
+		}
+	}
+	for _, block := range g.Blocks {
+		if block.Pruned != nil {
+			cover(block.Pruned)
+		}
+	}
+
+	// Only the first statement of every stretch of dead code is reported.
+	var dead []*cfg.Block
+	for _, block := range g.Blocks {
+		if !block.Live && !covered[block] && deadCode(g, block) != nil {
+			dead = append(dead, block)
+		}
+	}
+	sort.SliceStable(dead, func(i, j int) bool {
+		return ast.Pos(deadCode(g, dead[i])).Offset < ast.Pos(deadCode(g, dead[j])).Offset
+	})
+	for _, block := range dead {
+		if covered[block] {
+			continue
+		}
+		c.diags.Warnf(ast.Pos(deadCode(g, block)), "unreachable-code", "code will never be executed")
+		cover(block)
+	}
+}
+
+// deadCode returns the first node of block worth reporting when it is
+// unreachable. Declarations without initializers do nothing, a break
+// following a return is a common style, and the increment of a loop or
+// the test of a do statement whose body never completes, as in the
+// do { ... } while (0) of macros, say nothing about the code written.
+// The operands of &&, || and ?: stand for their full expression.
+func deadCode(g *cfg.CFG, block *cfg.Block) ast.Node {
+	if block.Kind == cfg.KindForPost || block.Kind == cfg.KindDoTest {
+		return nil
+	}
+	for _, n := range block.Nodes {
+		if full := g.Full[n]; full != nil {
+			n = full
+		}
+		switch n := n.(type) {
+		case *ast.InitDeclarator:
+			if n.Initializer == nil {
+				continue
+			}
+		case *ast.JumpStatement:
+			if n.IsBreak {
+				continue
+			}
+		}
+		return n
+	}
+	return nil
+}
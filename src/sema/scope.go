@@ -0,0 +1,149 @@
+package sema
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/lexer"
+	"lazarus-c/src/types"
+)
+
+type ObjectKind int
+
+const (
+	Variable ObjectKind = iota
+	Function
+	Parameter
+	EnumConstant
+	Tag
+	Label
+)
+
+var objectKinds = [...]string{
+	Variable:     "variable",
+	Function:     "function",
+	Parameter:    "parameter",
+	EnumConstant: "enumeration constant",
+	Tag:          "tag",
+	Label:        "label",
+}
+
+func (k ObjectKind) String() string {
+	return objectKinds[k]
+}
+
+// Object is a named entity of the program: a variable, function,
+// parameter, enumeration constant, tag or label.
+type Object struct {
+	Kind ObjectKind
+	Name string
+	// Type is nil for labels.
+	Type    types.Type
+	Storage Storage
+	// Pos is the position of the name in the first declaration.
+	Pos lexer.Position
+	// Decl is the node of the first declaration: the direct declarator
+	// holding the name, the enumerator, the structure, union or enum
+	// specifier or the labeled statement.
+	Decl ast.Node
+	// Defined is set for functions with a body, variables with storage
+	// and labels that exist.
+	Defined bool
+	// Value is the value of an enumeration constant.
+	Value int64
+	// Unused is set when a declaration of o has the unused attribute,
+	// which silences the warnings about o not being used.
+	Unused bool
+	// Silenced holds the names of the warnings about o silenced by the
+	// nowarn attribute of one of its declarations.
+	Silenced map[string]bool
+	// Format is set for functions declared with the format attribute.
+	Format *Format
+	Scope  *Scope
+}
+
+// Linkage reports whether declarations of o in different scopes refer to
+// the same entity.
+func (o *Object) Linkage() bool {
+	switch o.Kind {
+	case Function:
+		return true
+	case Variable:
+		return o.Scope.Kind == FileScope || o.Storage == Extern
+	}
+	return false
+}
+
+type ScopeKind int
+
+const (
+	FileScope ScopeKind = iota
+	// FunctionScope only holds the labels of a function.
+	FunctionScope
+	BlockScope
+	PrototypeScope
+)
+
+// Scope is a region of the program where declarations are visible. Tags
+// and ordinary identifiers live in separate namespaces of the same
+// scope.
+type Scope struct {
+	Kind   ScopeKind
+	Parent *Scope
+	// Node is the translation unit, function definition, compound
+	// statement or function declarator suffix of the scope.
+	Node     ast.Node
+	Objects  map[string]*Object
+	Tags     map[string]*Object
+	Children []*Scope
+}
+
+func newScope(kind ScopeKind, parent *Scope, node ast.Node) *Scope {
+	var s = &Scope{Kind: kind, Parent: parent, Node: node, Objects: map[string]*Object{}, Tags: map[string]*Object{}}
+	if parent != nil {
+		parent.Children = append(parent.Children, s)
+	}
+	return s
+}
+
+func (s *Scope) insert(obj *Object) {
+	obj.Scope = s
+	if obj.Kind == Tag {
+		s.Tags[obj.Name] = obj
+	} else {
+		s.Objects[obj.Name] = obj
+	}
+}
+
+// Lookup returns the ordinary identifier visible in s with the given
+// name, or nil.
+func (s *Scope) Lookup(name string) *Object {
+	for ; s != nil; s = s.Parent {
+		if s.Kind == FunctionScope {
+			continue
+		}
+		if obj := s.Objects[name]; obj != nil {
+			return obj
+		}
+	}
+	return nil
+}
+
+// LookupTag returns the tag visible in s with the given name, or nil.
+func (s *Scope) LookupTag(name string) *Object {
+	for ; s != nil; s = s.Parent {
+		if obj := s.Tags[name]; obj != nil {
+			return obj
+		}
+	}
+	return nil
+}
+
+// LookupLabel returns the label of the function enclosing s with the
+// given name, or nil.
+func (s *Scope) LookupLabel(name string) *Object {
+	for ; s != nil; s = s.Parent {
+		if s.Kind == FunctionScope {
+			return s.Objects[name]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,705 @@
+// Package sema implements the semantic analysis of C programs: the types
+// of declarations, scopes and name resolution, and the checks of
+// expressions and statements.
+package sema
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/diag"
+	"lazarus-c/src/lexer"
+	"lazarus-c/src/types"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Storage is the storage class given by declaration specifiers.
+type Storage int
+
+const (
+	NoStorage Storage = iota
+	Extern
+	Static
+	Auto
+	Register
+)
+
+// Tags declares and finds the tags of structures, unions and
+// enumerations.
+type Tags interface {
+	// LookupTag returns the type named by tag, searching only the
+	// current scope when local is set, or nil.
+	LookupTag(tag string, local bool) types.Type
+	// DeclareTag declares tag in the current scope for the type of the
+	// specifier n.
+	DeclareTag(tag string, t types.Type, n ast.Node)
+}
+
+// fileTags is a single scope of tags.
+type fileTags map[string]types.Type
+
+func (tags fileTags) LookupTag(tag string, local bool) types.Type {
+	return tags[tag]
+}
+
+func (tags fileTags) DeclareTag(tag string, t types.Type, n ast.Node) {
+	tags[tag] = t
+}
+
+// Typer builds the types described by declaration specifiers and
+// declarators.
+type Typer struct {
+	Tags Tags
+	// Target sets the ranges of the integer types. X86_64 is used when
+	// nil.
+	Target *types.Target
+	// Constant evaluates the integer constant expressions of array
+	// lengths, bit-field widths and enumeration values. When the
+	// expression is not constant, it reports why and returns false, and
+	// arrays have variable length.
+	Constant func(n *ast.ConstantExpression) (int64, bool)
+	// Length, when set, evaluates the lengths of arrays other than
+	// structure members in place of Constant. It may accept lengths which
+	// are not constant, returning false without a report, for arrays of
+	// variable length.
+	Length func(n *ast.ConstantExpression) (int64, bool)
+	// Variable, when set, is called for each array of variable length
+	// with the expression of its length.
+	Variable func(n *ast.ConstantExpression, array *types.Array)
+	// Enumerator, when set, is called for each enumeration constant as
+	// soon as its value is known, so that the next ones can refer to it.
+	Enumerator func(n *ast.Enumerator, constant *types.EnumConstant, enum *types.Enum)
+	// Prototype, when set, is called before the parameters of a function
+	// declarator are processed, and the function it returns after, with
+	// the resulting parameters.
+	Prototype func(n *ast.DeclaratorSuffix) func(params []*types.Param)
+	// Parameter, when set, is called for each named parameter of a
+	// prototype as soon as its type is known, so that the lengths of the
+	// next ones can refer to it.
+	Parameter   func(n *ast.ParameterDeclaration, param *types.Param)
+	Diagnostics *diag.List
+
+	// members counts the structure definitions whose members are being
+	// processed.
+	members int
+}
+
+// NewTyper returns a Typer keeping tags in a single scope and only
+// evaluating integer literals, reporting errors to diags.
+func NewTyper(diags *diag.List) *Typer {
+	var t = &Typer{Tags: fileTags{}, Diagnostics: diags}
+	t.Constant = t.literalConstant
+	return t
+}
+
+// Declaration returns the name and type declared by a declarator with
+// the given specifiers, along with the storage class.
+func (t *Typer) Declaration(specs *ast.DeclarationSpecifiers, d *ast.Declarator) (string, types.Type, Storage) {
+	var base, storage = t.Specifiers(specs)
+	var name, typ = t.Declarator(base, d)
+	return name, typ, storage
+}
+
+// Specifiers returns the type and storage class given by specs. A missing
+// type specifier means int.
+func (t *Typer) Specifiers(specs *ast.DeclarationSpecifiers) (types.Type, Storage) {
+	var pos = specs.Pos
+	var typeSpecs []*ast.TypeSpecifier
+	var quals types.Qualifiers
+	var storage = NoStorage
+	for ; specs != nil; specs = specs.DeclarationSpecifiers {
+		switch {
+		case specs.StorageClassSpecifier != nil:
+			if storage != NoStorage {
+				t.Diagnostics.Errorf(specs.Pos, "multiple storage classes in declaration specifiers")
+			}
+			storage = storageClasses[*specs.StorageClassSpecifier]
+		case specs.TypeSpecifier != nil:
+			typeSpecs = append(typeSpecs, specs.TypeSpecifier)
+		case specs.TypeQualifier != nil:
+			quals |= qualifier(*specs.TypeQualifier.Qualifier)
+		}
+	}
+	return types.Qualify(t.typeSpecifiers(pos, typeSpecs), quals), storage
+}
+
+var storageClasses = map[string]Storage{
+	"extern":   Extern,
+	"static":   Static,
+	"auto":     Auto,
+	"register": Register,
+}
+
+// SpecifierQualifiers returns the type given by the specifiers of a type
+// name or structure member.
+func (t *Typer) SpecifierQualifiers(list *ast.SpecifierQualifierList) types.Type {
+	var pos = list.Pos
+	var typeSpecs []*ast.TypeSpecifier
+	var quals types.Qualifiers
+	for ; list != nil; list = list.SpecifierQualifierList {
+		if list.TypeSpecifier != nil {
+			typeSpecs = append(typeSpecs, list.TypeSpecifier)
+		} else {
+			quals |= qualifier(*list.TypeQualifier.Qualifier)
+		}
+	}
+	return types.Qualify(t.typeSpecifiers(pos, typeSpecs), quals)
+}
+
+func qualifier(keyword string) types.Qualifiers {
+	if keyword == "const" {
+		return types.Const
+	}
+	return types.Volatile
+}
+
+// specifierKinds maps the valid combinations of basic type specifiers,
+// sorted as in specifierOrder, to their types.
+var specifierKinds = map[string]types.Kind{
+	"void":                   types.Void,
+	"char":                   types.Char,
+	"signed char":            types.SignedChar,
+	"unsigned char":          types.UnsignedChar,
+	"short":                  types.Short,
+	"short int":              types.Short,
+	"signed short":           types.Short,
+	"signed short int":       types.Short,
+	"unsigned short":         types.UnsignedShort,
+	"unsigned short int":     types.UnsignedShort,
+	"int":                    types.Int,
+	"signed":                 types.Int,
+	"signed int":             types.Int,
+	"unsigned":               types.UnsignedInt,
+	"unsigned int":           types.UnsignedInt,
+	"long":                   types.Long,
+	"long int":               types.Long,
+	"signed long":            types.Long,
+	"signed long int":        types.Long,
+	"unsigned long":          types.UnsignedLong,
+	"unsigned long int":      types.UnsignedLong,
+	"long long":              types.LongLong,
+	"long long int":          types.LongLong,
+	"signed long long":       types.LongLong,
+	"signed long long int":   types.LongLong,
+	"unsigned long long":     types.UnsignedLongLong,
+	"unsigned long long int": types.UnsignedLongLong,
+	"float":                  types.Float,
+	"double":                 types.Double,
+	"long double":            types.LongDouble,
+}
+
+var specifierOrder = map[string]int{
+	"signed": 0, "unsigned": 1, "short": 2, "long": 3, "char": 4,
+	"int": 5, "float": 6, "double": 7, "void": 8,
+}
+
+func (t *Typer) typeSpecifiers(pos lexer.Position, specs []*ast.TypeSpecifier) types.Type {
+	var keywords []string
+	for _, spec := range specs {
+		switch {
+		case spec.StructOrUnionSpecifier != nil || spec.EnumSpecifier != nil:
+			if len(specs) > 1 {
+				t.Diagnostics.Errorf(spec.Pos, "invalid combination of type specifiers")
+			}
+			if spec.StructOrUnionSpecifier != nil {
+				return t.structSpecifier(spec.StructOrUnionSpecifier)
+			}
+			return t.enumSpecifier(spec.EnumSpecifier)
+		default:
+			keywords = append(keywords, *spec.TypeSpecifier)
+		}
+	}
+
+	if len(keywords) == 0 {
+		t.Diagnostics.Warnf(pos, "implicit-int", "type specifier missing, defaults to int")
+		return types.Typ[types.Int]
+	}
+	sort.SliceStable(keywords, func(i, j int) bool {
+		return specifierOrder[keywords[i]] < specifierOrder[keywords[j]]
+	})
+	var kind, ok = specifierKinds[strings.Join(keywords, " ")]
+	if !ok {
+		t.Diagnostics.Errorf(pos, "invalid combination of type specifiers %s", strings.Join(keywords, " "))
+		return types.Typ[types.Int]
+	}
+	return types.Typ[kind]
+}
+
+// structSpecifier returns the type named or defined by n. A specifier
+// with a body defines a new type unless a declaration without body in
+// the same scope announced it; one without body refers to the visible
+// type with that tag, or declares it.
+func (t *Typer) structSpecifier(n *ast.StructOrUnionSpecifier) types.Type {
+	var union = *n.StructOrUnion == "union"
+	var tag = ""
+	if n.Identifier != nil {
+		tag = *n.Identifier
+	}
+
+	var typ *types.Struct
+	if tag != "" {
+		var previous = t.Tags.LookupTag(tag, n.StructDeclarationList != nil)
+		if s, ok := previous.(*types.Struct); ok && s.Union == union {
+			typ = s
+		} else if previous != nil && (n.StructDeclarationList != nil || !ok || s.Union != union) {
+			t.Diagnostics.Errorf(n.Pos, "%s %s redeclared as a different kind of tag", *n.StructOrUnion, tag)
+		}
+	}
+	if typ != nil && typ.Complete && n.StructDeclarationList != nil {
+		t.Diagnostics.Errorf(n.Pos, "redefinition of %s", typ)
+		typ = nil
+	}
+	if typ == nil {
+		typ = &types.Struct{Union: union, Tag: tag}
+		if tag != "" {
+			t.Tags.DeclareTag(tag, typ, n)
+		}
+	}
+
+	if n.StructDeclarationList != nil {
+		t.fields(typ, n.StructDeclarationList)
+	}
+	for _, attrs := range []*ast.Attributes{n.Attributes, n.TrailingAttributes} {
+		t.attributes(attrs, &typ.Packed, &typ.Align)
+	}
+	return typ
+}
+
+// maxAlign is the largest alignment of the basic types of the supported
+// targets, given to aligned attributes without argument.
+const maxAlign = 16
+
+// attributes applies the packed and aligned attributes of n, if any, to
+// the alignment of a structure or field.
+func (t *Typer) attributes(n *ast.Attributes, packed *bool, align *int64) {
+	if n == nil {
+		return
+	}
+	for _, a := range n.Attributes {
+		switch *a.Name {
+		case "packed":
+			if len(a.Arguments) > 0 {
+				t.Diagnostics.Errorf(a.Pos, "attribute packed takes no arguments")
+			}
+			*packed = true
+		case "aligned":
+			switch len(a.Arguments) {
+			case 0:
+				*align = max(*align, maxAlign)
+			case 1:
+				var value, ok = t.Constant(a.Arguments[0])
+				if ok && (value <= 0 || value&(value-1) != 0) {
+					t.Diagnostics.Errorf(a.Arguments[0].Pos, "requested alignment %d is not a positive power of 2", value)
+				} else if ok {
+					*align = max(*align, value)
+				}
+			default:
+				t.Diagnostics.Errorf(a.Pos, "attribute aligned takes one argument")
+			}
+		default:
+			if !knownAttributes[*a.Name] {
+				t.Diagnostics.Warnf(a.Pos, "attributes", "unknown attribute %s ignored", *a.Name)
+			}
+		}
+	}
+}
+
+func (t *Typer) fields(typ *types.Struct, list *ast.StructDeclarationList) {
+	t.members++
+	defer func() { t.members-- }()
+	var seen = map[string]*ast.StructDeclarator{}
+	var decls = list.StructDeclarations
+	for idx, decl := range decls {
+		var base = t.SpecifierQualifiers(decl.SpecifierQualifierList)
+		var declarators = decl.StructDeclaratorList.StructDeclarators
+		for _idx, d := range declarators {
+			var field = &types.Field{Type: base}
+			if d.Declarator != nil {
+				field.Name, field.Type = t.Declarator(base, d.Declarator)
+			}
+			if previous := seen[field.Name]; previous != nil {
+				t.Diagnostics.Errorf(d.Pos, "duplicate member %s", field.Name).
+					Note(previous.Pos, "previous declaration of %s is here", field.Name)
+			} else if field.Name != "" {
+				seen[field.Name] = d
+			}
+
+			t.attributes(d.Attributes, &field.Packed, &field.Align)
+			if d.ConstantExpression != nil {
+				t.bitField(field, d)
+			} else if array, ok := field.Type.(*types.Array); ok && array.Kind == types.Unsized &&
+				!typ.Union && idx == len(decls)-1 && _idx == len(declarators)-1 && len(typ.Fields) > 0 {
+				// A flexible array member.
+			} else if _, ok := field.Type.(*types.Function); ok {
+				t.Diagnostics.Errorf(d.Pos, "field %s declared as a function", field.Name)
+			} else if !types.IsComplete(field.Type) {
+				t.Diagnostics.Errorf(d.Pos, "field %s has incomplete type %s", field.Name, field.Type)
+			}
+			typ.Fields = append(typ.Fields, field)
+		}
+	}
+	typ.Complete = true
+}
+
+func (t *Typer) bitField(field *types.Field, d *ast.StructDeclarator) {
+	field.BitField = true
+	if !types.IsInteger(field.Type) {
+		t.Diagnostics.Errorf(d.Pos, "bit-field %s has invalid type %s", field.Name, field.Type)
+	}
+	var bits, ok = t.Constant(d.ConstantExpression)
+	switch {
+	case !ok:
+	case bits < 0:
+		t.Diagnostics.Errorf(d.ConstantExpression.Pos, "bit-field %s has negative width", field.Name)
+	case bits == 0 && field.Name != "":
+		t.Diagnostics.Errorf(d.ConstantExpression.Pos, "named bit-field %s has zero width", field.Name)
+	case types.IsInteger(field.Type) && bits > int64(t.target().Bits(field.Type)):
+		t.Diagnostics.Errorf(d.ConstantExpression.Pos, "width of bit-field exceeds width of its type")
+	default:
+		field.Bits = int(bits)
+	}
+}
+
+// enumSpecifier returns the type named or defined by n, like
+// structSpecifier.
+func (t *Typer) enumSpecifier(n *ast.EnumSpecifier) types.Type {
+	var tag = ""
+	if n.Identifier != nil {
+		tag = *n.Identifier
+	}
+
+	var typ *types.Enum
+	if tag != "" {
+		var previous = t.Tags.LookupTag(tag, n.EnumeratorList != nil)
+		if e, ok := previous.(*types.Enum); ok {
+			typ = e
+		} else if previous != nil {
+			t.Diagnostics.Errorf(n.Pos, "enum %s redeclared as a different kind of tag", tag)
+		}
+	}
+	if typ != nil && typ.Complete && n.EnumeratorList != nil {
+		t.Diagnostics.Errorf(n.Pos, "redefinition of %s", typ)
+		typ = nil
+	}
+	if typ == nil {
+		typ = &types.Enum{Tag: tag, Underlying: types.Typ[types.Int]}
+		if tag != "" {
+			t.Tags.DeclareTag(tag, typ, n)
+		}
+	}
+
+	if n.EnumBase != nil && !typ.Complete {
+		typ.Underlying, typ.Fixed = t.enumBase(n.EnumBase), true
+	} else if n.EnumBase != nil {
+		t.Diagnostics.Errorf(n.EnumBase.Pos, "underlying type given after the definition of %s", typ)
+	}
+	if n.EnumeratorList != nil {
+		t.enumerators(typ, n.EnumeratorList)
+	}
+	return typ
+}
+
+// exactWidthTypes are the integer types of <stdint.h> accepted as the
+// underlying type of enumerations.
+var exactWidthTypes = map[string]types.Kind{
+	"int8_t":   types.SignedChar,
+	"uint8_t":  types.UnsignedChar,
+	"int16_t":  types.Short,
+	"uint16_t": types.UnsignedShort,
+	"int32_t":  types.Int,
+	"uint32_t": types.UnsignedInt,
+	"int64_t":  types.Long,
+	"uint64_t": types.UnsignedLong,
+}
+
+// enumBase returns the underlying type given by n.
+func (t *Typer) enumBase(n *ast.EnumBase) *types.Basic {
+	if n.Identifier != nil {
+		var kind, ok = exactWidthTypes[*n.Identifier]
+		if !ok {
+			t.Diagnostics.Errorf(n.Pos, "unknown type name %s", *n.Identifier)
+			return types.Typ[types.Int]
+		}
+		return types.Typ[kind]
+	}
+	var base, _ = types.Unqualified(t.SpecifierQualifiers(n.SpecifierQualifierList))
+	if basic, ok := base.(*types.Basic); ok && types.IsInteger(basic) {
+		return basic
+	}
+	t.Diagnostics.Errorf(n.Pos, "invalid underlying type %s of enumeration", base)
+	return types.Typ[types.Int]
+}
+
+// enumerators gives their values to the constants of list, each one
+// following the previous unless it has an expression. The values must
+// fit the underlying type when it is fixed, or int as the standard
+// requires. Otherwise, the underlying type is the first of unsigned int,
+// int, long and unsigned long holding all the values.
+func (t *Typer) enumerators(typ *types.Enum, list *ast.EnumeratorList) {
+	var value int64
+	var intType = types.Typ[types.Int]
+	for idx, e := range list.Enumerators {
+		var pos = e.Pos
+		if e.ConstantExpression != nil {
+			value, _ = t.Constant(e.ConstantExpression)
+			pos = e.ConstantExpression.Pos
+		} else if idx > 0 && value == math.MinInt64 {
+			t.Diagnostics.Errorf(pos, "overflow in enumeration value of %s", *e.Identifier)
+		}
+		switch {
+		case typ.Fixed && !fits(t.target(), value, typ.Underlying):
+			t.Diagnostics.Errorf(pos, "enumerator value %d of %s is outside the range of underlying type %s", value, *e.Identifier, typ.Underlying)
+		case !typ.Fixed && !fits(t.target(), value, intType):
+			t.Diagnostics.Warnf(pos, "enum-range", "enumerator value %d of %s is outside the range of int", value, *e.Identifier)
+		}
+
+		var constant = &types.EnumConstant{Name: *e.Identifier, Value: value}
+		typ.Constants = append(typ.Constants, constant)
+		if t.Enumerator != nil {
+			t.Enumerator(e, constant, typ)
+		}
+		value++
+	}
+
+	if !typ.Fixed {
+		typ.Underlying = types.Typ[types.UnsignedLong]
+		for _, kind := range []types.Kind{types.UnsignedInt, types.Int, types.Long} {
+			var fitsAll = true
+			for _, c := range typ.Constants {
+				fitsAll = fitsAll && fits(t.target(), c.Value, types.Typ[kind])
+			}
+			if fitsAll {
+				typ.Underlying = types.Typ[kind]
+				break
+			}
+		}
+	}
+	typ.Complete = true
+}
+
+func (t *Typer) target() *types.Target {
+	if t.Target == nil {
+		return types.X86_64
+	}
+	return t.Target
+}
+
+// fits reports whether the integer type typ can represent value.
+func fits(target *types.Target, value int64, typ types.Type) bool {
+	var bits = target.Bits(typ)
+	if target.IsSigned(typ) {
+		return bits >= 64 || -1<<(bits-1) <= value && value < 1<<(bits-1)
+	}
+	return value >= 0 && (bits >= 64 || value < 1<<bits)
+}
+
+// Declarator returns the name and type declared by d for the type base
+// given by the specifiers.
+func (t *Typer) Declarator(base types.Type, d *ast.Declarator) (string, types.Type) {
+	var typ = t.pointer(base, d.Pointer)
+	var direct = d.DirectDeclarators[0]
+	typ = t.suffixes(typ, direct.DeclaratorSuffixes)
+	if direct.Declarator != nil {
+		return t.Declarator(typ, direct.Declarator)
+	}
+	return *direct.Identifier, typ
+}
+
+// AbstractDeclarator returns the type described by d for the type base.
+func (t *Typer) AbstractDeclarator(base types.Type, d *ast.AbstractDeclarator) types.Type {
+	var typ = t.pointer(base, d.Pointer)
+	var direct = d.DirectAbstractDeclarator
+	if direct == nil {
+		return typ
+	}
+	typ = t.suffixes(typ, direct.DeclaratorSuffixes)
+	if direct.AbstractDeclarator != nil {
+		return t.AbstractDeclarator(typ, direct.AbstractDeclarator)
+	}
+	return typ
+}
+
+// TypeName returns the type named in a cast or sizeof expression.
+func (t *Typer) TypeName(n *ast.TypeName) types.Type {
+	var typ = t.SpecifierQualifiers(n.SpecifierQualifierList)
+	if n.AbstractDeclarator != nil {
+		typ = t.AbstractDeclarator(typ, n.AbstractDeclarator)
+	}
+	return typ
+}
+
+// pointer derives the pointer types of p from base, the leftmost star
+// applying first.
+func (t *Typer) pointer(base types.Type, p *ast.Pointer) types.Type {
+	for ; p != nil; p = p.Pointer {
+		var quals types.Qualifiers
+		if p.TypeQualifierList != nil {
+			for _, q := range p.TypeQualifierList.TypeQualifiers {
+				quals |= qualifier(*q.Qualifier)
+			}
+		}
+		base = types.Qualify(&types.Pointer{Elem: base}, quals)
+	}
+	return base
+}
+
+// suffixes derives array and function types from base. The suffix
+// nearest to the name applies last: int a[2][3] is an array of two arrays
+// of three integers.
+func (t *Typer) suffixes(base types.Type, suffixes []*ast.DeclaratorSuffix) types.Type {
+	for idx := len(suffixes) - 1; idx >= 0; idx-- {
+		var suffix = suffixes[idx]
+		if suffix.IsArray {
+			base = t.array(base, suffix)
+		} else {
+			base = t.function(base, suffix)
+		}
+	}
+	return base
+}
+
+func (t *Typer) array(elem types.Type, suffix *ast.DeclaratorSuffix) types.Type {
+	if _, ok := elem.(*types.Function); ok {
+		t.Diagnostics.Errorf(suffix.Pos, "declaration of array of functions")
+	} else if !types.IsComplete(elem) {
+		t.Diagnostics.Errorf(suffix.Pos, "array has incomplete element type %s", elem)
+	}
+
+	var array = &types.Array{Elem: elem, Kind: types.Unsized}
+	if suffix.ArrayLength != nil {
+		var constant = t.Constant
+		if t.Length != nil && t.members == 0 {
+			constant = t.Length
+		}
+		var length, ok = constant(suffix.ArrayLength)
+		switch {
+		case !ok:
+			array.Kind = types.VariableLength
+			if t.Variable != nil {
+				t.Variable(suffix.ArrayLength, array)
+			}
+		case length < 0:
+			t.Diagnostics.Errorf(suffix.ArrayLength.Pos, "array has negative size")
+		default:
+			array.Kind, array.Len = types.Sized, length
+		}
+	}
+	return array
+}
+
+func (t *Typer) function(result types.Type, suffix *ast.DeclaratorSuffix) types.Type {
+	switch result.(type) {
+	case *types.Array:
+		t.Diagnostics.Errorf(suffix.Pos, "function cannot return array type %s", result)
+	case *types.Function:
+		t.Diagnostics.Errorf(suffix.Pos, "function cannot return function type %s", result)
+	}
+
+	var function = &types.Function{Result: result}
+	if suffix.ParameterTypeList != nil {
+		var done func([]*types.Param)
+		if t.Prototype != nil {
+			done = t.Prototype(suffix)
+		}
+		function.Prototype = true
+		function.Params, function.Variadic = t.Parameters(suffix.ParameterTypeList)
+		if done != nil {
+			done(function.Params)
+		}
+	}
+	return function
+}
+
+// Parameters returns the parameters of a prototype, with array and
+// function types adjusted to pointers. A lone void parameter stands for
+// an empty list.
+func (t *Typer) Parameters(list *ast.ParameterTypeList) ([]*types.Param, bool) {
+	var decls = list.ParameterList.ParameterDeclarations
+	var params []*types.Param
+	for _, decl := range decls {
+		var typ, storage = t.Specifiers(decl.DeclarationSpecifiers)
+		if storage != NoStorage && storage != Register {
+			t.Diagnostics.Errorf(decl.Pos, "invalid storage class for parameter")
+		}
+		var param = &types.Param{Type: typ}
+		switch {
+		case decl.Declarator != nil:
+			param.Name, param.Type = t.Declarator(typ, decl.Declarator)
+		case decl.AbstractDeclarator != nil:
+			param.Type = t.AbstractDeclarator(typ, decl.AbstractDeclarator)
+		case types.IsVoid(typ) && len(decls) == 1 && !list.Ellipsis:
+			return nil, false
+		}
+
+		if types.IsVoid(param.Type) {
+			t.Diagnostics.Errorf(decl.Pos, "parameter has void type")
+		}
+		param.Type = AdjustParameter(param.Type)
+		params = append(params, param)
+		if t.Parameter != nil && decl.Declarator != nil {
+			t.Parameter(decl, param)
+		}
+	}
+	return params, list.Ellipsis
+}
+
+// AdjustParameter returns the type of a parameter declared with type t:
+// arrays become pointers to their elements and functions pointers to
+// functions.
+func AdjustParameter(t types.Type) types.Type {
+	switch u := t.(type) {
+	case *types.Array:
+		return &types.Pointer{Elem: u.Elem}
+	case *types.Function:
+		return &types.Pointer{Elem: u}
+	}
+	return t
+}
+
+// literalConstant evaluates integer and character literals, possibly
+// signed and parenthesized.
+func (t *Typer) literalConstant(n *ast.ConstantExpression) (int64, bool) {
+	var value, ok = literal(n)
+	if !ok {
+		t.Diagnostics.Errorf(n.Pos, "expression is not an integer constant expression")
+	}
+	return value, ok
+}
+
+func literal(n ast.Node) (int64, bool) {
+	for {
+		switch e := n.(type) {
+		case *ast.UnaryExpression:
+			if e.SizeOfTypeName != nil || len(e.UnaryOperators) > 0 {
+				return 0, false
+			}
+			if e.UnaryOperatorOnCast != nil {
+				var value, ok = literal(e.CastExpression)
+				switch *e.UnaryOperatorOnCast.Operator {
+				case "-":
+					return -value, ok
+				case "+":
+					return value, ok
+				}
+				return 0, false
+			}
+		case *ast.PrimaryExpression:
+			switch {
+			case e.Int != nil:
+				var value, err = strconv.ParseInt(*e.Int, 0, 64)
+				return value, err == nil
+			case e.Char != nil:
+				return int64(int8((*e.Char)[0])), len(*e.Char) == 1
+			case e.Expression != nil:
+				return literal(e.Expression)
+			}
+			return 0, false
+		}
+		var children = ast.Children(n)
+		if len(children) != 1 {
+			return 0, false
+		}
+		n = children[0]
+	}
+}
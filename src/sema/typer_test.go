@@ -0,0 +1,130 @@
+package sema
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/diag"
+	"lazarus-c/src/types"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// spell describes t in words, reading its derivations outwards from the
+// name as the spiral rule does.
+func spell(t types.Type) string {
+	switch t := t.(type) {
+	case *types.Pointer:
+		return "pointer to " + spell(t.Elem)
+	case *types.Array:
+		if t.Kind == types.Sized {
+			return "array " + strconv.FormatInt(t.Len, 10) + " of " + spell(t.Elem)
+		}
+		return "array of " + spell(t.Elem)
+	case *types.Function:
+		var params []string
+		for _, param := range t.Params {
+			params = append(params, types.Declaration(param.Type, ""))
+		}
+		if t.Variadic {
+			params = append(params, "...")
+		}
+		return "function(" + strings.Join(params, ", ") + ") returning " + spell(t.Result)
+	case *types.Qualified:
+		return t.Quals.String() + " " + spell(t.Elem)
+	}
+	return t.String()
+}
+
+var declarators = []struct {
+	src   string
+	name  string
+	spelt string
+	// decl is the declaration written back by types.Declaration, when
+	// it differs from src.
+	decl string
+}{
+	{src: "int x", name: "x", spelt: "int"},
+	{src: "int *p", name: "p", spelt: "pointer to int"},
+	{src: "int **pp", name: "pp", spelt: "pointer to pointer to int"},
+	{src: "int a[3][4]", name: "a", spelt: "array 3 of array 4 of int"},
+	{src: "int *ap[3]", name: "ap", spelt: "array 3 of pointer to int"},
+	{src: "int (*pa)[3]", name: "pa", spelt: "pointer to array 3 of int"},
+	{src: "int *f(void)", name: "f", spelt: "function() returning pointer to int"},
+	{src: "int (*pf)(int, char)", name: "pf", spelt: "pointer to function(int, char) returning int"},
+	{src: "int (*fp[4])(char *, ...)", name: "fp", spelt: "array 4 of pointer to function(char *, ...) returning int"},
+	{src: "char *(*(*x)())[]", name: "x", spelt: "pointer to function() returning pointer to array of pointer to char"},
+	{src: "char *(*(**foo[][8])())[]", name: "foo", spelt: "array of array 8 of pointer to pointer to function() returning pointer to array of pointer to char"},
+	{src: "void (*signal(int, void (*)(int)))(int)", name: "signal",
+		spelt: "function(int, void (*)(int)) returning pointer to function(int) returning void"},
+	{src: "int (*(*fpa)(int))[5]", name: "fpa", spelt: "pointer to function(int) returning pointer to array 5 of int"},
+	{src: "const char *s", name: "s", spelt: "pointer to const char"},
+	{src: "char *const s", name: "s", spelt: "const pointer to char"},
+	{src: "const int *volatile *const p", name: "p", spelt: "const pointer to volatile pointer to const int"},
+	{src: "int ((x))", name: "x", spelt: "int", decl: "int x"},
+	{src: "int (*(a))[2]", name: "a", spelt: "pointer to array 2 of int", decl: "int (*a)[2]"},
+	{src: "int g(int a[3], void f(void))", name: "g", spelt: "function(int *, void (*)(void)) returning int",
+		decl: "int g(int *a, void (*f)(void))"},
+}
+
+func TestDeclarator(t *testing.T) {
+	for _, test := range declarators {
+		var unit, err = ast.ParseString(test.src + ";")
+		if err != nil {
+			t.Errorf("parsing %q: %v", test.src, err)
+			continue
+		}
+		var decl = unit.ExternalDeclarations[0].Declaration
+		var diags diag.List
+		var name, typ, _ = NewTyper(&diags).Declaration(decl.DeclarationSpecifiers, decl.InitDeclaratorList.InitDeclarators[0].Declarator)
+		if len(diags) > 0 {
+			t.Errorf("%s: unexpected diagnostic %s", test.src, diags[0].Message)
+			continue
+		}
+		if name != test.name {
+			t.Errorf("%s: declares %s instead of %s", test.src, name, test.name)
+		}
+		if got := spell(typ); got != test.spelt {
+			t.Errorf("%s: declares %s as\n\t%s\ninstead of\n\t%s", test.src, name, got, test.spelt)
+		}
+		var want = test.decl
+		if want == "" {
+			want = test.src
+		}
+		if got := types.Declaration(typ, name); got != want {
+			t.Errorf("%s: written back as %s", test.src, got)
+		}
+	}
+}
+
+var illegalDeclarators = []struct {
+	src     string
+	message string
+}{
+	{"int f(void)[3]", "function cannot return array type int[3]"},
+	{"int (*f(void))(void)(void)", "function cannot return function type int(void)"},
+	{"int g(void)(int)", "function cannot return function type int(int)"},
+	{"int a[2](void)", "declaration of array of functions"},
+	{"int (*pa[3])[2](void)", "declaration of array of functions"},
+	{"void v[4]", "array has incomplete element type void"},
+	{"int n[-1]", "array has negative size"},
+}
+
+func TestIllegalDeclarator(t *testing.T) {
+	for _, test := range illegalDeclarators {
+		var unit, err = ast.ParseString(test.src + ";")
+		if err != nil {
+			t.Errorf("parsing %q: %v", test.src, err)
+			continue
+		}
+		var decl = unit.ExternalDeclarations[0].Declaration
+		var diags diag.List
+		NewTyper(&diags).Declaration(decl.DeclarationSpecifiers, decl.InitDeclaratorList.InitDeclarators[0].Declarator)
+		var messages []string
+		for _, d := range diags {
+			messages = append(messages, d.Message)
+		}
+		if len(messages) != 1 || messages[0] != test.message {
+			t.Errorf("%s: reported %q instead of %q", test.src, messages, test.message)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package sema
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/cfg"
+)
+
+// initialization warns about the local variables of scalar type that the
+// graph g of a function may read before assigning them. A variable is
+// initialized after a block on every path reaching it when it is in the
+// must set of the block, and on some path when it is in its may set.
+// The operands of &&, || and ?: have blocks of their own, so that both
+// arms of c ? (x = 1) : (x = 2) initialize x while a && (x = 1) may not.
+// Variables whose address is taken may be assigned through a pointer and
+// are left out.
+func (c *checker) initialization(body *ast.CompoundStatement, g *cfg.CFG) {
+	var escaped = addressTaken(c.info, body)
+	var vars = map[*Object]int{}
+	for _, block := range g.Blocks {
+		for _, n := range block.Nodes {
+			var d, ok = n.(*ast.InitDeclarator)
+			if !ok {
+				continue
+			}
+			var obj = c.info.Defs[ast.DeclaredName(d.Declarator)]
+			if obj == nil || obj.Kind != Variable || !tracked(obj, escaped) {
+				continue
+			}
+			if _, ok := vars[obj]; !ok {
+				vars[obj] = len(vars)
+			}
+		}
+	}
+	if len(vars) == 0 {
+		return
+	}
+
+	var preds = map[*cfg.Block][]*cfg.Block{}
+	for _, block := range g.Blocks {
+		for _, succ := range block.Succs {
+			preds[succ] = append(preds[succ], block)
+		}
+	}
+
+	// The must sets start full, shrinking and the may sets growing until
+	// they settle.
+	var all = newBitSet(len(vars))
+	for _, idx := range vars {
+		all.set(idx)
+	}
+	var must = map[*cfg.Block]bitSet{}
+	var may = map[*cfg.Block]bitSet{}
+	for _, block := range g.Blocks {
+		must[block], may[block] = all, newBitSet(len(vars))
+	}
+
+	var reported = map[*Object]bool{}
+	var transfer = func(block *cfg.Block, report bool) (bitSet, bitSet) {
+		var blockMust, blockMay = all.clone(), newBitSet(len(vars))
+		if block == g.Blocks[0] {
+			blockMust = newBitSet(len(vars))
+		}
+		for _, pred := range preds[block] {
+			if pred.Live {
+				blockMust.intersect(must[pred])
+				blockMay.union(may[pred])
+			}
+		}
+		for _, n := range block.Nodes {
+			c.accesses(n, func(a access) {
+				var idx, ok = vars[a.obj]
+				if !ok {
+					return
+				}
+				switch a.kind {
+				case read:
+					if report && !blockMust.has(idx) && !reported[a.obj] {
+						reported[a.obj] = true
+						c.uninitialized(a, blockMay.has(idx))
+					}
+				case declare:
+					// A declaration reached again by a loop makes its
+					// variable indeterminate.
+					blockMust.clear(idx)
+					blockMay.clear(idx)
+				default:
+					blockMay.set(idx)
+					blockMust.set(idx)
+				}
+			})
+		}
+		return blockMust, blockMay
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, block := range g.Blocks {
+			if !block.Live {
+				continue
+			}
+			var blockMust, blockMay = transfer(block, false)
+			if !blockMust.equal(must[block]) || !blockMay.equal(may[block]) {
+				must[block], may[block] = blockMust, blockMay
+				changed = true
+			}
+		}
+	}
+	for _, block := range g.Blocks {
+		if block.Live {
+			transfer(block, true)
+		}
+	}
+}
+
+// uninitialized reports the read a of a variable that is not initialized
+// on any path reaching it, or only on some of them when maybe is set.
+func (c *checker) uninitialized(a access, maybe bool) {
+	var pos = ast.Pos(a.node)
+	if maybe && a.obj.Silenced["maybe-uninitialized"] || !maybe && a.obj.Silenced["uninitialized"] {
+		return
+	}
+	if maybe {
+		c.diags.Warnf(pos, "maybe-uninitialized", "variable %s may be used uninitialized", a.obj.Name).
+			Note(a.obj.Pos, "%s is declared here", a.obj.Name)
+	} else {
+		c.diags.Warnf(pos, "uninitialized", "variable %s is used uninitialized", a.obj.Name).
+			Note(a.obj.Pos, "%s is declared here", a.obj.Name)
+	}
+}
+
+// bitSet is a set of small integers.
+type bitSet []uint64
+
+func newBitSet(n int) bitSet {
+	return make(bitSet, (n+63)/64)
+}
+
+func (s bitSet) has(i int) bool { return s[i/64]&(1<<(i%64)) != 0 }
+func (s bitSet) set(i int)      { s[i/64] |= 1 << (i % 64) }
+func (s bitSet) clear(i int)    { s[i/64] &^= 1 << (i % 64) }
+
+func (s bitSet) clone() bitSet {
+	return append(bitSet(nil), s...)
+}
+
+func (s bitSet) intersect(t bitSet) {
+	for i := range s {
+		s[i] &= t[i]
+	}
+}
+
+func (s bitSet) union(t bitSet) {
+	for i := range s {
+		s[i] |= t[i]
+	}
+}
+
+func (s bitSet) equal(t bitSet) bool {
+	for i := range s {
+		if s[i] != t[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package sema
+
+import (
+	"lazarus-c/src/ast"
+	"lazarus-c/src/cfg"
+	"sort"
+)
+
+// unused warns about the local variables, parameters and labels that are
+// never referred to, and about the static functions and variables of the
+// file that are not either.
+func (c *checker) unused() {
+	var used = map[*Object]bool{}
+	for _, obj := range c.info.Uses {
+		used[obj] = true
+	}
+	var objects []*Object
+	var seen = map[*Object]bool{}
+	for _, obj := range c.info.Defs {
+		if !seen[obj] && !used[obj] && !obj.Unused && obj.Scope != nil {
+			seen[obj] = true
+			objects = append(objects, obj)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Pos.Offset < objects[j].Pos.Offset })
+
+	for _, obj := range objects {
+		var file = obj.Scope.Kind == FileScope
+		var name, what string
+		switch {
+		case obj.Kind == Variable && obj.Storage != Extern && (!file || obj.Storage == Static):
+			name, what = "unused-variable", "variable"
+		case obj.Kind == Parameter && obj.Defined:
+			name, what = "unused-parameter", "parameter"
+		case obj.Kind == Function && file && obj.Storage == Static && obj.Defined:
+			name, what = "unused-function", "function"
+		case obj.Kind == Label:
+			name, what = "unused-label", "label"
+		}
+		if name != "" && !obj.Silenced[name] {
+			c.diags.Warnf(obj.Pos, name, "unused %s %s", what, obj.Name)
+		}
+	}
+}
+
+// deadStores warns about the values stored to the local variables of the
+// graph g of a function that are never read, overwritten or lost at the
+// end of the function first. It computes the live variables, whose value
+// may be read later, at the end of each block.
+func (c *checker) deadStores(body *ast.CompoundStatement, g *cfg.CFG) {
+	var escaped = addressTaken(c.info, body)
+	var vars = map[*Object]int{}
+	var accesses = map[*cfg.Block][]access{}
+	for _, block := range g.Blocks {
+		if !block.Live {
+			continue
+		}
+		for _, n := range block.Nodes {
+			c.accesses(n, func(a access) {
+				if !tracked(a.obj, escaped) || a.obj.Unused {
+					return
+				}
+				if _, ok := vars[a.obj]; !ok {
+					vars[a.obj] = len(vars)
+				}
+				accesses[block] = append(accesses[block], a)
+			})
+		}
+	}
+	if len(vars) == 0 {
+		return
+	}
+
+	var live = map[*cfg.Block]bitSet{}
+	var transfer = func(block *cfg.Block, report bool) bitSet {
+		var set = newBitSet(len(vars))
+		for _, succ := range block.Succs {
+			if live[succ] != nil {
+				set.union(live[succ])
+			}
+		}
+		var list = accesses[block]
+		for idx := len(list) - 1; idx >= 0; idx-- {
+			var a = list[idx]
+			var v = vars[a.obj]
+			switch a.kind {
+			case read:
+				set.set(v)
+				continue
+			case assign, update, increment, initialize:
+				if report && !set.has(v) && c.reportStore(a) && !a.obj.Silenced["dead-store"] {
+					c.diags.Warnf(ast.Pos(a.node), "dead-store", "value stored to %s is never read", a.obj.Name)
+				}
+			}
+			set.clear(v)
+		}
+		return set
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for idx := len(g.Blocks) - 1; idx >= 0; idx-- {
+			var block = g.Blocks[idx]
+			if !block.Live {
+				continue
+			}
+			var set = transfer(block, false)
+			if live[block] == nil || !set.equal(live[block]) {
+				live[block] = set
+				changed = true
+			}
+		}
+	}
+	for _, block := range g.Blocks {
+		if block.Live {
+			transfer(block, true)
+		}
+	}
+}
+
+// reportStore reports whether the dead store a is worth a warning.
+// Increments are often left at the end of loops, and initializers with a
+// constant value are a common precaution.
+func (c *checker) reportStore(a access) bool {
+	switch a.kind {
+	case increment:
+		return false
+	case initialize:
+		var init = a.node.(*ast.InitDeclarator).Initializer
+		return init.AssignmentExpression != nil && !c.info.Types[init.AssignmentExpression].Constant
+	}
+	return true
+}
@@ -0,0 +1,306 @@
+package types
+
+// IsInteger reports whether t is an integer or enumeration type.
+func IsInteger(t Type) bool {
+	switch t := unqualified(t).(type) {
+	case *Basic:
+		return t.Kind >= Char && t.Kind <= UnsignedLongLong
+	case *Enum:
+		return true
+	}
+	return false
+}
+
+// IsFloating reports whether t is a floating type.
+func IsFloating(t Type) bool {
+	var basic, ok = unqualified(t).(*Basic)
+	return ok && basic.Kind >= Float
+}
+
+// IsArithmetic reports whether t is an integer or floating type.
+func IsArithmetic(t Type) bool {
+	return IsInteger(t) || IsFloating(t)
+}
+
+// IsScalar reports whether t is an arithmetic or pointer type.
+func IsScalar(t Type) bool {
+	var _, ok = unqualified(t).(*Pointer)
+	return ok || IsArithmetic(t)
+}
+
+// IsVoid reports whether t is void, qualified or not.
+func IsVoid(t Type) bool {
+	var basic, ok = unqualified(t).(*Basic)
+	return ok && basic.Kind == Void
+}
+
+// IsComplete reports whether the size of t is known: void, unsized
+// arrays, functions and undefined structures or enumerations are
+// incomplete.
+func IsComplete(t Type) bool {
+	switch t := unqualified(t).(type) {
+	case *Basic:
+		return t.Kind != Void
+	case *Array:
+		return t.Kind != Unsized && IsComplete(t.Elem)
+	case *Function:
+		return false
+	case *Struct:
+		return t.Complete
+	case *Enum:
+		return t.Complete
+	}
+	return true
+}
+
+func unqualified(t Type) Type {
+	var u, _ = Unqualified(t)
+	return u
+}
+
+// Identical reports whether a and b are the same type.
+func Identical(a, b Type) bool {
+	if a == b {
+		return true
+	}
+	switch a := a.(type) {
+	case *Basic:
+		var b, ok = b.(*Basic)
+		return ok && a.Kind == b.Kind
+	case *Pointer:
+		var b, ok = b.(*Pointer)
+		return ok && Identical(a.Elem, b.Elem)
+	case *Array:
+		var b, ok = b.(*Array)
+		return ok && a.Kind == b.Kind && (a.Kind != Sized || a.Len == b.Len) && Identical(a.Elem, b.Elem)
+	case *Function:
+		var b, ok = b.(*Function)
+		if !ok || a.Prototype != b.Prototype || a.Variadic != b.Variadic || len(a.Params) != len(b.Params) || !Identical(a.Result, b.Result) {
+			return false
+		}
+		for idx := range a.Params {
+			if !Identical(a.Params[idx].Type, b.Params[idx].Type) {
+				return false
+			}
+		}
+		return true
+	case *Qualified:
+		var b, ok = b.(*Qualified)
+		return ok && a.Quals == b.Quals && Identical(a.Elem, b.Elem)
+	}
+	// Structures and enumerations are only identical to themselves.
+	return false
+}
+
+// Compatible reports whether a and b are compatible types, that is
+// whether declarations of the same object or function may use them.
+func Compatible(a, b Type) bool {
+	return compatible(a, b, nil)
+}
+
+// CompatibleAcross reports whether a and b, the types of declarations in
+// different translation units, are compatible. Structures, unions and
+// enumerations of different units are compatible when they have the same
+// tag and members, rather than only when they are the same type.
+func CompatibleAcross(a, b Type) bool {
+	return compatible(a, b, map[[2]Type]bool{})
+}
+
+// compatible implements Compatible, or CompatibleAcross when assumed is
+// not nil. assumed then holds the pairs of structures being compared,
+// which are assumed compatible to end the recursion of types pointing to
+// themselves.
+func compatible(a, b Type, assumed map[[2]Type]bool) bool {
+	var ua, qa = Unqualified(a)
+	var ub, qb = Unqualified(b)
+	if qa != qb {
+		return false
+	}
+	if ua == ub {
+		return true
+	}
+
+	switch a := ua.(type) {
+	case *Basic:
+		switch b := ub.(type) {
+		case *Basic:
+			return a.Kind == b.Kind
+		case *Enum:
+			return b.Underlying != nil && a.Kind == b.Underlying.Kind
+		}
+	case *Enum:
+		switch b := ub.(type) {
+		case *Basic:
+			return a.Underlying != nil && a.Underlying.Kind == b.Kind
+		case *Enum:
+			return assumed != nil && compatibleEnums(a, b)
+		}
+	case *Struct:
+		var b, ok = ub.(*Struct)
+		return ok && assumed != nil && compatibleStructs(a, b, assumed)
+	case *Pointer:
+		var b, ok = ub.(*Pointer)
+		return ok && compatible(a.Elem, b.Elem, assumed)
+	case *Array:
+		var b, ok = ub.(*Array)
+		return ok && compatible(a.Elem, b.Elem, assumed) && (a.Kind != Sized || b.Kind != Sized || a.Len == b.Len)
+	case *Function:
+		var b, ok = ub.(*Function)
+		return ok && compatibleFunctions(a, b, assumed)
+	}
+	return false
+}
+
+func compatibleFunctions(a, b *Function, assumed map[[2]Type]bool) bool {
+	if !compatible(a.Result, b.Result, assumed) {
+		return false
+	}
+	if !a.Prototype && !b.Prototype {
+		return true
+	}
+	if !a.Prototype || !b.Prototype {
+		// A prototype is compatible with a function declared without
+		// one if no argument needs the default promotions.
+		var proto = a
+		if !a.Prototype {
+			proto = b
+		}
+		if proto.Variadic {
+			return false
+		}
+		for _, param := range proto.Params {
+			var t = unqualified(param.Type)
+			if !compatible(t, DefaultPromotion(t), assumed) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if a.Variadic != b.Variadic || len(a.Params) != len(b.Params) {
+		return false
+	}
+	for idx := range a.Params {
+		if !compatible(unqualified(a.Params[idx].Type), unqualified(b.Params[idx].Type), assumed) {
+			return false
+		}
+	}
+	return true
+}
+
+// compatibleStructs reports whether the structures a and b of different
+// translation units are compatible. An incomplete structure is compatible
+// with any of the same tag.
+func compatibleStructs(a, b *Struct, assumed map[[2]Type]bool) bool {
+	if a.Union != b.Union || a.Tag != b.Tag {
+		return false
+	}
+	if !a.Complete || !b.Complete || assumed[[2]Type{a, b}] {
+		return true
+	}
+	assumed[[2]Type{a, b}] = true
+	if len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for idx, field := range a.Fields {
+		var other = b.Fields[idx]
+		if field.Name != other.Name || field.BitField != other.BitField || field.Bits != other.Bits || !compatible(field.Type, other.Type, assumed) {
+			return false
+		}
+	}
+	return true
+}
+
+// compatibleEnums reports whether the enumerations a and b of different
+// translation units are compatible.
+func compatibleEnums(a, b *Enum) bool {
+	if a.Tag != b.Tag {
+		return false
+	}
+	if !a.Complete || !b.Complete {
+		return true
+	}
+	if len(a.Constants) != len(b.Constants) {
+		return false
+	}
+	for idx, constant := range a.Constants {
+		if constant.Name != b.Constants[idx].Name || constant.Value != b.Constants[idx].Value {
+			return false
+		}
+	}
+	return true
+}
+
+// Composite returns the composite type of two compatible types: the
+// type combining what each of them tells, such as the length of an array
+// or the parameters of a prototype.
+func Composite(a, b Type) Type {
+	var ua, quals = Unqualified(a)
+	var ub, _ = Unqualified(b)
+
+	switch a := ua.(type) {
+	case *Pointer:
+		if b, ok := ub.(*Pointer); ok {
+			return Qualify(&Pointer{Composite(a.Elem, b.Elem)}, quals)
+		}
+	case *Array:
+		if b, ok := ub.(*Array); ok {
+			var composite = &Array{Composite(a.Elem, b.Elem), a.Kind, a.Len}
+			if a.Kind != Sized && b.Kind == Sized || a.Kind == Unsized && b.Kind == VariableLength {
+				composite.Kind, composite.Len = b.Kind, b.Len
+			}
+			return Qualify(composite, quals)
+		}
+	case *Function:
+		if b, ok := ub.(*Function); ok {
+			if !a.Prototype {
+				a, b = b, a
+			}
+			var composite = &Function{Composite(a.Result, b.Result), nil, a.Variadic, a.Prototype}
+			for idx, param := range a.Params {
+				var t = param.Type
+				if b.Prototype {
+					t = Composite(t, b.Params[idx].Type)
+				}
+				composite.Params = append(composite.Params, &Param{param.Name, t})
+			}
+			return composite
+		}
+	}
+	return a
+}
+
+// DefaultPromotion returns the type of an argument of type t passed
+// without a prototype: float becomes double and small integers go
+// through the integer promotions.
+func DefaultPromotion(t Type) Type {
+	if IsFloating(t) {
+		if unqualified(t).(*Basic).Kind == Float {
+			return Typ[Double]
+		}
+		return unqualified(t)
+	}
+	if IsInteger(t) {
+		return IntegerPromotion(t)
+	}
+	return t
+}
+
+// IntegerPromotion returns the type an operand of integer type t is
+// converted to in arithmetic: types of lower rank than int become int,
+// as int can represent all their values on the targets supported.
+func IntegerPromotion(t Type) Type {
+	switch u := unqualified(t).(type) {
+	case *Basic:
+		if u.Kind < Int {
+			return Typ[Int]
+		}
+		return u
+	case *Enum:
+		if u.Underlying == nil {
+			return Typ[Int]
+		}
+		return IntegerPromotion(u.Underlying)
+	}
+	return t
+}
@@ -0,0 +1,117 @@
+package types
+
+// Sizeof returns the size in bytes of the complete type t.
+func (target *Target) Sizeof(t Type) int64 {
+	switch t := unqualified(t).(type) {
+	case *Basic:
+		return target.Sizes[t.Kind]
+	case *Pointer:
+		return target.PointerSize
+	case *Array:
+		return t.Len * target.Sizeof(t.Elem)
+	case *Enum:
+		return target.Sizes[integerKind(t)]
+	case *Struct:
+		return target.Layout(t).Size
+	}
+	return 1
+}
+
+// Alignof returns the alignment in bytes of the complete type t.
+func (target *Target) Alignof(t Type) int64 {
+	switch t := unqualified(t).(type) {
+	case *Basic:
+		return target.Aligns[t.Kind]
+	case *Pointer:
+		return target.PointerAlign
+	case *Array:
+		return target.Alignof(t.Elem)
+	case *Enum:
+		return target.Aligns[integerKind(t)]
+	case *Struct:
+		return target.Layout(t).Align
+	}
+	return 1
+}
+
+// Layout describes the placement in memory of the fields of a structure
+// or union.
+type Layout struct {
+	Size   int64
+	Align  int64
+	Fields []FieldLayout
+}
+
+// FieldLayout is the placement of a field: its offset in bytes from the
+// start of the structure and, for bit-fields, the offset in bits of
+// their first bit from that byte. Size is the size in bytes of fields
+// other than bit-fields.
+type FieldLayout struct {
+	*Field
+	Offset int64
+	Bit    int
+	Size   int64
+}
+
+// Layout places the fields of the complete structure or union s.
+// Bit-fields are packed into the storage units of their declared type,
+// moving to the next unit rather than straddling two, and zero-width
+// bit-fields close the current unit. As in both supported ABIs, unnamed
+// bit-fields do not affect the alignment of the structure. The bit-fields
+// of packed structures follow each other without regard to units.
+// A flexible array member takes no room but aligns the structure.
+func (target *Target) Layout(s *Struct) *Layout {
+	var layout = &Layout{Align: 1}
+	var bit int64
+	var size int64
+	for _, field := range s.Fields {
+		if s.Union {
+			bit = 0
+		}
+		var packed = s.Packed || field.Packed
+		var align = target.fieldAlign(field, packed)
+		if field.BitField {
+			var unit = target.Alignof(field.Type) * 8
+			switch {
+			case field.Bits == 0:
+				bit = roundUp(bit, unit)
+				continue
+			case !packed && bit/unit != (bit+int64(field.Bits)-1)/unit:
+				bit = roundUp(bit, unit)
+			}
+			bit = roundUp(bit, field.Align*8)
+			layout.Fields = append(layout.Fields, FieldLayout{Field: field, Offset: bit / 8, Bit: int(bit % 8)})
+			bit += int64(field.Bits)
+			if field.Name == "" {
+				size = max(size, roundUp(bit, 8)/8)
+				continue
+			}
+		} else {
+			var size = target.Sizeof(field.Type)
+			bit = roundUp(bit, align*8)
+			layout.Fields = append(layout.Fields, FieldLayout{Field: field, Offset: bit / 8, Size: size})
+			bit += size * 8
+		}
+		layout.Align = max(layout.Align, align)
+		size = max(size, roundUp(bit, 8)/8)
+	}
+	layout.Align = max(layout.Align, s.Align)
+	layout.Size = roundUp(size, layout.Align)
+	return layout
+}
+
+// fieldAlign returns the alignment of field within its structure.
+func (target *Target) fieldAlign(field *Field, packed bool) int64 {
+	var align = target.Alignof(field.Type)
+	if packed {
+		align = 1
+	}
+	return max(align, field.Align)
+}
+
+func roundUp(n, align int64) int64 {
+	if align <= 1 {
+		return n
+	}
+	return (n + align - 1) / align * align
+}
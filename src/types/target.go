@@ -0,0 +1,160 @@
+package types
+
+// Target describes the data model of a machine: the sizes and
+// alignments, in bytes, of the basic types and pointers, and whether
+// plain char is signed.
+type Target struct {
+	Name         string
+	Sizes        [LongDouble + 1]int64
+	Aligns       [LongDouble + 1]int64
+	PointerSize  int64
+	PointerAlign int64
+	CharSigned   bool
+}
+
+// The LP64 data model shared by the supported targets.
+var lp64Sizes = [LongDouble + 1]int64{
+	Void:             1,
+	Char:             1,
+	SignedChar:       1,
+	UnsignedChar:     1,
+	Short:            2,
+	UnsignedShort:    2,
+	Int:              4,
+	UnsignedInt:      4,
+	Long:             8,
+	UnsignedLong:     8,
+	LongLong:         8,
+	UnsignedLongLong: 8,
+	Float:            4,
+	Double:           8,
+	LongDouble:       16,
+}
+
+// X86_64 is the target of the System V ABI for x86-64.
+var X86_64 = &Target{
+	Name:         "x86_64",
+	Sizes:        lp64Sizes,
+	Aligns:       lp64Sizes,
+	PointerSize:  8,
+	PointerAlign: 8,
+	CharSigned:   true,
+}
+
+// AArch64 is the target of the AAPCS64 for 64-bit Arm, where plain char
+// is unsigned.
+var AArch64 = &Target{
+	Name:         "aarch64",
+	Sizes:        lp64Sizes,
+	Aligns:       lp64Sizes,
+	PointerSize:  8,
+	PointerAlign: 8,
+	CharSigned:   false,
+}
+
+// Targets lists the supported targets by name.
+var Targets = map[string]*Target{
+	X86_64.Name:  X86_64,
+	AArch64.Name: AArch64,
+}
+
+// Bits returns the width in bits of the integer type t.
+func (target *Target) Bits(t Type) int {
+	return int(target.Sizes[integerKind(t)] * 8)
+}
+
+// IsSigned reports whether the integer type t is signed.
+func (target *Target) IsSigned(t Type) bool {
+	switch integerKind(t) {
+	case Char:
+		return target.CharSigned
+	case SignedChar, Short, Int, Long, LongLong:
+		return true
+	}
+	return false
+}
+
+// integerKind returns the kind of the integer type t, enumerations
+// having the kind of their underlying type.
+func integerKind(t Type) Kind {
+	switch t := unqualified(t).(type) {
+	case *Basic:
+		return t.Kind
+	case *Enum:
+		if t.Underlying != nil {
+			return t.Underlying.Kind
+		}
+	}
+	return Int
+}
+
+// Rank returns the conversion rank of the integer type t: types of the
+// same width and different signedness have the same rank.
+func Rank(t Type) int {
+	switch integerKind(t) {
+	case Char, SignedChar, UnsignedChar:
+		return 1
+	case Short, UnsignedShort:
+		return 2
+	case Int, UnsignedInt:
+		return 3
+	case Long, UnsignedLong:
+		return 4
+	}
+	return 5
+}
+
+// Unsigned returns the unsigned type corresponding to the integer type t.
+func Unsigned(t Type) *Basic {
+	switch integerKind(t) {
+	case Char, SignedChar, UnsignedChar:
+		return Typ[UnsignedChar]
+	case Short, UnsignedShort:
+		return Typ[UnsignedShort]
+	case Int, UnsignedInt:
+		return Typ[UnsignedInt]
+	case Long, UnsignedLong:
+		return Typ[UnsignedLong]
+	}
+	return Typ[UnsignedLongLong]
+}
+
+// ArithmeticConversion returns the common type of the operands of an
+// arithmetic operator with types a and b, according to the usual
+// arithmetic conversions.
+func (target *Target) ArithmeticConversion(a, b Type) Type {
+	for _, kind := range []Kind{LongDouble, Double, Float} {
+		if isKind(a, kind) || isKind(b, kind) {
+			return Typ[kind]
+		}
+	}
+
+	a, b = IntegerPromotion(a), IntegerPromotion(b)
+	if integerKind(a) == integerKind(b) {
+		return Typ[integerKind(a)]
+	}
+	var signedA, signedB = target.IsSigned(a), target.IsSigned(b)
+	if signedA == signedB {
+		if Rank(a) >= Rank(b) {
+			return Typ[integerKind(a)]
+		}
+		return Typ[integerKind(b)]
+	}
+
+	var signed, unsigned = a, b
+	if signedB {
+		signed, unsigned = b, a
+	}
+	switch {
+	case Rank(unsigned) >= Rank(signed):
+		return Typ[integerKind(unsigned)]
+	case target.Bits(signed) > target.Bits(unsigned):
+		return Typ[integerKind(signed)]
+	}
+	return Unsigned(signed)
+}
+
+func isKind(t Type, kind Kind) bool {
+	var basic, ok = unqualified(t).(*Basic)
+	return ok && basic.Kind == kind
+}
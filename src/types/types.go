@@ -0,0 +1,227 @@
+// Package types models the types of C: basic arithmetic types, derived
+// pointer, array and function types, structures, unions, enumerations and
+// their qualified versions.
+package types
+
+// Type is implemented by every type of this package.
+type Type interface {
+	// String returns the type in the declaration syntax of C, as a
+	// declaration without a name.
+	String() string
+	aType()
+}
+
+// Kind is the kind of a basic type.
+type Kind int
+
+const (
+	Void Kind = iota
+	Char
+	SignedChar
+	UnsignedChar
+	Short
+	UnsignedShort
+	Int
+	UnsignedInt
+	Long
+	UnsignedLong
+	LongLong
+	UnsignedLongLong
+	Float
+	Double
+	LongDouble
+)
+
+var kindNames = [...]string{
+	Void:             "void",
+	Char:             "char",
+	SignedChar:       "signed char",
+	UnsignedChar:     "unsigned char",
+	Short:            "short",
+	UnsignedShort:    "unsigned short",
+	Int:              "int",
+	UnsignedInt:      "unsigned int",
+	Long:             "long",
+	UnsignedLong:     "unsigned long",
+	LongLong:         "long long",
+	UnsignedLongLong: "unsigned long long",
+	Float:            "float",
+	Double:           "double",
+	LongDouble:       "long double",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Basic is void or one of the arithmetic types. The types of Typ are the
+// only instances needed, though any Basic of the same kind is identical.
+type Basic struct {
+	Kind Kind
+}
+
+// Typ holds a Basic type for each kind.
+var Typ = func() [LongDouble + 1]*Basic {
+	var typ [LongDouble + 1]*Basic
+	for kind := range typ {
+		typ[kind] = &Basic{Kind(kind)}
+	}
+	return typ
+}()
+
+// Pointer is a pointer to Elem.
+type Pointer struct {
+	Elem Type
+}
+
+// ArrayKind tells how the length of an array is known.
+type ArrayKind int
+
+const (
+	// Sized arrays have a constant length.
+	Sized ArrayKind = iota
+	// Unsized arrays have an unspecified length and are incomplete.
+	Unsized
+	// VariableLength arrays have a length only known at run time.
+	VariableLength
+)
+
+// Array is an array of Elem. Len is only meaningful for sized arrays.
+type Array struct {
+	Elem Type
+	Kind ArrayKind
+	Len  int64
+}
+
+// Param is a parameter of a function type. Its name is empty when the
+// declaration does not give one.
+type Param struct {
+	Name string
+	Type Type
+}
+
+// Function is the type of a function returning Result. Functions declared
+// with an identifier list or empty parentheses have no prototype, hence no
+// parameter information; f(void) is a prototype without parameters.
+type Function struct {
+	Result    Type
+	Params    []*Param
+	Variadic  bool
+	Prototype bool
+}
+
+// Field is a member of a structure or union. Bit-fields have BitField set
+// and their width in Bits.
+type Field struct {
+	Name     string
+	Type     Type
+	BitField bool
+	Bits     int
+	// Packed and Align, when not zero, override the alignment of the
+	// type of the field: packed fields are aligned on bytes, or on
+	// Align when set.
+	Packed bool
+	Align  int64
+}
+
+// Struct is a structure or union type. Every declaration of a structure
+// body creates a distinct type, so structures are identical only to
+// themselves. Structures declared without a body are incomplete until
+// Complete is set.
+type Struct struct {
+	Union    bool
+	Tag      string
+	Fields   []*Field
+	Complete bool
+	// Packed packs every field, and Align, when not zero, is the least
+	// alignment of the structure.
+	Packed bool
+	Align  int64
+}
+
+// Field returns the field with the given name, or nil.
+func (s *Struct) Field(name string) *Field {
+	for _, field := range s.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// EnumConstant is a constant declared in an enumeration.
+type EnumConstant struct {
+	Name  string
+	Value int64
+}
+
+// Enum is an enumeration type. Like structures, every enumeration is a
+// distinct type. Values of the type are represented by Underlying, an
+// integer type.
+type Enum struct {
+	Tag        string
+	Constants  []*EnumConstant
+	Underlying *Basic
+	Complete   bool
+	// Fixed is set when the declaration gave the underlying type, rather
+	// than letting it be chosen from the values of the constants.
+	Fixed bool
+}
+
+// Qualifiers is a set of type qualifiers.
+type Qualifiers int
+
+const (
+	Const Qualifiers = 1 << iota
+	Volatile
+)
+
+func (q Qualifiers) String() string {
+	switch q {
+	case Const:
+		return "const"
+	case Volatile:
+		return "volatile"
+	case Const | Volatile:
+		return "const volatile"
+	}
+	return ""
+}
+
+// Qualified is the version of Elem with the qualifiers Quals. Elem is
+// never itself qualified; use Qualify to build qualified types.
+type Qualified struct {
+	Elem  Type
+	Quals Qualifiers
+}
+
+// Qualify returns t with the qualifiers q added. Qualifying an array type
+// qualifies its elements instead.
+func Qualify(t Type, q Qualifiers) Type {
+	if q == 0 {
+		return t
+	}
+	switch t := t.(type) {
+	case *Qualified:
+		return &Qualified{t.Elem, t.Quals | q}
+	case *Array:
+		return &Array{Qualify(t.Elem, q), t.Kind, t.Len}
+	}
+	return &Qualified{t, q}
+}
+
+// Unqualified returns t without its qualifiers, and those qualifiers.
+func Unqualified(t Type) (Type, Qualifiers) {
+	if q, ok := t.(*Qualified); ok {
+		return q.Elem, q.Quals
+	}
+	return t, 0
+}
+
+func (*Basic) aType()     {}
+func (*Pointer) aType()   {}
+func (*Array) aType()     {}
+func (*Function) aType()  {}
+func (*Struct) aType()    {}
+func (*Enum) aType()      {}
+func (*Qualified) aType() {}
@@ -0,0 +1,158 @@
+package types
+
+import "testing"
+
+var (
+	intType    = Typ[Int]
+	charType   = Typ[Char]
+	constInt   = Qualify(intType, Const)
+	intPtr     = &Pointer{intType}
+	point      = &Struct{Tag: "point", Fields: []*Field{{Name: "x", Type: intType}, {Name: "y", Type: intType}}, Complete: true}
+	color      = &Enum{Tag: "color", Constants: []*EnumConstant{{"red", 0}}, Underlying: Typ[UnsignedInt], Complete: true}
+	noProto    = &Function{Result: intType}
+	intOfInt   = &Function{Result: intType, Params: []*Param{{"n", intType}}, Prototype: true}
+	intOfChar  = &Function{Result: intType, Params: []*Param{{"c", charType}}, Prototype: true}
+	intOfVoid  = &Function{Result: intType, Prototype: true}
+	printfType = &Function{Result: intType, Params: []*Param{{"", &Pointer{Qualify(charType, Const)}}}, Variadic: true, Prototype: true}
+)
+
+func sized(elem Type, n int64) *Array { return &Array{elem, Sized, n} }
+func unsized(elem Type) *Array        { return &Array{elem, Unsized, 0} }
+
+var declarations = []struct {
+	t    Type
+	name string
+	decl string
+}{
+	{intType, "", "int"},
+	{Typ[UnsignedLongLong], "n", "unsigned long long n"},
+	{constInt, "c", "const int c"},
+	{intPtr, "p", "int *p"},
+	{Qualify(intPtr, Const), "p", "int *const p"},
+	{&Pointer{Qualify(charType, Const|Volatile)}, "", "const volatile char *"},
+	{sized(sized(intType, 4), 3), "a", "int a[3][4]"},
+	{&Pointer{sized(intType, 3)}, "pa", "int (*pa)[3]"},
+	{sized(intPtr, 3), "ap", "int *ap[3]"},
+	{unsized(charType), "s", "char s[]"},
+	{&Array{intType, VariableLength, 0}, "v", "int v[*]"},
+	{intOfInt, "f", "int f(int n)"},
+	{intOfVoid, "", "int(void)"},
+	{noProto, "g", "int g()"},
+	{printfType, "printf", "int printf(const char *, ...)"},
+	{&Pointer{intOfVoid}, "fp", "int (*fp)(void)"},
+	{&Function{Result: &Pointer{sized(intType, 5)}, Params: []*Param{{"", intType}}, Prototype: true}, "f", "int (*f(int))[5]"},
+	{point, "p", "struct point p"},
+	{&Struct{Union: true, Complete: true}, "u", "union {...} u"},
+	{color, "", "enum color"},
+}
+
+func TestDeclaration(t *testing.T) {
+	for _, test := range declarations {
+		if got := Declaration(test.t, test.name); got != test.decl {
+			t.Errorf("declared as %s instead of %s", got, test.decl)
+		}
+	}
+}
+
+var compatibles = []struct {
+	a, b       Type
+	compatible bool
+}{
+	{intType, Typ[Int], true},
+	{intType, Typ[Long], false},
+	{charType, Typ[SignedChar], false},
+	{intType, constInt, false},
+	{constInt, Qualify(Typ[Int], Const), true},
+	{intPtr, &Pointer{Typ[Int]}, true},
+	{intPtr, &Pointer{constInt}, false},
+	{sized(intType, 3), unsized(intType), true},
+	{sized(intType, 3), sized(intType, 4), false},
+	{sized(intType, 3), &Array{intType, VariableLength, 0}, true},
+	{intOfInt, noProto, true},
+	{intOfChar, noProto, false},
+	{printfType, noProto, false},
+	{intOfInt, &Function{Result: intType, Params: []*Param{{"m", constInt}}, Prototype: true}, true},
+	{intOfInt, intOfVoid, false},
+	{color, Typ[UnsignedInt], true},
+	{color, intType, false},
+	{point, point, true},
+	{point, &Struct{Tag: "point", Fields: point.Fields, Complete: true}, false},
+}
+
+func TestCompatible(t *testing.T) {
+	for _, test := range compatibles {
+		if Compatible(test.a, test.b) != test.compatible || Compatible(test.b, test.a) != test.compatible {
+			t.Errorf("%s and %s: compatible is %t", test.a, test.b, !test.compatible)
+		}
+	}
+}
+
+func TestCompatibleAcross(t *testing.T) {
+	var other = &Struct{Tag: "point", Fields: []*Field{{Name: "x", Type: intType}, {Name: "y", Type: intType}}, Complete: true}
+	var renamed = &Struct{Tag: "point", Fields: []*Field{{Name: "x", Type: intType}, {Name: "z", Type: intType}}, Complete: true}
+	var incomplete = &Struct{Tag: "point"}
+	var list = &Struct{Tag: "list", Complete: true}
+	list.Fields = []*Field{{Name: "next", Type: &Pointer{list}}}
+	var otherList = &Struct{Tag: "list", Complete: true}
+	otherList.Fields = []*Field{{Name: "next", Type: &Pointer{otherList}}}
+
+	for _, test := range []struct {
+		a, b       Type
+		compatible bool
+	}{
+		{point, other, true},
+		{point, renamed, false},
+		{point, incomplete, true},
+		{list, otherList, true},
+		{&Pointer{point}, &Pointer{other}, true},
+		{color, &Enum{Tag: "color", Constants: []*EnumConstant{{"red", 0}}, Underlying: Typ[UnsignedInt], Complete: true}, true},
+		{color, &Enum{Tag: "color", Constants: []*EnumConstant{{"red", 1}}, Underlying: Typ[UnsignedInt], Complete: true}, false},
+	} {
+		if CompatibleAcross(test.a, test.b) != test.compatible {
+			t.Errorf("%s and %s: compatible across units is %t", test.a, test.b, !test.compatible)
+		}
+	}
+}
+
+func TestComposite(t *testing.T) {
+	for _, test := range []struct {
+		a, b      Type
+		composite string
+	}{
+		{unsized(intType), sized(intType, 3), "int[3]"},
+		{sized(intType, 3), unsized(intType), "int[3]"},
+		{&Pointer{unsized(intType)}, &Pointer{sized(intType, 2)}, "int (*)[2]"},
+		{noProto, intOfInt, "int(int n)"},
+		{intOfInt, noProto, "int(int n)"},
+		{&Function{Result: intType, Params: []*Param{{"a", unsized(intType)}}, Prototype: true},
+			&Function{Result: intType, Params: []*Param{{"b", sized(intType, 4)}}, Prototype: true}, "int(int a[4])"},
+	} {
+		if got := Composite(test.a, test.b).String(); got != test.composite {
+			t.Errorf("%s and %s: composite %s instead of %s", test.a, test.b, got, test.composite)
+		}
+	}
+}
+
+func TestPromotion(t *testing.T) {
+	for _, test := range []struct {
+		t                 Type
+		integer, argument string
+	}{
+		{charType, "int", "int"},
+		{Typ[UnsignedShort], "int", "int"},
+		{Qualify(Typ[UnsignedChar], Const), "int", "int"},
+		{Typ[UnsignedInt], "unsigned int", "unsigned int"},
+		{Typ[Long], "long", "long"},
+		{&Enum{Tag: "small", Underlying: Typ[UnsignedChar], Complete: true}, "int", "int"},
+		{color, "unsigned int", "unsigned int"},
+		{Typ[Float], "float", "double"},
+		{intPtr, "int *", "int *"},
+	} {
+		if got := IntegerPromotion(test.t).String(); IsInteger(test.t) && got != test.integer {
+			t.Errorf("%s: promoted to %s instead of %s", test.t, got, test.integer)
+		}
+		if got := DefaultPromotion(test.t).String(); got != test.argument {
+			t.Errorf("%s: passed as %s instead of %s", test.t, got, test.argument)
+		}
+	}
+}
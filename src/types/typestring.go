@@ -0,0 +1,106 @@
+package types
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Declaration returns the declaration of name with type t in C syntax,
+// such as "int (*name[4])(char *, ...)". With an empty name, it is the
+// type name used in casts and sizeof.
+func Declaration(t Type, name string) string {
+	return declare(t, name, false)
+}
+
+// declare wraps inner, the declarator built so far, with the derivations
+// of t. suffixed reports whether inner ends with array or function
+// suffixes applied to nothing else.
+func declare(t Type, inner string, suffixed bool) string {
+	switch t := t.(type) {
+	case *Pointer:
+		return declarePointer(t, "", inner)
+	case *Array:
+		var suffix = "[]"
+		switch t.Kind {
+		case Sized:
+			suffix = "[" + strconv.FormatInt(t.Len, 10) + "]"
+		case VariableLength:
+			suffix = "[*]"
+		}
+		return declare(t.Elem, inner+suffix, suffixed || inner == "")
+	case *Function:
+		return declare(t.Result, inner+"("+params(t)+")", suffixed || inner == "")
+	case *Qualified:
+		if ptr, ok := t.Elem.(*Pointer); ok {
+			return declarePointer(ptr, t.Quals.String(), inner)
+		}
+		return t.Quals.String() + " " + declare(t.Elem, inner, suffixed)
+	}
+
+	var specifier = specifier(t)
+	if inner == "" || suffixed {
+		return specifier + inner
+	}
+	return specifier + " " + inner
+}
+
+func declarePointer(t *Pointer, quals string, inner string) string {
+	var declarator = "*" + quals
+	if quals != "" && inner != "" {
+		declarator += " "
+	}
+	declarator += inner
+	switch elem, _ := Unqualified(t.Elem); elem.(type) {
+	case *Array, *Function:
+		declarator = "(" + declarator + ")"
+	}
+	return declare(t.Elem, declarator, false)
+}
+
+func params(t *Function) string {
+	if !t.Prototype {
+		return ""
+	}
+	if len(t.Params) == 0 {
+		return "void"
+	}
+	var list []string
+	for _, param := range t.Params {
+		list = append(list, Declaration(param.Type, param.Name))
+	}
+	if t.Variadic {
+		list = append(list, "...")
+	}
+	return strings.Join(list, ", ")
+}
+
+// specifier returns the type specifier of a type that is not derived.
+func specifier(t Type) string {
+	switch t := t.(type) {
+	case *Basic:
+		return t.Kind.String()
+	case *Struct:
+		var keyword = "struct"
+		if t.Union {
+			keyword = "union"
+		}
+		if t.Tag == "" {
+			return keyword + " {...}"
+		}
+		return keyword + " " + t.Tag
+	case *Enum:
+		if t.Tag == "" {
+			return "enum {...}"
+		}
+		return "enum " + t.Tag
+	}
+	return "?"
+}
+
+func (t *Basic) String() string     { return Declaration(t, "") }
+func (t *Pointer) String() string   { return Declaration(t, "") }
+func (t *Array) String() string     { return Declaration(t, "") }
+func (t *Function) String() string  { return Declaration(t, "") }
+func (t *Struct) String() string    { return Declaration(t, "") }
+func (t *Enum) String() string      { return Declaration(t, "") }
+func (t *Qualified) String() string { return Declaration(t, "") }